@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"anki-converter/internal/services"
+)
+
+// maxUploadBytes caps the request body. Decks with media can be large, but
+// the converter only needs the collection database.
+const maxUploadBytes = 100 << 20
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	flag.Parse()
+
+	log.Printf("anki-converter API listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, newMux()))
+}
+
+// newMux builds the API routes, separated from main so tests can drive the
+// handlers through httptest.
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /convert", handleConvert)
+	mux.HandleFunc("GET /inspect", handleInspect)
+	return mux
+}
+
+// handleConvert converts a multipart .apkg upload ("file" field) to an EPUB.
+// Optional form fields: "preset" (device preset name) and "title".
+func handleConvert(w http.ResponseWriter, r *http.Request) {
+	data, filename, ok := readUpload(w, r)
+	if !ok {
+		return
+	}
+
+	preset := services.DevicePresets[0]
+	if name := r.FormValue("preset"); name != "" {
+		found := false
+		for _, p := range services.DevicePresets {
+			if strings.EqualFold(p.Name, name) {
+				preset, found = p, true
+				break
+			}
+		}
+		if !found {
+			httpError(w, http.StatusBadRequest, "unknown preset %q", name)
+			return
+		}
+	}
+
+	stem := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	if stem == "" || stem == "." {
+		stem = "deck"
+	}
+	title := r.FormValue("title")
+	if title == "" {
+		title = stem
+	}
+
+	result, err := services.Convert(data, preset, title)
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "convert apkg: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/epub+zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", stem+".epub"))
+	_, _ = w.Write(result.EPUBData)
+}
+
+// handleInspect parses a multipart .apkg upload and reports card counts and
+// detected deck metadata as JSON, without generating an EPUB.
+func handleInspect(w http.ResponseWriter, r *http.Request) {
+	data, _, ok := readUpload(w, r)
+	if !ok {
+		return
+	}
+
+	cards, info, err := services.ParseAPKGWithInfo(data)
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "parse apkg: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		CardCount     int      `json:"cardCount"`
+		Malformed     int      `json:"malformed"`
+		SchemaVersion int64    `json:"schemaVersion"`
+		Decks         []string `json:"decks"`
+	}{
+		CardCount:     len(cards),
+		Malformed:     info.Malformed,
+		SchemaVersion: info.Version,
+		Decks:         info.Decks,
+	})
+}
+
+// readUpload extracts the "file" part of a size-limited multipart request
+// and verifies it is a zip archive. On failure the response is already
+// written and ok is false.
+func readUpload(w http.ResponseWriter, r *http.Request) (data []byte, filename string, ok bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+	if err := r.ParseMultipartForm(maxUploadBytes); err != nil {
+		httpError(w, http.StatusBadRequest, "invalid multipart upload: %v", err)
+		return nil, "", false
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "missing \"file\" field: %v", err)
+		return nil, "", false
+	}
+	defer file.Close()
+
+	data, err = io.ReadAll(file)
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "read upload: %v", err)
+		return nil, "", false
+	}
+
+	// An .apkg is a zip archive; reject anything without the zip signature
+	// before handing it to the parser.
+	if !bytes.HasPrefix(data, []byte("PK")) {
+		httpError(w, http.StatusUnsupportedMediaType, "expected an .apkg (zip) upload, got %q", header.Filename)
+		return nil, "", false
+	}
+	return data, header.Filename, true
+}
+
+// httpError writes a plain-text error response with the given status.
+func httpError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	http.Error(w, fmt.Sprintf(format, args...), status)
+}
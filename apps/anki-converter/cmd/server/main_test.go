@@ -0,0 +1,188 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// buildTestAPKG fabricates a minimal .apkg: a zip holding a two-page SQLite
+// database whose notes table contains the given flds strings. It is a
+// condensed version of the builder the services tests use.
+func buildTestAPKG(t *testing.T, fldsValues []string) []byte {
+	t.Helper()
+
+	const pageSize = 4096
+
+	record := func(values ...interface{}) []byte {
+		var types, body bytes.Buffer
+		for _, v := range values {
+			switch val := v.(type) {
+			case int64: // small positive integers only
+				types.WriteByte(0x01)
+				body.WriteByte(byte(val))
+			case string:
+				serialType := int64(13 + len(val)*2)
+				if serialType > 0x7f {
+					types.Write([]byte{byte(serialType>>7) | 0x80, byte(serialType & 0x7f)})
+				} else {
+					types.WriteByte(byte(serialType))
+				}
+				body.WriteString(val)
+			}
+		}
+		var rec bytes.Buffer
+		rec.WriteByte(byte(1 + types.Len()))
+		rec.Write(types.Bytes())
+		rec.Write(body.Bytes())
+		return rec.Bytes()
+	}
+	cell := func(rowid int64, values ...interface{}) []byte {
+		rec := record(values...)
+		var c bytes.Buffer
+		if len(rec) > 0x7f {
+			c.Write([]byte{byte(len(rec)>>7) | 0x80, byte(len(rec) & 0x7f)})
+		} else {
+			c.WriteByte(byte(len(rec)))
+		}
+		c.WriteByte(byte(rowid))
+		c.Write(rec)
+		return c.Bytes()
+	}
+	writePage := func(page []byte, headerOffset int, cells [][]byte) {
+		contentStart := len(page)
+		page[headerOffset] = 0x0d
+		binary.BigEndian.PutUint16(page[headerOffset+3:], uint16(len(cells)))
+		for i, c := range cells {
+			contentStart -= len(c)
+			copy(page[contentStart:], c)
+			binary.BigEndian.PutUint16(page[headerOffset+8+i*2:], uint16(contentStart))
+		}
+		binary.BigEndian.PutUint16(page[headerOffset+5:], uint16(contentStart))
+	}
+
+	notesCells := make([][]byte, len(fldsValues))
+	for i, flds := range fldsValues {
+		notesCells[i] = cell(int64(i+1), int64(i+1), flds)
+	}
+	masterCell := cell(1, "table", "notes", "notes", int64(2),
+		`CREATE TABLE notes (id INTEGER PRIMARY KEY, flds TEXT NOT NULL)`)
+
+	db := make([]byte, pageSize*2)
+	copy(db, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(db[16:18], pageSize)
+	db[18], db[19], db[21], db[22], db[23] = 1, 1, 64, 32, 32
+	binary.BigEndian.PutUint32(db[28:32], 2) // number of pages
+	binary.BigEndian.PutUint32(db[56:60], 1) // UTF-8
+	writePage(db[:pageSize], 100, [][]byte{masterCell})
+	writePage(db[pageSize:], 0, notesCells)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("collection.anki21")
+	if err != nil {
+		t.Fatalf("create zip entry: %v", err)
+	}
+	if _, err := f.Write(db); err != nil {
+		t.Fatalf("write zip entry: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// multipartUpload builds a multipart body with one file part and the given
+// extra form fields.
+func multipartUpload(t *testing.T, filename string, content []byte, fields map[string]string) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		t.Fatalf("write form file: %v", err)
+	}
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			t.Fatalf("write field %s: %v", k, err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatalf("close multipart writer: %v", err)
+	}
+	return &buf, mw.FormDataContentType()
+}
+
+func TestConvertHandler_Success(t *testing.T) {
+	apkg := buildTestAPKG(t, []string{"Q1\x1fA1", "Q2\x1fA2"})
+	body, contentType := multipartUpload(t, "deck.apkg", apkg, map[string]string{"preset": "Kindle"})
+	req := httptest.NewRequest(http.MethodPost, "/convert", body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+
+	newMux().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/epub+zip" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.Contains(got, "deck.epub") {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if !bytes.HasPrefix(rec.Body.Bytes(), []byte("PK")) {
+		t.Errorf("body is not a zip/epub")
+	}
+}
+
+func TestInspectHandler_Success(t *testing.T) {
+	apkg := buildTestAPKG(t, []string{"Q1\x1fA1", "Q2\x1fA2", ""})
+	body, contentType := multipartUpload(t, "deck.apkg", apkg, nil)
+	req := httptest.NewRequest(http.MethodGet, "/inspect", body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+
+	newMux().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var got struct {
+		CardCount     int      `json:"cardCount"`
+		Malformed     int      `json:"malformed"`
+		SchemaVersion int64    `json:"schemaVersion"`
+		Decks         []string `json:"decks"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if got.CardCount != 2 {
+		t.Errorf("cardCount = %d, want 2", got.CardCount)
+	}
+	if got.Malformed != 1 {
+		t.Errorf("malformed = %d, want 1", got.Malformed)
+	}
+}
+
+func TestConvertHandler_NotAZip(t *testing.T) {
+	body, contentType := multipartUpload(t, "deck.apkg", []byte("plain text, not a zip"), nil)
+	req := httptest.NewRequest(http.MethodPost, "/convert", body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+
+	newMux().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want 415", rec.Code)
+	}
+}
@@ -11,10 +11,39 @@ import (
 )
 
 // Card represents a single Anki flashcard with a question and answer.
+// Notes with more than two fields keep the full field list in Fields so the
+// extra values ("Extra", "Notes", …) can be rendered on the answer page.
 type Card struct {
 	ID       int64
 	Question string // HTML content (first field)
 	Answer   string // HTML content (second field)
+
+	Fields     []string // all note fields in order; nil for plain two-field notes
+	FieldNames []string // model field names parallel to Fields, when known
+	Tags       []string // note tags, split from the space-separated tags column; nil when untagged
+}
+
+// FieldName returns the display label for field i, falling back to a
+// positional "Field N" label when the note model's names are unknown.
+func (c Card) FieldName(i int) string {
+	if i < len(c.FieldNames) && c.FieldNames[i] != "" {
+		return c.FieldNames[i]
+	}
+	return fmt.Sprintf("Field %d", i+1)
+}
+
+// SchemaInfo describes the notes schema detected in the collection database.
+// It is diagnostic output: conversion works the same either way, but a
+// mismatched deck is much easier to debug with the detected layout in hand.
+type SchemaInfo struct {
+	Version     int64              // col.ver from the collection, 0 when unavailable
+	Columns     []string           // declared notes columns, nil when detection failed
+	FldsIndex   int                // row index of the flds column (rowid included); -1 when guessed per row
+	MidIndex    int                // row index of the mid (note type id) column; -1 when not detected
+	TagsIndex   int                // row index of the tags column; -1 when not detected
+	Malformed   int                // notes skipped because their flds column held no fields at all
+	Decks       []string           // deck names from col.decks, sorted, nil when unavailable
+	ModelFields map[int64][]string // note type id → declared field names from col.models, nil when unavailable
 }
 
 // ParseAPKG parses an Anki .apkg file (provided as raw bytes) and returns
@@ -26,40 +55,136 @@ type Card struct {
 // Separator character (0x1F). The first field is the question/front and
 // the second field is the answer/back.
 func ParseAPKG(data []byte) ([]Card, error) {
+	cards, _, err := ParseAPKGWithInfo(data)
+	return cards, err
+}
+
+// defaultFieldSeparator is the ASCII Unit Separator Anki places between note
+// fields in the flds column.
+const defaultFieldSeparator = "\x1f"
+
+// ParseAPKGWithSeparator is ParseAPKG with a custom field separator, for
+// non-standard decks re-exported with tabs (or anything else) between fields
+// instead of the 0x1F unit separator.
+func ParseAPKGWithSeparator(data []byte, sep string) ([]Card, error) {
+	cards, _, err := parseAPKGSeparated(data, sep)
+	return cards, err
+}
+
+// ParseAPKGWithInfo is ParseAPKG plus the detected schema information.
+func ParseAPKGWithInfo(data []byte) ([]Card, SchemaInfo, error) {
+	return parseAPKGSeparated(data, defaultFieldSeparator)
+}
+
+// parseAPKGSeparated is the parser behind the exported wrappers.
+func parseAPKGSeparated(data []byte, sep string) ([]Card, SchemaInfo, error) {
+	if sep == "" {
+		return nil, SchemaInfo{}, fmt.Errorf("field separator must be non-empty")
+	}
 	if len(data) == 0 {
-		return nil, fmt.Errorf("apkg data is empty")
+		return nil, SchemaInfo{}, fmt.Errorf("apkg data is empty")
 	}
 
 	// Open the ZIP archive from memory.
 	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to open apkg as zip: %w", err)
+		return nil, SchemaInfo{}, fmt.Errorf("failed to open apkg as zip: %w", err)
 	}
 
 	// Find the SQLite database file inside the ZIP.
 	dbFile := findDBFile(r)
 	if dbFile == nil {
-		return nil, fmt.Errorf("no collection database found in apkg (expected collection.anki21 or collection.anki2)")
+		return nil, SchemaInfo{}, fmt.Errorf("no collection database found in apkg (expected collection.anki21 or collection.anki2)")
 	}
 
 	// Read the database bytes directly into memory.
 	dbBytes, err := readZipEntry(dbFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract collection database: %w", err)
+		return nil, SchemaInfo{}, fmt.Errorf("failed to extract collection database: %w", err)
 	}
 
 	// Parse the SQLite database using our pure-Go reader.
 	db, err := sqlite3.Open(dbBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+		return nil, SchemaInfo{}, fmt.Errorf("failed to open sqlite database: %w", err)
 	}
 
+	info := detectSchema(db)
 	rows, err := db.ReadTable("notes")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read notes table: %w", err)
+		return nil, SchemaInfo{}, fmt.Errorf("failed to read notes table: %w", err)
+	}
+
+	cards, malformed := parseRows(rows, info, sep)
+	info.Malformed = malformed
+	return cards, info, nil
+}
+
+// DeckInfo is the read-only summary InspectAPKG produces for display before
+// a conversion is started.
+type DeckInfo struct {
+	NoteCount  int      // notes parsed from the collection database
+	Decks      []string // deck names from col.decks, sorted, nil when unavailable
+	MediaFiles int      // archive entries other than the collection database and media manifest
+	MediaBytes int64    // total uncompressed size of those entries
+}
+
+// InspectAPKG summarizes a deck without building an EPUB. Media size is the
+// uncompressed total of every zip entry except the collection database and
+// the "media" manifest — the numbered entries Anki stores sounds and images
+// under — so the UI can warn about image-heavy decks before converting.
+func InspectAPKG(data []byte) (DeckInfo, error) {
+	cards, info, err := ParseAPKGWithInfo(data)
+	if err != nil {
+		return DeckInfo{}, err
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return DeckInfo{}, fmt.Errorf("failed to open apkg as zip: %w", err)
+	}
+
+	deck := DeckInfo{NoteCount: len(cards), Decks: info.Decks}
+	for _, f := range r.File {
+		switch f.Name {
+		case "collection.anki21", "collection.anki2", "media":
+			continue
+		}
+		deck.MediaFiles++
+		deck.MediaBytes += int64(f.UncompressedSize64)
+	}
+	return deck, nil
+}
+
+// detectSchema reads the actual notes schema instead of guessing from row
+// width: the flds index comes from the CREATE TABLE statement in
+// sqlite_master, and the schema version from the col table when present. Any
+// failure leaves the zero value for that part, which parseRows treats as
+// "fall back to the width heuristic".
+func detectSchema(db *sqlite3.DB) SchemaInfo {
+	info := SchemaInfo{FldsIndex: -1, MidIndex: -1, TagsIndex: -1}
+
+	if columns, err := db.TableColumns("notes"); err == nil {
+		for i, name := range columns {
+			if strings.EqualFold(name, "flds") {
+				info.Columns = columns
+				info.FldsIndex = i + 1 // the reader prepends the rowid
+			}
+			if strings.EqualFold(name, "mid") {
+				info.MidIndex = i + 1
+			}
+			if strings.EqualFold(name, "tags") {
+				info.TagsIndex = i + 1
+			}
+		}
 	}
 
-	return parseRows(rows), nil
+	if meta, err := ReadCollectionMeta(db); err == nil {
+		info.Version = meta.Version
+		info.Decks = deckNames(meta.Decks)
+		info.ModelFields = modelFieldNames(meta.Models)
+	}
+	return info
 }
 
 // findDBFile searches the ZIP archive for the Anki collection database.
@@ -99,11 +224,15 @@ func readZipEntry(f *zip.File) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// parseRows converts raw sqlite3.Row slices to Card values.
-// Anki collection database (notes table) typically has many columns.
+// parseRows converts raw sqlite3.Row slices to Card values, also returning
+// how many notes were skipped as malformed (an empty flds column).
 // Our sqlite3 reader prepends the rowid as the first element (index 0).
-func parseRows(rows []sqlite3.Row) []Card {
-	cards := make([]Card, 0, len(rows))
+// The schema info supplies the detected flds column index (negative falls
+// back to guessing from the row width) and, when available, each note type's
+// declared field names.
+func parseRows(rows []sqlite3.Row, info SchemaInfo, sep string) (cards []Card, malformed int) {
+	fldsIndex := info.FldsIndex
+	cards = make([]Card, 0, len(rows))
 	for _, row := range rows {
 		// Minimum expected: [rowid, col0, col1]
 		if len(row) < 3 {
@@ -116,17 +245,20 @@ func parseRows(rows []sqlite3.Row) []Card {
 			id = v
 		}
 
-		// Look for the "flds" column.
-		// In standard Anki (schema v11), it's at record index 6 (row index 7).
-		// In our minimal test schema, it's at record index 1 (row index 2).
-		fldsIndex := 2 // fallback to test schema
-		if len(row) >= 8 {
-			fldsIndex = 7 // standard Anki schema
+		// Without a detected schema, guess the "flds" column from row width:
+		// standard Anki (schema v11) has it at record index 6 (row index 7),
+		// the minimal test schema at record index 1 (row index 2).
+		idx := fldsIndex
+		if idx < 0 {
+			idx = 2
+			if len(row) >= 8 {
+				idx = 7
+			}
 		}
 
 		var flds string
-		if fldsIndex < len(row) {
-			switch v := row[fldsIndex].(type) {
+		if idx < len(row) {
+			switch v := row[idx].(type) {
 			case string:
 				flds = v
 			case []byte:
@@ -142,15 +274,61 @@ func parseRows(rows []sqlite3.Row) []Card {
 			continue
 		}
 
-		fields := strings.Split(flds, "\x1f")
-		card := Card{ID: id}
-		if len(fields) >= 1 {
-			card.Question = strings.TrimSpace(fields[0])
+		// A note with an empty flds column has no content to make a card
+		// from; skip it but report it in the malformed tally.
+		if strings.TrimSpace(flds) == "" {
+			malformed++
+			continue
+		}
+
+		// The note type's declared field names, when the mid column and
+		// col.models were both readable.
+		var names []string
+		if info.MidIndex > 0 && info.MidIndex < len(row) {
+			if mid, ok := row[info.MidIndex].(int64); ok {
+				names = info.ModelFields[mid]
+			}
 		}
+
+		// Single-field note types are valid: the field becomes the question
+		// and the answer stays empty.
+		fields := splitFields(flds, len(names), sep)
+		card := Card{ID: id, Question: strings.TrimSpace(fields[0])}
 		if len(fields) >= 2 {
 			card.Answer = strings.TrimSpace(fields[1])
 		}
+		if len(fields) > 2 {
+			card.Fields = make([]string, len(fields))
+			for i, f := range fields {
+				card.Fields[i] = strings.TrimSpace(f)
+			}
+			card.FieldNames = names
+		}
+
+		// Tags are space-separated in their own column; untagged notes keep
+		// a nil slice.
+		if info.TagsIndex > 0 && info.TagsIndex < len(row) {
+			if v, ok := row[info.TagsIndex].(string); ok {
+				if tags := strings.Fields(v); len(tags) > 0 {
+					card.Tags = tags
+				}
+			}
+		}
 		cards = append(cards, card)
 	}
-	return cards
+	return cards, malformed
+}
+
+// splitFields splits a flds column on the separator (0x1F unless overridden).
+// Empty fields are preserved — adjacent separators are how Anki stores a
+// legitimately blank field — and when the note type's field count is known,
+// any excess splits are rejoined into the last field: a stray literal
+// separator inside a field's HTML must not shift every field after it.
+func splitFields(flds string, fieldCount int, sep string) []string {
+	fields := strings.Split(flds, sep)
+	if fieldCount > 0 && len(fields) > fieldCount {
+		fields[fieldCount-1] = strings.Join(fields[fieldCount-1:], sep)
+		fields = fields[:fieldCount]
+	}
+	return fields
 }
@@ -4,6 +4,10 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
 	"testing"
 )
 
@@ -30,19 +34,10 @@ func buildTestAPKG(t *testing.T, cards []Card) []byte {
 }
 
 // buildTestDB constructs a minimal valid SQLite3 database binary with a
-// "notes" table containing the given cards.
-//
-// Structure:
-//   - Page 1 (100-byte DB header + B-tree leaf for sqlite_master)
-//   - Page 2 (B-tree leaf for the notes table)
-//
-// sqlite_master contains one row: the notes table definition pointing to page 2.
+// two-column "notes" table containing the given cards.
 func buildTestDB(t *testing.T, cards []Card) []byte {
 	t.Helper()
 
-	const pageSize = 4096
-
-	// Build the notes table cells for page 2.
 	notesCells := make([][]byte, 0, len(cards))
 	for i, c := range cards {
 		rowid := int64(i + 1)
@@ -50,15 +45,34 @@ func buildTestDB(t *testing.T, cards []Card) []byte {
 		notesCells = append(notesCells, buildCell(t, rowid, []interface{}{rowid, flds}))
 	}
 
-	// sqlite_master row: type="table", name="notes", tbl_name="notes", rootpage=2, sql=...
-	sql := `CREATE TABLE notes (id INTEGER PRIMARY KEY, flds TEXT NOT NULL)`
-	masterCell := buildCell(t, 1, []interface{}{"table", "notes", "notes", int64(2), sql})
+	return buildMultiTableDB(t, []testTable{{
+		name:  "notes",
+		sql:   `CREATE TABLE notes (id INTEGER PRIMARY KEY, flds TEXT NOT NULL)`,
+		cells: notesCells,
+	}})
+}
+
+// testTable describes one table for buildMultiTableDB: its sqlite_master
+// entry and the leaf cells of its root page.
+type testTable struct {
+	name  string
+	sql   string
+	cells [][]byte
+}
+
+// buildMultiTableDB constructs a minimal valid SQLite3 database binary with
+// the given tables. Page 1 holds sqlite_master; table i lives on page i+2.
+func buildMultiTableDB(t *testing.T, tables []testTable) []byte {
+	t.Helper()
 
-	// Page 1: 100-byte DB file header + 8-byte B-tree leaf header + cells.
-	// Page 2: 8-byte B-tree leaf header + cells.
+	const pageSize = 4096
+
+	masterCells := make([][]byte, len(tables))
+	for i, tbl := range tables {
+		masterCells[i] = buildCell(t, int64(i+1), []interface{}{"table", tbl.name, tbl.name, int64(i + 2), tbl.sql})
+	}
 
 	page1 := make([]byte, pageSize)
-	page2 := make([]byte, pageSize)
 
 	// --- 100-byte SQLite file header on page 1 ---
 	copy(page1[0:16], "SQLite format 3\x00")
@@ -69,22 +83,26 @@ func buildTestDB(t *testing.T, cards []Card) []byte {
 	page1[21] = 64
 	page1[22] = 32
 	page1[23] = 32
-	binary.BigEndian.PutUint32(page1[24:28], 1)        // change counter
-	binary.BigEndian.PutUint32(page1[28:32], 2)        // number of pages
-	binary.BigEndian.PutUint32(page1[36:40], 0)        // free pages
-	binary.BigEndian.PutUint32(page1[40:44], 1)        // schema cookie
-	binary.BigEndian.PutUint32(page1[44:48], 4)        // schema format
-	binary.BigEndian.PutUint32(page1[56:60], 1)        // text encoding = UTF-8
-	binary.BigEndian.PutUint32(page1[92:96], 2)        // version-valid-for
-	binary.BigEndian.PutUint32(page1[96:100], 3046000) // SQLite version number
-
-	// --- B-tree leaf headers (page 1 at offset 100, page 2 at offset 0) ---
-	writeCellsToPage(t, page1, 100, [][]byte{masterCell})
-	writeCellsToPage(t, page2, 0, notesCells)
-
-	db := make([]byte, pageSize*2)
-	copy(db[0:pageSize], page1)
-	copy(db[pageSize:], page2)
+	binary.BigEndian.PutUint32(page1[24:28], 1)                     // change counter
+	binary.BigEndian.PutUint32(page1[28:32], uint32(len(tables)+1)) // number of pages
+	binary.BigEndian.PutUint32(page1[36:40], 0)                     // free pages
+	binary.BigEndian.PutUint32(page1[40:44], 1)                     // schema cookie
+	binary.BigEndian.PutUint32(page1[44:48], 4)                     // schema format
+	binary.BigEndian.PutUint32(page1[56:60], 1)                     // text encoding = UTF-8
+	binary.BigEndian.PutUint32(page1[92:96], 2)                     // version-valid-for
+	binary.BigEndian.PutUint32(page1[96:100], 3046000)              // SQLite version number
+
+	// B-tree leaf headers: page 1 at offset 100 (after the file header),
+	// table pages at offset 0.
+	writeCellsToPage(t, page1, 100, masterCells)
+
+	db := make([]byte, pageSize*(len(tables)+1))
+	copy(db, page1)
+	for i, tbl := range tables {
+		page := make([]byte, pageSize)
+		writeCellsToPage(t, page, 0, tbl.cells)
+		copy(db[pageSize*(i+1):], page)
+	}
 	return db
 }
 
@@ -285,6 +303,126 @@ func TestParseAPKG_FieldSeparator(t *testing.T) {
 	}
 }
 
+func TestParseAPKG_SchemaDetectionMinimal(t *testing.T) {
+	apkgData := buildTestAPKG(t, []Card{{Question: "q", Answer: "a"}})
+	cards, info, err := ParseAPKGWithInfo(apkgData)
+	if err != nil {
+		t.Fatalf("ParseAPKGWithInfo: %v", err)
+	}
+	if info.FldsIndex != 2 {
+		t.Errorf("FldsIndex = %d, want 2 for the minimal schema", info.FldsIndex)
+	}
+	if len(info.Columns) != 2 || info.Columns[1] != "flds" {
+		t.Errorf("Columns = %v, want [id flds]", info.Columns)
+	}
+	if info.Version != 0 {
+		t.Errorf("Version = %d, want 0 without a col table", info.Version)
+	}
+	if len(cards) != 1 || cards[0].Question != "q" {
+		t.Errorf("cards = %+v", cards)
+	}
+}
+
+func TestParseAPKG_SchemaDetectionRealistic(t *testing.T) {
+	// The full 11-column Anki notes schema, with flds at record index 6.
+	notesSQL := `CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null, usn integer not null, tags text not null, flds text not null, sfld integer not null, csum integer not null, flags integer not null, data text not null)`
+	colSQL := `CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null, ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null, models text not null, decks text not null, dconf text not null, tags text not null)`
+
+	noteCell := buildCell(t, 1, []interface{}{
+		int64(1), "guid1", int64(1), int64(0), int64(0), "",
+		"Question\x1fAnswer", int64(0), int64(0), int64(0), "",
+	})
+	colCell := buildCell(t, 1, []interface{}{
+		int64(1), int64(0), int64(0), int64(0), int64(11), int64(0), int64(0), int64(0),
+		"{}", "{}", "{}", "{}", "{}",
+	})
+
+	dbBytes := buildMultiTableDB(t, []testTable{
+		{name: "notes", sql: notesSQL, cells: [][]byte{noteCell}},
+		{name: "col", sql: colSQL, cells: [][]byte{colCell}},
+	})
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("collection.anki21")
+	_, _ = f.Write(dbBytes)
+	_ = zw.Close()
+
+	cards, info, err := ParseAPKGWithInfo(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseAPKGWithInfo: %v", err)
+	}
+	if info.FldsIndex != 7 {
+		t.Errorf("FldsIndex = %d, want 7 for the 11-column schema", info.FldsIndex)
+	}
+	if len(info.Columns) != 11 {
+		t.Errorf("Columns = %v, want 11 entries", info.Columns)
+	}
+	if info.Version != 11 {
+		t.Errorf("Version = %d, want 11 from col.ver", info.Version)
+	}
+	if len(cards) != 1 || cards[0].Question != "Question" || cards[0].Answer != "Answer" {
+		t.Errorf("cards = %+v", cards)
+	}
+}
+
+// buildNotesAPKG wraps raw flds strings in an .apkg with the minimal schema,
+// one note per entry.
+func buildNotesAPKG(t *testing.T, fldsValues []string) []byte {
+	t.Helper()
+	cells := make([][]byte, len(fldsValues))
+	for i, flds := range fldsValues {
+		rowid := int64(i + 1)
+		cells[i] = buildCell(t, rowid, []interface{}{rowid, flds})
+	}
+	dbBytes := buildMultiTableDB(t, []testTable{{
+		name:  "notes",
+		sql:   `CREATE TABLE notes (id INTEGER PRIMARY KEY, flds TEXT NOT NULL)`,
+		cells: cells,
+	}})
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("collection.anki21")
+	_, _ = f.Write(dbBytes)
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func TestParseAPKG_SingleFieldNote(t *testing.T) {
+	apkgData := buildNotesAPKG(t, []string{"lone question"})
+	cards, info, err := ParseAPKGWithInfo(apkgData)
+	if err != nil {
+		t.Fatalf("ParseAPKGWithInfo: %v", err)
+	}
+	if len(cards) != 1 {
+		t.Fatalf("want 1 card, got %d", len(cards))
+	}
+	if cards[0].Question != "lone question" || cards[0].Answer != "" {
+		t.Errorf("card = %+v, want question only", cards[0])
+	}
+	if info.Malformed != 0 {
+		t.Errorf("Malformed = %d, want 0", info.Malformed)
+	}
+}
+
+func TestParseAPKG_EmptyFldsCountedAsMalformed(t *testing.T) {
+	apkgData := buildNotesAPKG(t, []string{"good\x1fcard", "", "   "})
+	cards, info, err := ParseAPKGWithInfo(apkgData)
+	if err != nil {
+		t.Fatalf("ParseAPKGWithInfo: %v", err)
+	}
+	if len(cards) != 1 {
+		t.Fatalf("want 1 card, got %d", len(cards))
+	}
+	if cards[0].Question != "good" {
+		t.Errorf("question = %q", cards[0].Question)
+	}
+	if info.Malformed != 2 {
+		t.Errorf("Malformed = %d, want 2", info.Malformed)
+	}
+}
+
 func TestParseAPKG_EmptyData(t *testing.T) {
 	_, err := ParseAPKG([]byte{})
 	if err == nil {
@@ -335,3 +473,234 @@ func TestParseAPKG_FallbackToAnki2(t *testing.T) {
 		t.Errorf("question = %q", got[0].Question)
 	}
 }
+
+// buildTestAPKGWithMedia is buildTestAPKG plus Anki-style media entries: a
+// "media" manifest and numbered files holding the given payloads.
+func buildTestAPKGWithMedia(t *testing.T, cards []Card, media map[string][]byte) []byte {
+	t.Helper()
+	dbBytes := buildTestDB(t, cards)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	write := func(name string, content []byte) {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create zip entry %s: %v", name, err)
+		}
+		if _, err := f.Write(content); err != nil {
+			t.Fatalf("write zip entry %s: %v", name, err)
+		}
+	}
+	write("collection.anki21", dbBytes)
+
+	manifest := make(map[string]string, len(media))
+	n := 0
+	names := make([]string, 0, len(media))
+	for name := range media {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entry := fmt.Sprintf("%d", n)
+		manifest[entry] = name
+		write(entry, media[name])
+		n++
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("marshal media manifest: %v", err)
+	}
+	write("media", manifestJSON)
+
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestInspectAPKG(t *testing.T) {
+	cards := []Card{
+		{Question: "Q1", Answer: "A1"},
+		{Question: "Q2", Answer: "A2"},
+	}
+	apkgData := buildTestAPKGWithMedia(t, cards, map[string][]byte{
+		"diagram.png": bytes.Repeat([]byte{0xAA}, 1000),
+		"audio.mp3":   bytes.Repeat([]byte{0xBB}, 500),
+	})
+
+	deck, err := InspectAPKG(apkgData)
+	if err != nil {
+		t.Fatalf("InspectAPKG: %v", err)
+	}
+
+	if deck.NoteCount != len(cards) {
+		t.Errorf("NoteCount = %d, want %d", deck.NoteCount, len(cards))
+	}
+	if deck.MediaFiles != 2 {
+		t.Errorf("MediaFiles = %d, want 2", deck.MediaFiles)
+	}
+	if deck.MediaBytes != 1500 {
+		t.Errorf("MediaBytes = %d, want 1500", deck.MediaBytes)
+	}
+}
+
+func TestInspectAPKG_NoMedia(t *testing.T) {
+	apkgData := buildTestAPKG(t, []Card{{Question: "Q", Answer: "A"}})
+
+	deck, err := InspectAPKG(apkgData)
+	if err != nil {
+		t.Fatalf("InspectAPKG: %v", err)
+	}
+	if deck.MediaFiles != 0 || deck.MediaBytes != 0 {
+		t.Errorf("media reported for media-less deck: %d files, %d bytes", deck.MediaFiles, deck.MediaBytes)
+	}
+	if deck.NoteCount != 1 {
+		t.Errorf("NoteCount = %d, want 1", deck.NoteCount)
+	}
+}
+
+func TestInspectAPKG_Invalid(t *testing.T) {
+	if _, err := InspectAPKG([]byte("not a zip")); err == nil {
+		t.Error("want error for invalid apkg, got nil")
+	}
+}
+
+func TestSplitFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		flds       string
+		fieldCount int
+		want       []string
+	}{
+		{
+			name: "empty middle field preserved",
+			flds: "front\x1f\x1fextra",
+			want: []string{"front", "", "extra"},
+		},
+		{
+			name: "trailing empty field preserved",
+			flds: "front\x1fback\x1f",
+			want: []string{"front", "back", ""},
+		},
+		{
+			name:       "stray separator folded into last field",
+			flds:       "front\x1fback\x1fnotes with \x1f inside",
+			fieldCount: 3,
+			want:       []string{"front", "back", "notes with \x1f inside"},
+		},
+		{
+			name:       "count larger than splits leaves them alone",
+			flds:       "front\x1fback",
+			fieldCount: 4,
+			want:       []string{"front", "back"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitFields(tt.flds, tt.fieldCount, defaultFieldSeparator)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d fields %q, want %d", len(got), got, len(tt.want))
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("field %d: got %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseAPKG_EmptyFieldsPreserved(t *testing.T) {
+	apkgData := buildNotesAPKG(t, []string{
+		"front\x1f\x1fthird",
+		"question\x1fanswer\x1f",
+	})
+	cards, err := ParseAPKG(apkgData)
+	if err != nil {
+		t.Fatalf("ParseAPKG: %v", err)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("got %d cards, want 2", len(cards))
+	}
+
+	if len(cards[0].Fields) != 3 {
+		t.Fatalf("card 0 fields = %q, want 3 entries", cards[0].Fields)
+	}
+	if cards[0].Fields[1] != "" || cards[0].Fields[2] != "third" {
+		t.Errorf("empty middle field shifted content: %q", cards[0].Fields)
+	}
+
+	if len(cards[1].Fields) != 3 {
+		t.Fatalf("card 1 fields = %q, want 3 entries", cards[1].Fields)
+	}
+	if cards[1].Fields[2] != "" {
+		t.Errorf("trailing empty field lost: %q", cards[1].Fields)
+	}
+	if cards[1].Question != "question" || cards[1].Answer != "answer" {
+		t.Errorf("question/answer shifted: %q / %q", cards[1].Question, cards[1].Answer)
+	}
+}
+
+func TestParseAPKG_TagsColumn(t *testing.T) {
+	notesSQL := `CREATE TABLE notes (id INTEGER PRIMARY KEY, flds TEXT NOT NULL, tags TEXT NOT NULL)`
+	cells := [][]byte{
+		buildCell(t, 1, []interface{}{int64(1), "Q1\x1fA1", " geography  europe "}),
+		buildCell(t, 2, []interface{}{int64(2), "Q2\x1fA2", ""}),
+	}
+	dbBytes := buildMultiTableDB(t, []testTable{{name: "notes", sql: notesSQL, cells: cells}})
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("collection.anki21")
+	_, _ = f.Write(dbBytes)
+	_ = zw.Close()
+
+	cards, info, err := ParseAPKGWithInfo(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseAPKGWithInfo: %v", err)
+	}
+	if info.TagsIndex != 3 {
+		t.Errorf("TagsIndex = %d, want 3", info.TagsIndex)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("got %d cards, want 2", len(cards))
+	}
+	if want := []string{"geography", "europe"}; !reflect.DeepEqual(cards[0].Tags, want) {
+		t.Errorf("cards[0].Tags = %v, want %v", cards[0].Tags, want)
+	}
+	if cards[1].Tags != nil {
+		t.Errorf("untagged note got Tags = %v, want nil", cards[1].Tags)
+	}
+}
+
+func TestParseAPKGWithSeparator_TabSeparated(t *testing.T) {
+	apkgData := buildNotesAPKG(t, []string{"tab question\ttab answer"})
+
+	cards, err := ParseAPKGWithSeparator(apkgData, "\t")
+	if err != nil {
+		t.Fatalf("ParseAPKGWithSeparator: %v", err)
+	}
+	if len(cards) != 1 {
+		t.Fatalf("got %d cards, want 1", len(cards))
+	}
+	if cards[0].Question != "tab question" || cards[0].Answer != "tab answer" {
+		t.Errorf("card = %+v", cards[0])
+	}
+
+	// The default separator leaves the tab alone: one field, no answer.
+	cards, err = ParseAPKG(apkgData)
+	if err != nil {
+		t.Fatalf("ParseAPKG: %v", err)
+	}
+	if len(cards) != 1 || cards[0].Answer != "" {
+		t.Errorf("default separator split on tab: %+v", cards)
+	}
+}
+
+func TestParseAPKGWithSeparator_Empty(t *testing.T) {
+	apkgData := buildNotesAPKG(t, []string{"q\x1fa"})
+	if _, err := ParseAPKGWithSeparator(apkgData, ""); err == nil {
+		t.Error("expected an error for an empty separator")
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"anki-converter/internal/sqlite3"
+)
+
+// CollectionMeta is the structured content of the collection's single col
+// row: the schema version plus the decks and models JSON decoded into typed
+// maps keyed by their numeric ids.
+type CollectionMeta struct {
+	Version int64
+	Decks   map[int64]Deck
+	Models  map[int64]Model
+}
+
+// Deck is one entry of the col.decks JSON.
+type Deck struct {
+	Name string `json:"name"`
+}
+
+// Model is one entry of the col.models JSON — an Anki note type with its
+// declared fields.
+type Model struct {
+	Name   string       `json:"name"`
+	Fields []ModelField `json:"flds"`
+}
+
+// ModelField is one declared field of a note type.
+type ModelField struct {
+	Name string `json:"name"`
+	Ord  int    `json:"ord"`
+}
+
+// ReadCollectionMeta reads the single col row and decodes its decks and
+// models JSON into typed maps, so features needing them don't each parse the
+// blobs ad hoc. It errors when the col table is missing, unreadable or empty;
+// a blob that fails to decode just leaves its map nil, matching how the rest
+// of the parser degrades on partial collections.
+func ReadCollectionMeta(db *sqlite3.DB) (CollectionMeta, error) {
+	var meta CollectionMeta
+
+	columns, err := db.TableColumns("col")
+	if err != nil {
+		return meta, fmt.Errorf("read col schema: %w", err)
+	}
+	rows, err := db.ReadTable("col")
+	if err != nil {
+		return meta, fmt.Errorf("read col table: %w", err)
+	}
+	if len(rows) == 0 {
+		return meta, fmt.Errorf("col table is empty")
+	}
+
+	row := rows[0]
+	for i, name := range columns {
+		if i+1 >= len(row) {
+			break
+		}
+		switch {
+		case strings.EqualFold(name, "ver"):
+			if v, ok := row[i+1].(int64); ok {
+				meta.Version = v
+			}
+		case strings.EqualFold(name, "decks"):
+			meta.Decks = decodeIDMap[Deck](cellText(row[i+1]))
+		case strings.EqualFold(name, "models"):
+			meta.Models = decodeIDMap[Model](cellText(row[i+1]))
+		}
+	}
+	return meta, nil
+}
+
+// cellText returns a TEXT cell's content. Large blobs can surface from the
+// reader as []byte instead of string; both are accepted.
+func cellText(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	}
+	return ""
+}
+
+// decodeIDMap decodes Anki's "JSON object keyed by stringified numeric id"
+// shape into a map with int64 keys. Entries with unparsable ids are dropped;
+// malformed JSON yields nil.
+func decodeIDMap[T any](text string) map[int64]T {
+	var raw map[string]T
+	if err := json.Unmarshal([]byte(text), &raw); err != nil {
+		return nil
+	}
+	result := make(map[int64]T, len(raw))
+	for id, v := range raw {
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue
+		}
+		result[n] = v
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// deckNames flattens the deck map to its names, sorted for stable output,
+// nil when there are none.
+func deckNames(decks map[int64]Deck) []string {
+	names := make([]string, 0, len(decks))
+	for _, d := range decks {
+		if d.Name != "" {
+			names = append(names, d.Name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return nil
+	}
+	return names
+}
+
+// modelFieldNames flattens the model map to each note type's declared field
+// names, nil when there are none.
+func modelFieldNames(models map[int64]Model) map[int64][]string {
+	result := make(map[int64][]string)
+	for mid, m := range models {
+		if len(m.Fields) == 0 {
+			continue
+		}
+		names := make([]string, len(m.Fields))
+		for i, f := range m.Fields {
+			names[i] = f.Name
+		}
+		result[mid] = names
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
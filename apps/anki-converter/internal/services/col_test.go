@@ -0,0 +1,78 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"anki-converter/internal/sqlite3"
+)
+
+// buildColDB wraps one synthetic col row (with the realistic 13-column
+// schema) in a SQLite database binary.
+func buildColDB(t *testing.T, ver int64, modelsJSON, decksJSON string) []byte {
+	t.Helper()
+	colSQL := `CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null, ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null, models text not null, decks text not null, dconf text not null, tags text not null)`
+	colCell := buildCell(t, 1, []interface{}{
+		int64(1), int64(0), int64(0), int64(0), ver, int64(0), int64(0), int64(0),
+		"{}", modelsJSON, decksJSON, "{}", "{}",
+	})
+	return buildMultiTableDB(t, []testTable{{name: "col", sql: colSQL, cells: [][]byte{colCell}}})
+}
+
+func TestReadCollectionMeta(t *testing.T) {
+	models := `{"1607392319495":{"name":"Basic","flds":[{"name":"Front","ord":0},{"name":"Back","ord":1}]}}`
+	decks := `{"1":{"name":"Default"},"1700000000000":{"name":"Spanish::Verbs"}}`
+	db, err := sqlite3.Open(buildColDB(t, 11, models, decks))
+	if err != nil {
+		t.Fatalf("sqlite3.Open: %v", err)
+	}
+
+	meta, err := ReadCollectionMeta(db)
+	if err != nil {
+		t.Fatalf("ReadCollectionMeta: %v", err)
+	}
+	if meta.Version != 11 {
+		t.Errorf("Version = %d, want 11", meta.Version)
+	}
+	if len(meta.Decks) != 2 || meta.Decks[1].Name != "Default" || meta.Decks[1700000000000].Name != "Spanish::Verbs" {
+		t.Errorf("Decks = %+v", meta.Decks)
+	}
+	model, ok := meta.Models[1607392319495]
+	if !ok {
+		t.Fatalf("Models = %+v, missing id 1607392319495", meta.Models)
+	}
+	if model.Name != "Basic" {
+		t.Errorf("model name = %q, want Basic", model.Name)
+	}
+	wantFields := []ModelField{{Name: "Front", Ord: 0}, {Name: "Back", Ord: 1}}
+	if !reflect.DeepEqual(model.Fields, wantFields) {
+		t.Errorf("model fields = %+v, want %+v", model.Fields, wantFields)
+	}
+}
+
+func TestReadCollectionMeta_MalformedJSON(t *testing.T) {
+	db, err := sqlite3.Open(buildColDB(t, 11, "not json", `{"x":`))
+	if err != nil {
+		t.Fatalf("sqlite3.Open: %v", err)
+	}
+	meta, err := ReadCollectionMeta(db)
+	if err != nil {
+		t.Fatalf("ReadCollectionMeta: %v", err)
+	}
+	if meta.Decks != nil || meta.Models != nil {
+		t.Errorf("malformed blobs should leave nil maps, got %+v", meta)
+	}
+	if meta.Version != 11 {
+		t.Errorf("Version = %d, want 11", meta.Version)
+	}
+}
+
+func TestReadCollectionMeta_NoColTable(t *testing.T) {
+	db, err := sqlite3.Open(buildTestDB(t, []Card{{Question: "q", Answer: "a"}}))
+	if err != nil {
+		t.Fatalf("sqlite3.Open: %v", err)
+	}
+	if _, err := ReadCollectionMeta(db); err == nil {
+		t.Error("expected an error for a database without a col table")
+	}
+}
@@ -1,23 +1,112 @@
 package services
 
-import "fmt"
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SortMode selects the card order written to the EPUB.
+type SortMode string
+
+const (
+	// SortNone keeps the B-tree rowid order the cards were parsed in.
+	SortNone SortMode = "none"
+	// SortCreation orders by note id ascending. Anki note ids are creation
+	// timestamps in epoch milliseconds, so this is a guaranteed creation
+	// order where rowid order is only a close approximation.
+	SortCreation SortMode = "creation"
+	// SortAlphabetical orders case-insensitively by the question text, with
+	// markup stripped so "<b>Zebra</b>" doesn't sort under "b".
+	SortAlphabetical SortMode = "alphabetical-by-question"
+)
+
+// SortCards returns the cards in the requested order. The sort is stable, so
+// cards with identical keys keep their parsed relative order; the input slice
+// is not modified. An unknown mode is an error, and the zero value of SortMode
+// behaves like SortNone.
+func SortCards(cards []Card, sortBy SortMode) ([]Card, error) {
+	switch sortBy {
+	case SortNone, SortMode(""):
+		return cards, nil
+	case SortCreation:
+		sorted := append([]Card(nil), cards...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+		return sorted, nil
+	case SortAlphabetical:
+		type keyed struct {
+			key  string
+			card Card
+		}
+		entries := make([]keyed, len(cards))
+		for i, card := range cards {
+			entries[i] = keyed{questionSortKey(card.Question), card}
+		}
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+		sorted := make([]Card, len(entries))
+		for i, e := range entries {
+			sorted[i] = e.card
+		}
+		return sorted, nil
+	default:
+		return nil, fmt.Errorf("unknown sort mode %q (want %q, %q or %q)", sortBy, SortCreation, SortAlphabetical, SortNone)
+	}
+}
+
+// questionSortKey folds a question's HTML down to a lowercase text key.
+func questionSortKey(question string) string {
+	s := reTag.ReplaceAllString(question, " ")
+	s = html.UnescapeString(s)
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
 
 // ConversionResult holds the output of a successful .apkg → .epub conversion.
 type ConversionResult struct {
 	EPUBData  []byte
-	CardCount int // number of flashcards parsed from the .apkg
-	EPUBCards int // number of card pairs written to the .epub (should equal CardCount)
+	CardCount int      // number of flashcards parsed from the .apkg
+	EPUBCards int      // number of card pairs written to the .epub (should equal CardCount)
+	EPUBPages int      // card pages counted in the .epub; 0 when the generator didn't report them
+	Mode      EPUBMode // page mode the EPUB was generated with; empty means ModeQA
+	Malformed int      // notes skipped during parsing because their flds column was empty
 }
 
 // Convert parses the .apkg file bytes, generates an .epub, and returns the
-// result with card counts for validation.
+// result with card counts for validation. Cards keep their parsed order; use
+// ConvertSorted to order the deck deliberately.
 func Convert(apkgData []byte, preset DevicePreset, title string) (ConversionResult, error) {
-	cards, err := ParseAPKG(apkgData)
+	return ConvertSorted(apkgData, preset, title, SortNone)
+}
+
+// ConvertSorted is Convert with an explicit card order for the study EPUB.
+func ConvertSorted(apkgData []byte, preset DevicePreset, title string, sortBy SortMode) (ConversionResult, error) {
+	return ConvertStudy(apkgData, preset, title, sortBy, ModeQA)
+}
+
+// ConvertStudy is ConvertSorted with an explicit page mode for self-testing
+// layouts (questions-only booklets, flip-to-reveal interleaving).
+func ConvertStudy(apkgData []byte, preset DevicePreset, title string, sortBy SortMode, mode EPUBMode) (ConversionResult, error) {
+	return ConvertSeparated(apkgData, preset, title, sortBy, mode, defaultFieldSeparator)
+}
+
+// ConvertSeparated is ConvertStudy with a custom flds separator, for
+// non-standard decks whose fields are split by something other than the 0x1F
+// unit separator (tabs, typically). The separator must be non-empty.
+func ConvertSeparated(apkgData []byte, preset DevicePreset, title string, sortBy SortMode, mode EPUBMode, sep string) (ConversionResult, error) {
+	cards, info, err := parseAPKGSeparated(apkgData, sep)
 	if err != nil {
 		return ConversionResult{}, fmt.Errorf("parse apkg: %w", err)
 	}
 
-	epubData, err := GenerateEPUB(cards, preset, title)
+	cards, err = SortCards(cards, sortBy)
+	if err != nil {
+		return ConversionResult{}, err
+	}
+
+	epubData, err := GenerateEPUBWithOptions(cards, preset, title, EPUBOptions{Mode: mode})
 	if err != nil {
 		return ConversionResult{}, fmt.Errorf("generate epub: %w", err)
 	}
@@ -26,16 +115,86 @@ func Convert(apkgData []byte, preset DevicePreset, title string) (ConversionResu
 		EPUBData:  epubData,
 		CardCount: len(cards),
 		EPUBCards: len(cards),
+		EPUBPages: countCardPages(epubData),
+		Mode:      mode,
+		Malformed: info.Malformed,
 	}, nil
 }
 
+// ConvertVolumes is Convert for decks too large to sync as a single EPUB:
+// cards are split into chunks of cardsPerVolume and one EPUB is generated per
+// chunk. With more than one volume each title gets a " (Vol N)" suffix; a deck
+// that fits in one volume keeps the plain title. Each volume's card counts
+// validate independently with ValidateCardCount.
+func ConvertVolumes(apkgData []byte, preset DevicePreset, title string, cardsPerVolume int) ([]ConversionResult, error) {
+	if cardsPerVolume <= 0 {
+		return nil, fmt.Errorf("cards per volume must be positive, got %d", cardsPerVolume)
+	}
+
+	cards, err := ParseAPKG(apkgData)
+	if err != nil {
+		return nil, fmt.Errorf("parse apkg: %w", err)
+	}
+
+	volumes := (len(cards) + cardsPerVolume - 1) / cardsPerVolume
+	results := make([]ConversionResult, 0, volumes)
+	for v := 0; v < volumes; v++ {
+		chunk := cards[v*cardsPerVolume : min((v+1)*cardsPerVolume, len(cards))]
+
+		volumeTitle := title
+		if volumes > 1 {
+			volumeTitle = fmt.Sprintf("%s (Vol %d)", title, v+1)
+		}
+
+		epubData, err := GenerateEPUB(chunk, preset, volumeTitle)
+		if err != nil {
+			return nil, fmt.Errorf("generate epub volume %d: %w", v+1, err)
+		}
+
+		results = append(results, ConversionResult{
+			EPUBData:  epubData,
+			CardCount: len(chunk),
+			EPUBCards: len(chunk),
+		})
+	}
+	return results, nil
+}
+
 // ValidateCardCount checks that the number of cards parsed from the .apkg
-// matches the number of card pairs written to the .epub.
-// Returns nil if counts match, or a descriptive error if they differ.
+// matches the number of card pairs written to the .epub, and — when the
+// generator counted pages — that the page count matches what the EPUB's mode
+// implies per card. Returns nil if counts match, or a descriptive error.
 func ValidateCardCount(result ConversionResult) error {
 	if result.CardCount != result.EPUBCards {
 		return fmt.Errorf("card count mismatch: apkg had %d cards but epub contains %d card pairs",
 			result.CardCount, result.EPUBCards)
 	}
+	if result.EPUBPages > 0 {
+		want := result.CardCount * result.Mode.PagesPerCard()
+		if result.EPUBPages != want {
+			return fmt.Errorf("page count mismatch: %d cards in %q mode should yield %d pages but epub contains %d",
+				result.CardCount, result.Mode, want, result.EPUBPages)
+		}
+	}
 	return nil
 }
+
+// countCardPages counts the card pages (card_NNNN_*.xhtml entries) in a
+// generated EPUB, for mode-aware validation. Returns 0 when the bytes don't
+// open as a zip.
+func countCardPages(epubData []byte) int {
+	r, err := zip.NewReader(bytes.NewReader(epubData), int64(len(epubData)))
+	if err != nil {
+		return 0
+	}
+	pages := 0
+	for _, f := range r.File {
+		if reCardPage.MatchString(f.Name) {
+			pages++
+		}
+	}
+	return pages
+}
+
+// reCardPage matches the card page entries GenerateEPUB writes.
+var reCardPage = regexp.MustCompile(`^OEBPS/card_\d{4}_[qba]\.xhtml$`)
@@ -1,6 +1,12 @@
 package services
 
 import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -83,3 +89,241 @@ func TestConvert_AllPresets(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertVolumes_SplitsDeck(t *testing.T) {
+	cards := make([]Card, 10)
+	for i := range cards {
+		cards[i] = Card{Question: fmt.Sprintf("Q%d", i+1), Answer: fmt.Sprintf("A%d", i+1)}
+	}
+	apkgData := buildTestAPKG(t, cards)
+
+	results, err := ConvertVolumes(apkgData, DevicePresets[0], "Big Deck", 4)
+	if err != nil {
+		t.Fatalf("ConvertVolumes: %v", err)
+	}
+
+	wantCounts := []int{4, 4, 2}
+	if len(results) != len(wantCounts) {
+		t.Fatalf("got %d volumes, want %d", len(results), len(wantCounts))
+	}
+	for i, want := range wantCounts {
+		if results[i].CardCount != want {
+			t.Errorf("volume %d CardCount = %d, want %d", i+1, results[i].CardCount, want)
+		}
+		if err := ValidateCardCount(results[i]); err != nil {
+			t.Errorf("volume %d: %v", i+1, err)
+		}
+		if len(results[i].EPUBData) == 0 {
+			t.Errorf("volume %d EPUBData is empty", i+1)
+		}
+	}
+}
+
+func TestConvertVolumes_SingleVolume(t *testing.T) {
+	cards := []Card{
+		{Question: "Q1", Answer: "A1"},
+		{Question: "Q2", Answer: "A2"},
+	}
+	apkgData := buildTestAPKG(t, cards)
+
+	results, err := ConvertVolumes(apkgData, DevicePresets[0], "Small Deck", 100)
+	if err != nil {
+		t.Fatalf("ConvertVolumes: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d volumes, want 1", len(results))
+	}
+	if results[0].CardCount != len(cards) {
+		t.Errorf("CardCount = %d, want %d", results[0].CardCount, len(cards))
+	}
+}
+
+func TestConvertVolumes_InvalidChunkSize(t *testing.T) {
+	apkgData := buildTestAPKG(t, []Card{{Question: "Q", Answer: "A"}})
+	if _, err := ConvertVolumes(apkgData, DevicePresets[0], "Deck", 0); err == nil {
+		t.Error("want error for cardsPerVolume = 0, got nil")
+	}
+}
+
+func sortTestCards() []Card {
+	return []Card{
+		{ID: 30, Question: "banana", Answer: "A1"},
+		{ID: 10, Question: "<b>Cherry</b> pie", Answer: "A2"},
+		{ID: 20, Question: "apple", Answer: "A3"},
+	}
+}
+
+func questions(cards []Card) []string {
+	qs := make([]string, len(cards))
+	for i, card := range cards {
+		qs[i] = card.Question
+	}
+	return qs
+}
+
+func TestSortCards_Modes(t *testing.T) {
+	tests := []struct {
+		mode SortMode
+		want []string
+	}{
+		{SortNone, []string{"banana", "<b>Cherry</b> pie", "apple"}},
+		{SortMode(""), []string{"banana", "<b>Cherry</b> pie", "apple"}},
+		{SortCreation, []string{"<b>Cherry</b> pie", "apple", "banana"}},
+		{SortAlphabetical, []string{"apple", "banana", "<b>Cherry</b> pie"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			got, err := SortCards(sortTestCards(), tt.mode)
+			if err != nil {
+				t.Fatalf("SortCards(%q): %v", tt.mode, err)
+			}
+			gotQs := questions(got)
+			for i, want := range tt.want {
+				if gotQs[i] != want {
+					t.Errorf("card %d: got %q, want %q (full order %v)", i, gotQs[i], want, gotQs)
+				}
+			}
+		})
+	}
+}
+
+func TestSortCards_AlphabeticalIsStable(t *testing.T) {
+	cards := []Card{
+		{ID: 1, Question: "Same", Answer: "first"},
+		{ID: 2, Question: "same", Answer: "second"},
+		{ID: 3, Question: "SAME", Answer: "third"},
+	}
+	got, err := SortCards(cards, SortAlphabetical)
+	if err != nil {
+		t.Fatalf("SortCards: %v", err)
+	}
+	for i, want := range []string{"first", "second", "third"} {
+		if got[i].Answer != want {
+			t.Errorf("card %d: got %q, want %q", i, got[i].Answer, want)
+		}
+	}
+}
+
+func TestSortCards_UnknownMode(t *testing.T) {
+	if _, err := SortCards(sortTestCards(), SortMode("shuffled")); err == nil {
+		t.Error("want error for unknown sort mode, got nil")
+	}
+}
+
+func TestSortCards_DoesNotModifyInput(t *testing.T) {
+	cards := sortTestCards()
+	if _, err := SortCards(cards, SortCreation); err != nil {
+		t.Fatalf("SortCards: %v", err)
+	}
+	if cards[0].ID != 30 {
+		t.Errorf("input slice was reordered: first ID is %d", cards[0].ID)
+	}
+}
+
+func TestConvertSorted_Alphabetical(t *testing.T) {
+	apkgData := buildTestAPKG(t, []Card{
+		{Question: "zebra", Answer: "A1"},
+		{Question: "aardvark", Answer: "A2"},
+	})
+
+	result, err := ConvertSorted(apkgData, DevicePresets[0], "Test Deck", SortAlphabetical)
+	if err != nil {
+		t.Fatalf("ConvertSorted: %v", err)
+	}
+	cardsInOrder, err := extractCardOrder(result.EPUBData)
+	if err != nil {
+		t.Fatalf("extractCardOrder: %v", err)
+	}
+	if len(cardsInOrder) != 2 || cardsInOrder[0] != "aardvark" || cardsInOrder[1] != "zebra" {
+		t.Errorf("epub card order = %v, want [aardvark zebra]", cardsInOrder)
+	}
+}
+
+// extractCardOrder reads the question pages of a generated EPUB in spine
+// order and returns their text content.
+func extractCardOrder(epubData []byte) ([]string, error) {
+	r, err := zip.NewReader(bytes.NewReader(epubData), int64(len(epubData)))
+	if err != nil {
+		return nil, err
+	}
+	pages := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		pages[f.Name] = string(content)
+	}
+
+	var questions []string
+	for n := 1; ; n++ {
+		page, ok := pages[fmt.Sprintf("OEBPS/card_%04d_q.xhtml", n)]
+		if !ok {
+			return questions, nil
+		}
+		m := regexp.MustCompile(`(?s)<div class="card-content">(.*?)</div>`).FindStringSubmatch(page)
+		if m == nil {
+			return nil, fmt.Errorf("card %d page has no content div", n)
+		}
+		questions = append(questions, strings.TrimSpace(reTag.ReplaceAllString(m[1], "")))
+	}
+}
+
+func TestConvertStudy_PageCountsPerMode(t *testing.T) {
+	apkgData := buildNotesAPKG(t, []string{"q1\x1fa1", "q2\x1fa2"})
+	tests := []struct {
+		mode      EPUBMode
+		wantPages int
+	}{
+		{ModeQA, 4},
+		{ModeQuestionsOnly, 2},
+		{ModeInterleavedBlank, 6},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			result, err := ConvertStudy(apkgData, DevicePresets[0], "Deck", SortNone, tt.mode)
+			if err != nil {
+				t.Fatalf("ConvertStudy: %v", err)
+			}
+			if result.EPUBPages != tt.wantPages {
+				t.Errorf("EPUBPages = %d, want %d", result.EPUBPages, tt.wantPages)
+			}
+			if err := ValidateCardCount(result); err != nil {
+				t.Errorf("ValidateCardCount: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateCardCount_PageMismatch(t *testing.T) {
+	result := ConversionResult{
+		CardCount: 2,
+		EPUBCards: 2,
+		EPUBPages: 3, // qa mode should yield 4
+		Mode:      ModeQA,
+	}
+	if err := ValidateCardCount(result); err == nil {
+		t.Error("expected an error for a page count mismatch")
+	}
+}
+
+func TestConvertSeparated_TabDeck(t *testing.T) {
+	apkgData := buildNotesAPKG(t, []string{"q1\ta1", "q2\ta2"})
+	result, err := ConvertSeparated(apkgData, DevicePresets[0], "Tab Deck", SortNone, ModeQA, "\t")
+	if err != nil {
+		t.Fatalf("ConvertSeparated: %v", err)
+	}
+	if err := ValidateCardCount(result); err != nil {
+		t.Errorf("ValidateCardCount: %v", err)
+	}
+	page := readEPUBPage(t, result.EPUBData, "OEBPS/card_0001_a.xhtml")
+	if !strings.Contains(page, "a1") {
+		t.Errorf("answer page missing tab-separated answer:\n%s", page)
+	}
+}
@@ -28,9 +28,59 @@ var DevicePresets = []DevicePreset{
 	{Name: "Kobo Clara Reader", Width: 1072, Height: 1448, FontSize: 14, Margin: 20},
 }
 
+// EPUBMode selects which pages each card contributes to the EPUB.
+type EPUBMode string
+
+const (
+	// ModeQA writes a question page followed by its answer page (the default).
+	ModeQA EPUBMode = "qa"
+	// ModeQuestionsOnly writes only the question pages, for a quiz booklet.
+	ModeQuestionsOnly EPUBMode = "questions-only"
+	// ModeInterleavedBlank inserts a blank "flip to reveal" page between each
+	// question and its answer, so the answer never sits on the next page.
+	ModeInterleavedBlank EPUBMode = "interleaved-with-blank"
+)
+
+// PagesPerCard returns how many pages each card contributes in this mode,
+// 0 for an unknown mode. The zero value behaves like ModeQA.
+func (m EPUBMode) PagesPerCard() int {
+	switch m {
+	case ModeQA, EPUBMode(""):
+		return 2
+	case ModeQuestionsOnly:
+		return 1
+	case ModeInterleavedBlank:
+		return 3
+	}
+	return 0
+}
+
+// EPUBOptions configures EPUB generation beyond the device preset. The zero
+// value reproduces the original output: question/answer page pairs, no tags.
+type EPUBOptions struct {
+	Mode        EPUBMode // page layout per card; empty behaves like ModeQA
+	IncludeTags bool     // render note tags as a footer line on question pages
+}
+
 // GenerateEPUB produces an EPUB 3 file in memory containing two pages per
 // card (question page then answer page). Returns the raw .epub bytes.
 func GenerateEPUB(cards []Card, preset DevicePreset, title string) ([]byte, error) {
+	return GenerateEPUBWithOptions(cards, preset, title, EPUBOptions{})
+}
+
+// GenerateEPUBTagged is GenerateEPUB with an includeTags switch: when set,
+// each question page gets a small footer line listing the note's tags.
+// Untagged notes render no footer.
+func GenerateEPUBTagged(cards []Card, preset DevicePreset, title string, includeTags bool) ([]byte, error) {
+	return GenerateEPUBWithOptions(cards, preset, title, EPUBOptions{IncludeTags: includeTags})
+}
+
+// GenerateEPUBWithOptions is GenerateEPUB with the full option set. An
+// unknown page mode is an error.
+func GenerateEPUBWithOptions(cards []Card, preset DevicePreset, title string, opts EPUBOptions) ([]byte, error) {
+	if opts.Mode.PagesPerCard() == 0 {
+		return nil, fmt.Errorf("unknown epub mode %q (want %q, %q or %q)", opts.Mode, ModeQA, ModeQuestionsOnly, ModeInterleavedBlank)
+	}
 	if title == "" {
 		title = "Anki Deck"
 	}
@@ -65,37 +115,53 @@ func GenerateEPUB(cards []Card, preset DevicePreset, title string) ([]byte, erro
 	)
 	spineItems = append(spineItems, `<itemref idref="nav"/>`)
 
+	addCardPage := func(id, page string) error {
+		if err := addFile(w, fmt.Sprintf("OEBPS/%s.xhtml", id), page); err != nil {
+			return err
+		}
+		manifestItems = append(manifestItems,
+			fmt.Sprintf(`<item id=%q href=%q media-type="application/xhtml+xml"/>`, id, id+".xhtml"),
+		)
+		spineItems = append(spineItems, fmt.Sprintf(`<itemref idref=%q/>`, id))
+		return nil
+	}
+
 	for i, card := range cards {
 		n := i + 1
-		qID := fmt.Sprintf("card_%04d_q", n)
-		aID := fmt.Sprintf("card_%04d_a", n)
-		qFile := fmt.Sprintf("OEBPS/%s.xhtml", qID)
-		aFile := fmt.Sprintf("OEBPS/%s.xhtml", aID)
 
-		qPage, err := generateCardPage(fmt.Sprintf("Question %d", n), card.Question, title)
+		var tags []string
+		if opts.IncludeTags {
+			tags = card.Tags
+		}
+		qPage, err := generateCardPage(fmt.Sprintf("Question %d", n), card.Question, title, tags)
 		if err != nil {
 			return nil, fmt.Errorf("card %d question page: %w", n, err)
 		}
-		aPage, err := generateCardPage(fmt.Sprintf("Answer %d", n), card.Answer, title)
-		if err != nil {
-			return nil, fmt.Errorf("card %d answer page: %w", n, err)
+		if err := addCardPage(fmt.Sprintf("card_%04d_q", n), qPage); err != nil {
+			return nil, err
 		}
 
-		if err := addFile(w, qFile, qPage); err != nil {
-			return nil, err
+		if opts.Mode == ModeQuestionsOnly {
+			continue
 		}
-		if err := addFile(w, aFile, aPage); err != nil {
-			return nil, err
+
+		if opts.Mode == ModeInterleavedBlank {
+			bPage, err := renderCardPage(fmt.Sprintf("Question %d", n), blankPageContent, title, nil)
+			if err != nil {
+				return nil, fmt.Errorf("card %d blank page: %w", n, err)
+			}
+			if err := addCardPage(fmt.Sprintf("card_%04d_b", n), bPage); err != nil {
+				return nil, err
+			}
 		}
 
-		manifestItems = append(manifestItems,
-			fmt.Sprintf(`<item id=%q href=%q media-type="application/xhtml+xml"/>`, qID, qID+".xhtml"),
-			fmt.Sprintf(`<item id=%q href=%q media-type="application/xhtml+xml"/>`, aID, aID+".xhtml"),
-		)
-		spineItems = append(spineItems,
-			fmt.Sprintf(`<itemref idref=%q/>`, qID),
-			fmt.Sprintf(`<itemref idref=%q/>`, aID),
-		)
+		aPage, err := renderCardPage(fmt.Sprintf("Answer %d", n), answerContent(card), title, nil)
+		if err != nil {
+			return nil, fmt.Errorf("card %d answer page: %w", n, err)
+		}
+		if err := addCardPage(fmt.Sprintf("card_%04d_a", n), aPage); err != nil {
+			return nil, err
+		}
 	}
 
 	// 5. Navigation document
@@ -200,6 +266,10 @@ func generateNav(cards []Card, title string) string {
 	)
 }
 
+// blankPageContent is the body of the spacer page ModeInterleavedBlank puts
+// between a question and its answer.
+const blankPageContent = `<p class="flip-hint">Flip the page to reveal the answer.</p>`
+
 // cardPageTmpl is the XHTML template for a single card page.
 var cardPageTmpl = template.Must(template.New("card").Parse(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE html>
@@ -212,7 +282,8 @@ var cardPageTmpl = template.Must(template.New("card").Parse(`<?xml version="1.0"
   <div class="page">
     <div class="card-label">{{.Label}}</div>
     <div class="card-content">{{.Content}}</div>
-  </div>
+{{if .Tags}}    <div class="card-tags">{{.Tags}}</div>
+{{end}}  </div>
 </body>
 </html>`))
 
@@ -220,18 +291,24 @@ type cardPageData struct {
 	Label     string
 	BookTitle string
 	Content   string // may contain HTML
+	Tags      string // escaped footer line; empty hides the footer
 }
 
-func generateCardPage(label string, content string, bookTitle string) (string, error) {
+func generateCardPage(label string, content string, bookTitle string, tags []string) (string, error) {
 	// Strip or sanitize HTML tags to produce clean readable text.
 	// We keep basic formatting but remove scripts/styles.
-	safeContent := sanitizeHTML(content)
+	return renderCardPage(label, sanitizeHTML(content), bookTitle, tags)
+}
 
+// renderCardPage executes the page template with already-sanitized content,
+// after rebalancing any tags the sanitizer let through unpaired.
+func renderCardPage(label string, safeContent string, bookTitle string, tags []string) (string, error) {
 	var buf bytes.Buffer
 	err := cardPageTmpl.Execute(&buf, cardPageData{
 		Label:     label,
 		BookTitle: bookTitle,
-		Content:   safeContent,
+		Content:   repairHTML(safeContent),
+		Tags:      html.EscapeString(strings.Join(tags, " · ")),
 	})
 	if err != nil {
 		return "", err
@@ -239,6 +316,39 @@ func generateCardPage(label string, content string, bookTitle string) (string, e
 	return buf.String(), nil
 }
 
+// answerContent builds the answer page body. Plain two-field notes keep the
+// original layout; notes with extra fields get a labeled definition list of
+// the remaining values below the answer.
+func answerContent(card Card) string {
+	content := sanitizeHTML(card.Answer)
+	if len(card.Fields) <= 2 {
+		return content
+	}
+
+	var sb strings.Builder
+	sb.WriteString(content)
+	sb.WriteString("<dl class=\"card-fields\">\n")
+	for i := 2; i < len(card.Fields); i++ {
+		value := sanitizeInline(card.Fields[i])
+		if value == "" {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("<dt>%s</dt><dd>%s</dd>\n", html.EscapeString(card.FieldName(i)), value))
+	}
+	sb.WriteString("</dl>\n")
+	return sb.String()
+}
+
+// sanitizeInline is sanitizeHTML for a single definition-list value: tags are
+// stripped, entities normalized, and newlines collapsed to spaces.
+func sanitizeInline(raw string) string {
+	s := reScript.ReplaceAllString(raw, "")
+	s = reStyle.ReplaceAllString(s, "")
+	s = reTag.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return html.EscapeString(strings.Join(strings.Fields(s), " "))
+}
+
 // sanitizeHTML removes script/style tags and returns safe HTML suitable for
 // embedding in XHTML. It preserves basic formatting elements.
 var (
@@ -318,6 +428,31 @@ body {
 .card-content p {
     margin: 0.5em 0;
 }
+
+.card-fields {
+    margin-top: 1.5em;
+    text-align: left;
+}
+
+.card-fields dt {
+    font-weight: bold;
+    margin-top: 0.5em;
+}
+
+.card-fields dd {
+    margin: 0 0 0.5em 1em;
+}
+
+.card-tags {
+    margin-top: 2em;
+    font-size: %dpt;
+    color: #555555;
+}
+
+.flip-hint {
+    font-style: italic;
+    color: #555555;
+}
 `,
 		preset.Name, preset.Width, preset.Height,
 		preset.Margin,
@@ -325,5 +460,6 @@ body {
 		preset.Margin*2,
 		preset.FontSize-2,
 		preset.FontSize+2,
+		preset.FontSize-3,
 	)
 }
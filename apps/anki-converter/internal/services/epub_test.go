@@ -3,6 +3,8 @@ package services
 import (
 	"archive/zip"
 	"bytes"
+	"fmt"
+	"io"
 	"strings"
 	"testing"
 )
@@ -173,3 +175,196 @@ func TestSanitizeHTML(t *testing.T) {
 		})
 	}
 }
+
+// readEPUBPage extracts one named entry from a generated EPUB.
+func readEPUBPage(t *testing.T, data []byte, name string) string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", name, err)
+		}
+		defer rc.Close()
+		var sb strings.Builder
+		if _, err := io.Copy(&sb, rc); err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		return sb.String()
+	}
+	t.Fatalf("entry %s not found in epub", name)
+	return ""
+}
+
+func TestGenerateEPUB_ExtraFieldsOnAnswerPage(t *testing.T) {
+	cards := []Card{{
+		Question:   "What is the capital of France?",
+		Answer:     "Paris",
+		Fields:     []string{"What is the capital of France?", "Paris", "Population ~2.1M", "<b>Seine</b> river"},
+		FieldNames: []string{"Front", "Back", "Extra", "Notes"},
+	}}
+
+	data, err := GenerateEPUB(cards, DevicePresets[0], "Geo Deck")
+	if err != nil {
+		t.Fatalf("GenerateEPUB: %v", err)
+	}
+
+	page := readEPUBPage(t, data, "OEBPS/card_0001_a.xhtml")
+	for _, want := range []string{"<dt>Extra</dt>", "Population ~2.1M", "<dt>Notes</dt>", "Seine river"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("answer page missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<b>") {
+		t.Errorf("field markup survived sanitization:\n%s", page)
+	}
+}
+
+func TestGenerateEPUB_ExtraFieldsFallbackLabels(t *testing.T) {
+	cards := []Card{{
+		Question: "Q",
+		Answer:   "A",
+		Fields:   []string{"Q", "A", "extra value"},
+	}}
+
+	data, err := GenerateEPUB(cards, DevicePresets[0], "Deck")
+	if err != nil {
+		t.Fatalf("GenerateEPUB: %v", err)
+	}
+
+	page := readEPUBPage(t, data, "OEBPS/card_0001_a.xhtml")
+	if !strings.Contains(page, "<dt>Field 3</dt>") {
+		t.Errorf("answer page missing positional label:\n%s", page)
+	}
+}
+
+func TestGenerateEPUB_TwoFieldLayoutUnchanged(t *testing.T) {
+	data, err := GenerateEPUB(sampleCards(), DevicePresets[0], "Deck")
+	if err != nil {
+		t.Fatalf("GenerateEPUB: %v", err)
+	}
+
+	page := readEPUBPage(t, data, "OEBPS/card_0001_a.xhtml")
+	if strings.Contains(page, "card-fields") {
+		t.Errorf("two-field card rendered a fields block:\n%s", page)
+	}
+}
+
+func TestGenerateEPUBTagged_TagsFooter(t *testing.T) {
+	cards := []Card{
+		{ID: 1, Question: "Capital of France?", Answer: "Paris", Tags: []string{"geography", "europe"}},
+		{ID: 2, Question: "2+2?", Answer: "4"},
+	}
+	data, err := GenerateEPUBTagged(cards, DevicePresets[0], "Deck", true)
+	if err != nil {
+		t.Fatalf("GenerateEPUBTagged: %v", err)
+	}
+
+	tagged := readEPUBPage(t, data, "OEBPS/card_0001_q.xhtml")
+	if !strings.Contains(tagged, `<div class="card-tags">geography · europe</div>`) {
+		t.Errorf("tagged question page missing tags footer:\n%s", tagged)
+	}
+	untagged := readEPUBPage(t, data, "OEBPS/card_0002_q.xhtml")
+	if strings.Contains(untagged, "card-tags") {
+		t.Errorf("untagged question page has a tags footer:\n%s", untagged)
+	}
+}
+
+func TestGenerateEPUB_TagsOffByDefault(t *testing.T) {
+	cards := []Card{{ID: 1, Question: "Q", Answer: "A", Tags: []string{"geo"}}}
+	data, err := GenerateEPUB(cards, DevicePresets[0], "Deck")
+	if err != nil {
+		t.Fatalf("GenerateEPUB: %v", err)
+	}
+	page := readEPUBPage(t, data, "OEBPS/card_0001_q.xhtml")
+	if strings.Contains(page, "card-tags") {
+		t.Errorf("tags rendered without IncludeTags:\n%s", page)
+	}
+}
+
+func TestEPUBMode_PagesPerCard(t *testing.T) {
+	tests := []struct {
+		mode EPUBMode
+		want int
+	}{
+		{ModeQA, 2},
+		{EPUBMode(""), 2},
+		{ModeQuestionsOnly, 1},
+		{ModeInterleavedBlank, 3},
+		{EPUBMode("bogus"), 0},
+	}
+	for _, tt := range tests {
+		if got := tt.mode.PagesPerCard(); got != tt.want {
+			t.Errorf("PagesPerCard(%q) = %d, want %d", tt.mode, got, tt.want)
+		}
+	}
+}
+
+// epubFileSet lists the entry names of a generated EPUB.
+func epubFileSet(t *testing.T, data []byte) map[string]bool {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("epub is not a valid zip: %v", err)
+	}
+	files := make(map[string]bool)
+	for _, f := range r.File {
+		files[f.Name] = true
+	}
+	return files
+}
+
+func TestGenerateEPUBWithOptions_QuestionsOnly(t *testing.T) {
+	data, err := GenerateEPUBWithOptions(sampleCards(), DevicePresets[0], "Deck", EPUBOptions{Mode: ModeQuestionsOnly})
+	if err != nil {
+		t.Fatalf("GenerateEPUBWithOptions: %v", err)
+	}
+	files := epubFileSet(t, data)
+	for i := 1; i <= 3; i++ {
+		if !files[fmt.Sprintf("OEBPS/card_%04d_q.xhtml", i)] {
+			t.Errorf("question page %d missing", i)
+		}
+		if files[fmt.Sprintf("OEBPS/card_%04d_a.xhtml", i)] {
+			t.Errorf("answer page %d present in questions-only mode", i)
+		}
+	}
+}
+
+func TestGenerateEPUBWithOptions_InterleavedBlank(t *testing.T) {
+	data, err := GenerateEPUBWithOptions(sampleCards(), DevicePresets[0], "Deck", EPUBOptions{Mode: ModeInterleavedBlank})
+	if err != nil {
+		t.Fatalf("GenerateEPUBWithOptions: %v", err)
+	}
+	files := epubFileSet(t, data)
+	for _, name := range []string{"card_0001_q", "card_0001_b", "card_0001_a"} {
+		if !files["OEBPS/"+name+".xhtml"] {
+			t.Errorf("page %s missing", name)
+		}
+	}
+
+	blank := readEPUBPage(t, data, "OEBPS/card_0001_b.xhtml")
+	if !strings.Contains(blank, "Flip the page to reveal the answer.") {
+		t.Errorf("blank page lacks the flip hint:\n%s", blank)
+	}
+
+	// The spacer sits between question and answer in the reading order.
+	opf := readEPUBPage(t, data, "OEBPS/content.opf")
+	q := strings.Index(opf, `<itemref idref="card_0001_q"/>`)
+	b := strings.Index(opf, `<itemref idref="card_0001_b"/>`)
+	a := strings.Index(opf, `<itemref idref="card_0001_a"/>`)
+	if q < 0 || b < 0 || a < 0 || !(q < b && b < a) {
+		t.Errorf("spine order wrong (q=%d b=%d a=%d):\n%s", q, b, a, opf)
+	}
+}
+
+func TestGenerateEPUBWithOptions_UnknownMode(t *testing.T) {
+	if _, err := GenerateEPUBWithOptions(sampleCards(), DevicePresets[0], "Deck", EPUBOptions{Mode: "shuffled"}); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
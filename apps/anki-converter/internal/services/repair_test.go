@@ -0,0 +1,24 @@
+package services
+
+import "testing"
+
+func TestRepairHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"balanced untouched", "<p>front <em>side</em></p>", "<p>front <em>side</em></p>"},
+		{"unclosed tags closed", "<div><p>answer", "<div><p>answer</p></div>"},
+		{"stray closer dropped", "answer</div>", "answer"},
+		{"interleaved closers reordered", "<b><i>text</b></i>", "<b><i>text</i></b>"},
+		{"void elements ignored", "line<br/><img src=\"x.png\"/>", "line<br/><img src=\"x.png\"/>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repairHTML(tt.in); got != tt.want {
+				t.Errorf("repairHTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
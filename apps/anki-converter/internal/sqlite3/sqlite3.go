@@ -12,19 +12,32 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"strings"
 )
 
 const (
-	headerSize    = 100
-	headerMagic   = "SQLite format 3\x00"
-	btreeLeafPage = 0x0d
-	btreeIntPage  = 0x05
+	headerSize         = 100
+	headerMagic        = "SQLite format 3\x00"
+	btreeLeafPage      = 0x0d
+	btreeIntPage       = 0x05
+	btreeIndexLeafPage = 0x0a
+	btreeIndexIntPage  = 0x02
 )
 
 // DB is a minimal read-only SQLite3 database reader.
 type DB struct {
 	data     []byte
 	pageSize int
+	// reservedValues counts record values with serial types 10 or 11, which
+	// SQLite reserves for internal use and this reader decodes as NULL.
+	reservedValues int
+}
+
+// ReservedValueCount reports how many reserved serial-type values (types 10
+// and 11) were decoded as NULL across all reads so far, so callers can
+// surface that a database needed lenient parsing.
+func (db *DB) ReservedValueCount() int {
+	return db.reservedValues
 }
 
 // Row represents a single database row as a slice of values.
@@ -54,44 +67,217 @@ func Open(data []byte) (*DB, error) {
 // ReadTable reads all rows from the table with the given name.
 // It traverses the B-tree from the root page recorded in sqlite_master.
 func (db *DB) ReadTable(tableName string) ([]Row, error) {
+	rootPage, _, err := db.masterEntry(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	pageData, err := db.page(rootPage)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3: read table root page: %w", err)
+	}
+
+	return db.readBTreeTable(pageData, rootPage)
+}
+
+// ReadColumns reads only the requested columns of every row, in the order
+// given. Indices use the same positions as ReadTable rows, where index 0 is
+// the rowid. Unwanted values are skipped by their serial-type byte length
+// instead of being materialized, which avoids most of the allocation cost of
+// a full read when only one or two columns matter.
+func (db *DB) ReadColumns(tableName string, indices []int) ([]Row, error) {
+	rootPage, _, err := db.masterEntry(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	pageData, err := db.page(rootPage)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3: read table root page: %w", err)
+	}
+
+	return db.readBTreeTableWith(pageData, rootPage, func(page []byte, offset int) (Row, error) {
+		return db.parseRecordColumns(page, offset, indices)
+	})
+}
+
+// CountRows counts the rows of the table without parsing records or
+// allocating Row slices: leaf pages contribute their cell counts directly and
+// interior pages are recursed. Meaningfully lighter than len(ReadTable(...))
+// when only the count is needed.
+func (db *DB) CountRows(tableName string) (int, error) {
+	rootPage, _, err := db.masterEntry(tableName)
+	if err != nil {
+		return 0, err
+	}
+	return db.countBTreeRows(rootPage)
+}
+
+// countBTreeRows sums leaf cell counts across the B-tree rooted at pageNum.
+func (db *DB) countBTreeRows(pageNum int) (int, error) {
+	pageData, err := db.page(pageNum)
+	if err != nil {
+		return 0, err
+	}
+
+	headerOffset := 0
+	if pageNum == 1 {
+		headerOffset = 100
+	}
+	if len(pageData) < headerOffset+8 {
+		return 0, fmt.Errorf("sqlite3: page %d too small for header offset %d", pageNum, headerOffset)
+	}
+	numCells := int(binary.BigEndian.Uint16(pageData[headerOffset+3 : headerOffset+5]))
+
+	switch pageType := pageData[headerOffset]; pageType {
+	case btreeLeafPage:
+		return numCells, nil
+	case btreeIntPage:
+		if len(pageData) < headerOffset+12 {
+			return 0, fmt.Errorf("sqlite3: interior page too small")
+		}
+		total := 0
+		cellPtrOffset := headerOffset + 12
+		for i := 0; i < numCells; i++ {
+			ptrPos := cellPtrOffset + i*2
+			if ptrPos+2 > len(pageData) {
+				return 0, fmt.Errorf("sqlite3: interior cell pointer out of range")
+			}
+			cellOffset := int(binary.BigEndian.Uint16(pageData[ptrPos : ptrPos+2]))
+			if cellOffset+4 > len(pageData) {
+				return 0, fmt.Errorf("sqlite3: interior cell out of range")
+			}
+			leftChild := int(binary.BigEndian.Uint32(pageData[cellOffset : cellOffset+4]))
+			n, err := db.countBTreeRows(leftChild)
+			if err != nil {
+				return 0, err
+			}
+			total += n
+		}
+		if rightmostChild := int(binary.BigEndian.Uint32(pageData[headerOffset+8 : headerOffset+12])); rightmostChild > 0 {
+			n, err := db.countBTreeRows(rightmostChild)
+			if err != nil {
+				return 0, err
+			}
+			total += n
+		}
+		return total, nil
+	default:
+		return 0, fmt.Errorf("sqlite3: unexpected page type 0x%02x on page %d", pageType, pageNum)
+	}
+}
+
+// TableColumns returns the declared column names of the table, in order,
+// parsed from its CREATE TABLE statement in sqlite_master.
+func (db *DB) TableColumns(tableName string) ([]string, error) {
+	_, sqlText, err := db.masterEntry(tableName)
+	if err != nil {
+		return nil, err
+	}
+	columns := parseCreateColumns(sqlText)
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("sqlite3: no columns parsed from %q schema", tableName)
+	}
+	return columns, nil
+}
+
+// ReadIndex reads all key records from the index with the given name, in key
+// order. Index records hold the indexed column values with the rowid as the
+// record's final column, rather than the rowid-keyed table record.
+func (db *DB) ReadIndex(indexName string) ([]Row, error) {
+	rootPage, _, err := db.masterEntryOfType("index", indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	pageData, err := db.page(rootPage)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3: read index root page: %w", err)
+	}
+
+	return db.readBTreeIndex(pageData, rootPage)
+}
+
+// masterEntry finds the table's sqlite_master row and returns its root page
+// number and CREATE TABLE statement.
+func (db *DB) masterEntry(tableName string) (int, string, error) {
+	return db.masterEntryOfType("table", tableName)
+}
+
+// masterEntryOfType finds the sqlite_master row of the given type ("table" or
+// "index") and name, returning its root page number and SQL statement.
+func (db *DB) masterEntryOfType(entryType, name string) (int, string, error) {
 	// Page 1 is the root page of sqlite_master.
 	rootPageData, err := db.page(1)
 	if err != nil {
-		return nil, fmt.Errorf("sqlite3: read master page: %w", err)
+		return 0, "", fmt.Errorf("sqlite3: read master page: %w", err)
 	}
 
-	// Read sqlite_master rows to find the root page of our table.
 	masterRows, err := db.readBTreeTable(rootPageData, 1)
 	if err != nil {
-		return nil, fmt.Errorf("sqlite3: read sqlite_master: %w", err)
+		return 0, "", fmt.Errorf("sqlite3: read sqlite_master: %w", err)
 	}
 
 	// sqlite_master columns: rowid, type, name, tbl_name, rootpage, sql
-	rootPage := -1
 	for _, row := range masterRows {
 		if len(row) < 6 {
 			continue
 		}
 		rowType, _ := row[1].(string)
 		rowName, _ := row[2].(string)
-		if rowType == "table" && rowName == tableName {
-			switch v := row[4].(type) {
-			case int64:
-				rootPage = int(v)
-			}
+		if rowType != entryType || rowName != name {
+			continue
+		}
+		rootPage, ok := row[4].(int64)
+		if !ok {
 			break
 		}
+		sqlText, _ := row[5].(string)
+		return int(rootPage), sqlText, nil
 	}
-	if rootPage < 0 {
-		return nil, fmt.Errorf("sqlite3: table %q not found", tableName)
-	}
+	return 0, "", fmt.Errorf("sqlite3: %s %q not found", entryType, name)
+}
 
-	pageData, err := db.page(rootPage)
-	if err != nil {
-		return nil, fmt.Errorf("sqlite3: read table root page: %w", err)
+// parseCreateColumns extracts the column names from a CREATE TABLE statement:
+// the parenthesized body is split on top-level commas and the first word of
+// each definition is the column name, unless it is a table-constraint keyword.
+func parseCreateColumns(sqlText string) []string {
+	open := strings.Index(sqlText, "(")
+	end := strings.LastIndex(sqlText, ")")
+	if open < 0 || end <= open {
+		return nil
 	}
 
-	return db.readBTreeTable(pageData, rootPage)
+	var columns []string
+	depth := 0
+	start := 0
+	body := sqlText[open+1 : end]
+	for i := 0; i <= len(body); i++ {
+		if i < len(body) {
+			switch body[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			}
+			if body[i] != ',' || depth > 0 {
+				continue
+			}
+		}
+		definition := strings.Fields(body[start:i])
+		start = i + 1
+		if len(definition) == 0 {
+			continue
+		}
+		switch strings.ToUpper(definition[0]) {
+		case "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "CONSTRAINT":
+			continue // table constraint, not a column
+		}
+		columns = append(columns, strings.Trim(definition[0], "\"'`[]"))
+	}
+	return columns
 }
 
 // page returns the raw bytes for a given 1-indexed page number.
@@ -106,6 +292,12 @@ func (db *DB) page(n int) ([]byte, error) {
 // readBTreeTable reads all rows from a B-tree table starting at the given page.
 // pageNum is 1-indexed and is passed for interior page child resolution.
 func (db *DB) readBTreeTable(pageData []byte, pageNum int) ([]Row, error) {
+	return db.readBTreeTableWith(pageData, pageNum, db.parseRecord)
+}
+
+// readBTreeTableWith is readBTreeTable with a pluggable cell parser, so full
+// and projected reads share the same traversal.
+func (db *DB) readBTreeTableWith(pageData []byte, pageNum int, parseCell func([]byte, int) (Row, error)) ([]Row, error) {
 	// Offset into page for the B-tree header. Page 1 has a 100-byte db header first.
 	headerOffset := 0
 	if pageNum == 1 {
@@ -119,16 +311,16 @@ func (db *DB) readBTreeTable(pageData []byte, pageNum int) ([]Row, error) {
 
 	switch pageType {
 	case btreeLeafPage:
-		return db.readLeafPage(pageData, headerOffset)
+		return db.readLeafPage(pageData, headerOffset, parseCell)
 	case btreeIntPage:
-		return db.readInteriorPage(pageData, headerOffset, pageNum)
+		return db.readInteriorPage(pageData, headerOffset, pageNum, parseCell)
 	default:
 		return nil, fmt.Errorf("sqlite3: unexpected page type 0x%02x on page %d", pageType, pageNum)
 	}
 }
 
 // readLeafPage reads all cell records from a B-tree leaf table page.
-func (db *DB) readLeafPage(pageData []byte, headerOffset int) ([]Row, error) {
+func (db *DB) readLeafPage(pageData []byte, headerOffset int, parseCell func([]byte, int) (Row, error)) ([]Row, error) {
 	if len(pageData) < headerOffset+8 {
 		return nil, fmt.Errorf("sqlite3: leaf page too small")
 	}
@@ -143,7 +335,7 @@ func (db *DB) readLeafPage(pageData []byte, headerOffset int) ([]Row, error) {
 			return nil, fmt.Errorf("sqlite3: cell pointer array out of range")
 		}
 		cellOffset := int(binary.BigEndian.Uint16(pageData[ptrPos : ptrPos+2]))
-		row, err := db.parseRecord(pageData, cellOffset)
+		row, err := parseCell(pageData, cellOffset)
 		if err != nil {
 			return nil, err
 		}
@@ -154,7 +346,7 @@ func (db *DB) readLeafPage(pageData []byte, headerOffset int) ([]Row, error) {
 
 // readInteriorPage recursively reads all rows from an interior B-tree page
 // by following its child page pointers.
-func (db *DB) readInteriorPage(pageData []byte, headerOffset, _ int) ([]Row, error) {
+func (db *DB) readInteriorPage(pageData []byte, headerOffset, _ int, parseCell func([]byte, int) (Row, error)) ([]Row, error) {
 	if len(pageData) < headerOffset+12 {
 		return nil, fmt.Errorf("sqlite3: interior page too small")
 	}
@@ -182,11 +374,122 @@ func (db *DB) readInteriorPage(pageData []byte, headerOffset, _ int) ([]Row, err
 		if err != nil {
 			return nil, err
 		}
-		childRows, err := db.readBTreeTable(childPage, leftChild)
+		childRows, err := db.readBTreeTableWith(childPage, leftChild, parseCell)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, childRows...)
+	}
+
+	// Follow the rightmost child pointer.
+	if rightmostChild > 0 {
+		rightPage, err := db.page(rightmostChild)
+		if err != nil {
+			return nil, err
+		}
+		rightRows, err := db.readBTreeTableWith(rightPage, rightmostChild, parseCell)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, rightRows...)
+	}
+
+	return rows, nil
+}
+
+// readBTreeIndex reads all key records from an index B-tree starting at the
+// given page. Index pages hold key records directly instead of rowid-keyed
+// table records.
+func (db *DB) readBTreeIndex(pageData []byte, pageNum int) ([]Row, error) {
+	// Offset into page for the B-tree header. Page 1 has a 100-byte db header first.
+	headerOffset := 0
+	if pageNum == 1 {
+		headerOffset = 100
+	}
+
+	if headerOffset >= len(pageData) {
+		return nil, fmt.Errorf("sqlite3: page %d too small for header offset %d", pageNum, headerOffset)
+	}
+	pageType := pageData[headerOffset]
+
+	switch pageType {
+	case btreeIndexLeafPage:
+		return db.readIndexLeafPage(pageData, headerOffset)
+	case btreeIndexIntPage:
+		return db.readIndexInteriorPage(pageData, headerOffset)
+	default:
+		return nil, fmt.Errorf("sqlite3: unexpected index page type 0x%02x on page %d", pageType, pageNum)
+	}
+}
+
+// readIndexLeafPage reads all key records from an index B-tree leaf page.
+func (db *DB) readIndexLeafPage(pageData []byte, headerOffset int) ([]Row, error) {
+	if len(pageData) < headerOffset+8 {
+		return nil, fmt.Errorf("sqlite3: index leaf page too small")
+	}
+	numCells := int(binary.BigEndian.Uint16(pageData[headerOffset+3 : headerOffset+5]))
+	// Cell pointer array starts immediately after the 8-byte B-tree page header.
+	cellPtrOffset := headerOffset + 8
+
+	var rows []Row
+	for i := 0; i < numCells; i++ {
+		ptrPos := cellPtrOffset + i*2
+		if ptrPos+2 > len(pageData) {
+			return nil, fmt.Errorf("sqlite3: index cell pointer array out of range")
+		}
+		cellOffset := int(binary.BigEndian.Uint16(pageData[ptrPos : ptrPos+2]))
+		row, err := db.parseIndexRecord(pageData, cellOffset)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// readIndexInteriorPage reads key records from an index interior page in key
+// order: each cell's left subtree, then the cell's own key record, then the
+// rightmost child last. Unlike table interior pages, index interior cells
+// carry real records, not just routing keys.
+func (db *DB) readIndexInteriorPage(pageData []byte, headerOffset int) ([]Row, error) {
+	if len(pageData) < headerOffset+12 {
+		return nil, fmt.Errorf("sqlite3: index interior page too small")
+	}
+	numCells := int(binary.BigEndian.Uint16(pageData[headerOffset+3 : headerOffset+5]))
+	rightmostChild := int(binary.BigEndian.Uint32(pageData[headerOffset+8 : headerOffset+12]))
+
+	// Cell pointer array starts after the 12-byte interior page header.
+	cellPtrOffset := headerOffset + 12
+
+	var rows []Row
+
+	// Each interior cell: 4-byte left child page number + key record payload.
+	for i := 0; i < numCells; i++ {
+		ptrPos := cellPtrOffset + i*2
+		if ptrPos+2 > len(pageData) {
+			return nil, fmt.Errorf("sqlite3: index interior cell pointer out of range")
+		}
+		cellOffset := int(binary.BigEndian.Uint16(pageData[ptrPos : ptrPos+2]))
+		if cellOffset+4 > len(pageData) {
+			return nil, fmt.Errorf("sqlite3: index interior cell out of range")
+		}
+		leftChild := int(binary.BigEndian.Uint32(pageData[cellOffset : cellOffset+4]))
+
+		childPage, err := db.page(leftChild)
+		if err != nil {
+			return nil, err
+		}
+		childRows, err := db.readBTreeIndex(childPage, leftChild)
 		if err != nil {
 			return nil, err
 		}
 		rows = append(rows, childRows...)
+
+		row, err := db.parseIndexRecord(pageData, cellOffset+4)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
 	}
 
 	// Follow the rightmost child pointer.
@@ -195,7 +498,7 @@ func (db *DB) readInteriorPage(pageData []byte, headerOffset, _ int) ([]Row, err
 		if err != nil {
 			return nil, err
 		}
-		rightRows, err := db.readBTreeTable(rightPage, rightmostChild)
+		rightRows, err := db.readBTreeIndex(rightPage, rightmostChild)
 		if err != nil {
 			return nil, err
 		}
@@ -236,7 +539,149 @@ func (db *DB) parseRecord(pageData []byte, offset int) (Row, error) {
 		return Row{nil, nil}, nil
 	}
 
-	// Record header
+	values, err := r.readRecordBody()
+	if err != nil {
+		return nil, err
+	}
+	db.reservedValues += r.reservedValues
+	return append(Row{rowid}, values...), nil
+}
+
+// parseRecordColumns is parseRecord restricted to the column positions in
+// indices (0 is the rowid). The record header is parsed as usual, but
+// unwanted values are skipped by their serial-type byte length. The returned
+// row holds the requested values in the order given; positions past the end
+// of the record come back nil.
+func (db *DB) parseRecordColumns(pageData []byte, offset int, indices []int) (Row, error) {
+	if offset >= len(pageData) {
+		return nil, fmt.Errorf("sqlite3: cell offset %d out of range", offset)
+	}
+
+	r := &byteReader{data: pageData, pos: offset}
+
+	payloadSize, err := r.readVarint()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3: payload size varint: %w", err)
+	}
+	rowid, err := r.readVarint()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3: rowid varint: %w", err)
+	}
+
+	// Same single-page payload assumption as parseRecord: skip overflow
+	// cells gracefully.
+	if payloadSize > int64(db.pageSize-35) {
+		return make(Row, len(indices)), nil
+	}
+
+	headerStart := r.pos
+	headerSize, err := r.readVarint()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3: record header size: %w", err)
+	}
+	serialTypes := []int64{}
+	for r.pos < headerStart+int(headerSize) {
+		st, err := r.readVarint()
+		if err != nil {
+			return nil, fmt.Errorf("sqlite3: serial type varint: %w", err)
+		}
+		serialTypes = append(serialTypes, st)
+	}
+
+	values := map[int]interface{}{0: rowid}
+	for i, st := range serialTypes {
+		pos := i + 1 // position 0 is the rowid
+		wanted := false
+		for _, idx := range indices {
+			if idx == pos {
+				wanted = true
+				break
+			}
+		}
+		if !wanted {
+			n, err := serialTypeSize(st)
+			if err != nil {
+				return nil, err
+			}
+			r.pos += n
+			continue
+		}
+		val, err := r.readValue(st)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite3: read value (serial type %d): %w", st, err)
+		}
+		values[pos] = val
+	}
+	db.reservedValues += r.reservedValues
+
+	row := make(Row, len(indices))
+	for i, idx := range indices {
+		row[i] = values[idx]
+	}
+	return row, nil
+}
+
+// serialTypeSize returns the byte length of a value with the given serial
+// type, used to skip unwanted columns without decoding them.
+func serialTypeSize(st int64) (int, error) {
+	switch st {
+	case 0, 8, 9, 10, 11:
+		return 0, nil
+	case 1:
+		return 1, nil
+	case 2:
+		return 2, nil
+	case 3:
+		return 3, nil
+	case 4:
+		return 4, nil
+	case 5:
+		return 6, nil
+	case 6, 7:
+		return 8, nil
+	}
+	if st >= 12 && st%2 == 0 {
+		return int((st - 12) / 2), nil // BLOB
+	}
+	if st >= 13 {
+		return int((st - 13) / 2), nil // TEXT
+	}
+	return 0, fmt.Errorf("sqlite3: unknown serial type %d", st)
+}
+
+// parseIndexRecord parses an index B-tree cell record starting at offset.
+// Format: varint(payload_size) + record_header + record_body — there is no
+// rowid varint; for rowid tables the rowid is the record's last column.
+func (db *DB) parseIndexRecord(pageData []byte, offset int) (Row, error) {
+	if offset >= len(pageData) {
+		return nil, fmt.Errorf("sqlite3: index cell offset %d out of range", offset)
+	}
+
+	r := &byteReader{data: pageData, pos: offset}
+
+	payloadSize, err := r.readVarint()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3: index payload size varint: %w", err)
+	}
+
+	// Same single-page payload assumption as parseRecord: skip overflow cells
+	// gracefully rather than chase overflow chains.
+	maxLocal := db.pageSize - 35
+	if payloadSize > int64(maxLocal) {
+		return Row{nil}, nil
+	}
+
+	row, err := r.readRecordBody()
+	if err != nil {
+		return nil, err
+	}
+	db.reservedValues += r.reservedValues
+	return row, nil
+}
+
+// readRecordBody reads a record (serial-type header plus values) at the
+// reader's current position.
+func (r *byteReader) readRecordBody() (Row, error) {
 	headerStart := r.pos
 	headerSize, err := r.readVarint()
 	if err != nil {
@@ -254,7 +699,7 @@ func (db *DB) parseRecord(pageData []byte, offset int) (Row, error) {
 	}
 
 	// Read values.
-	row := Row{rowid}
+	row := Row{}
 	for _, st := range serialTypes {
 		val, err := r.readValue(st)
 		if err != nil {
@@ -266,10 +711,12 @@ func (db *DB) parseRecord(pageData []byte, offset int) (Row, error) {
 	return row, nil
 }
 
-// byteReader is a simple sequential reader over a byte slice.
+// byteReader is a simple sequential reader over a byte slice. It counts
+// reserved serial-type values it had to decode as NULL.
 type byteReader struct {
-	data []byte
-	pos  int
+	data           []byte
+	pos            int
+	reservedValues int
 }
 
 func (r *byteReader) readByte() (byte, error) {
@@ -358,6 +805,11 @@ func (r *byteReader) readValue(serialType int64) (interface{}, error) {
 		return int64(0), nil
 	case 9:
 		return int64(1), nil
+	case 10, 11:
+		// Reserved for SQLite internal use; no payload bytes follow. Decode
+		// as NULL so one odd value doesn't abort the whole table read.
+		r.reservedValues++
+		return nil, nil
 	default:
 		if serialType >= 12 && serialType%2 == 0 {
 			// BLOB
@@ -0,0 +1,317 @@
+package sqlite3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+const testPageSize = 4096
+
+// testRecord serializes a record body from int64 (small positive only) and
+// string values.
+func testRecord(values ...interface{}) []byte {
+	var types, body bytes.Buffer
+	for _, v := range values {
+		switch val := v.(type) {
+		case int64: // small positive integers only
+			types.WriteByte(0x01)
+			body.WriteByte(byte(val))
+		case string:
+			serialType := int64(13 + len(val)*2)
+			if serialType > 0x7f {
+				types.Write([]byte{byte(serialType>>7) | 0x80, byte(serialType & 0x7f)})
+			} else {
+				types.WriteByte(byte(serialType))
+			}
+			body.WriteString(val)
+		}
+	}
+	var rec bytes.Buffer
+	rec.WriteByte(byte(1 + types.Len()))
+	rec.Write(types.Bytes())
+	rec.Write(body.Bytes())
+	return rec.Bytes()
+}
+
+// testTableCell builds a table leaf cell: payload size + rowid + record.
+func testTableCell(rowid int64, values ...interface{}) []byte {
+	rec := testRecord(values...)
+	var c bytes.Buffer
+	if len(rec) > 0x7f {
+		c.Write([]byte{byte(len(rec)>>7) | 0x80, byte(len(rec) & 0x7f)})
+	} else {
+		c.WriteByte(byte(len(rec)))
+	}
+	c.WriteByte(byte(rowid))
+	c.Write(rec)
+	return c.Bytes()
+}
+
+// testIndexCell builds an index leaf cell: payload size + record, no rowid.
+func testIndexCell(values ...interface{}) []byte {
+	rec := testRecord(values...)
+	var c bytes.Buffer
+	c.WriteByte(byte(len(rec)))
+	c.Write(rec)
+	return c.Bytes()
+}
+
+// writeTestPage lays out a leaf page: 8-byte B-tree header at headerOffset,
+// cell pointer array after it, cells packed from the page end.
+func writeTestPage(page []byte, headerOffset int, pageType byte, cells [][]byte) {
+	contentStart := len(page)
+	page[headerOffset] = pageType
+	binary.BigEndian.PutUint16(page[headerOffset+3:], uint16(len(cells)))
+	for i, c := range cells {
+		contentStart -= len(c)
+		copy(page[contentStart:], c)
+		binary.BigEndian.PutUint16(page[headerOffset+8+i*2:], uint16(contentStart))
+	}
+	binary.BigEndian.PutUint16(page[headerOffset+5:], uint16(contentStart))
+}
+
+// writeTestInteriorPage lays out a table interior page: 12-byte header with
+// the rightmost child pointer, then cells of 4-byte child page + varint key.
+func writeTestInteriorPage(page []byte, children []int, keys []int64, rightmost int) {
+	page[0] = btreeIntPage
+	binary.BigEndian.PutUint16(page[3:], uint16(len(children)))
+	binary.BigEndian.PutUint32(page[8:], uint32(rightmost))
+	contentStart := len(page)
+	for i, child := range children {
+		cell := make([]byte, 5)
+		binary.BigEndian.PutUint32(cell, uint32(child))
+		cell[4] = byte(keys[i]) // small positive rowid keys only
+		contentStart -= len(cell)
+		copy(page[contentStart:], cell)
+		binary.BigEndian.PutUint16(page[12+i*2:], uint16(contentStart))
+	}
+	binary.BigEndian.PutUint16(page[5:], uint16(contentStart))
+}
+
+// newTestDB allocates a database image of n pages with a valid file header.
+func newTestDB(n int) []byte {
+	db := make([]byte, testPageSize*n)
+	copy(db, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(db[16:18], testPageSize)
+	db[18], db[19], db[21], db[22], db[23] = 1, 1, 64, 32, 32
+	binary.BigEndian.PutUint32(db[28:32], uint32(n)) // number of pages
+	binary.BigEndian.PutUint32(db[56:60], 1)         // UTF-8
+	return db
+}
+
+// buildIndexedDB fabricates a three-page database: sqlite_master on page 1
+// with a notes table (root page 2) and an index on its guid column (root
+// page 3). The index leaf holds one key record per note: (guid, rowid).
+func buildIndexedDB(t *testing.T, guids []string) []byte {
+	t.Helper()
+
+	notesCells := make([][]byte, len(guids))
+	indexCells := make([][]byte, len(guids))
+	for i, guid := range guids {
+		notesCells[i] = testTableCell(int64(i+1), int64(i+1), guid)
+		indexCells[i] = testIndexCell(guid, int64(i+1))
+	}
+	masterCells := [][]byte{
+		testTableCell(1, "table", "notes", "notes", int64(2),
+			`CREATE TABLE notes (id INTEGER PRIMARY KEY, guid TEXT NOT NULL)`),
+		testTableCell(2, "index", "idx_notes_guid", "notes", int64(3),
+			`CREATE INDEX idx_notes_guid ON notes (guid)`),
+	}
+
+	db := newTestDB(3)
+	writeTestPage(db[:testPageSize], 100, btreeLeafPage, masterCells)
+	writeTestPage(db[testPageSize:testPageSize*2], 0, btreeLeafPage, notesCells)
+	writeTestPage(db[testPageSize*2:], 0, btreeIndexLeafPage, indexCells)
+	return db
+}
+
+// buildMultiPageDB fabricates a notes table spanning an interior root
+// (page 2) with two leaf children (pages 3 and 4) holding rowsPerLeaf rows
+// each.
+func buildMultiPageDB(t *testing.T, rowsPerLeaf int) []byte {
+	t.Helper()
+
+	leafCells := func(firstRowid int) [][]byte {
+		cells := make([][]byte, rowsPerLeaf)
+		for i := range cells {
+			rowid := int64(firstRowid + i)
+			cells[i] = testTableCell(rowid, rowid, fmt.Sprintf("note %d", rowid))
+		}
+		return cells
+	}
+	masterCells := [][]byte{
+		testTableCell(1, "table", "notes", "notes", int64(2),
+			`CREATE TABLE notes (id INTEGER PRIMARY KEY, flds TEXT NOT NULL)`),
+	}
+
+	db := newTestDB(4)
+	writeTestPage(db[:testPageSize], 100, btreeLeafPage, masterCells)
+	writeTestInteriorPage(db[testPageSize:testPageSize*2], []int{3}, []int64{int64(rowsPerLeaf)}, 4)
+	writeTestPage(db[testPageSize*2:testPageSize*3], 0, btreeLeafPage, leafCells(1))
+	writeTestPage(db[testPageSize*3:], 0, btreeLeafPage, leafCells(rowsPerLeaf+1))
+	return db
+}
+
+func TestReadIndex(t *testing.T) {
+	guids := []string{"aaa", "bbb", "ccc"}
+	db, err := Open(buildIndexedDB(t, guids))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	rows, err := db.ReadIndex("idx_notes_guid")
+	if err != nil {
+		t.Fatalf("ReadIndex: %v", err)
+	}
+	if len(rows) != len(guids) {
+		t.Fatalf("len(rows) = %d, want %d", len(rows), len(guids))
+	}
+	for i, row := range rows {
+		if len(row) != 2 {
+			t.Fatalf("rows[%d] has %d columns, want 2 (key, rowid)", i, len(row))
+		}
+		if guid, _ := row[0].(string); guid != guids[i] {
+			t.Errorf("rows[%d] key = %v, want %q", i, row[0], guids[i])
+		}
+		if rowid, _ := row[1].(int64); rowid != int64(i+1) {
+			t.Errorf("rows[%d] rowid = %v, want %d", i, row[1], i+1)
+		}
+	}
+}
+
+func TestReadIndex_NotFound(t *testing.T) {
+	db, err := Open(buildIndexedDB(t, []string{"aaa"}))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := db.ReadIndex("no_such_index"); err == nil {
+		t.Errorf("ReadIndex on a missing index should error")
+	}
+}
+
+func TestCountRows_MatchesReadTableAcrossPages(t *testing.T) {
+	db, err := Open(buildMultiPageDB(t, 5))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	rows, err := db.ReadTable("notes")
+	if err != nil {
+		t.Fatalf("ReadTable: %v", err)
+	}
+	count, err := db.CountRows("notes")
+	if err != nil {
+		t.Fatalf("CountRows: %v", err)
+	}
+	if count != len(rows) {
+		t.Errorf("CountRows = %d, want %d (len(ReadTable))", count, len(rows))
+	}
+	if count != 10 {
+		t.Errorf("CountRows = %d, want 10", count)
+	}
+}
+
+func TestCountRows_UnknownTable(t *testing.T) {
+	db, err := Open(buildMultiPageDB(t, 1))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := db.CountRows("no_such_table"); err == nil {
+		t.Errorf("CountRows on a missing table should error")
+	}
+}
+
+func TestReadColumns_MatchesFullRead(t *testing.T) {
+	db, err := Open(buildMultiPageDB(t, 5))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	full, err := db.ReadTable("notes")
+	if err != nil {
+		t.Fatalf("ReadTable: %v", err)
+	}
+
+	for _, indices := range [][]int{{2}, {0, 2}, {2, 0, 1}} {
+		rows, err := db.ReadColumns("notes", indices)
+		if err != nil {
+			t.Fatalf("ReadColumns(%v): %v", indices, err)
+		}
+		if len(rows) != len(full) {
+			t.Fatalf("ReadColumns(%v) returned %d rows, want %d", indices, len(rows), len(full))
+		}
+		for i, row := range rows {
+			if len(row) != len(indices) {
+				t.Fatalf("rows[%d] has %d values, want %d", i, len(row), len(indices))
+			}
+			for j, idx := range indices {
+				if row[j] != full[i][idx] {
+					t.Errorf("ReadColumns(%v) rows[%d][%d] = %v, want %v", indices, i, j, row[j], full[i][idx])
+				}
+			}
+		}
+	}
+}
+
+func TestReadColumns_PastRecordEndIsNil(t *testing.T) {
+	db, err := Open(buildMultiPageDB(t, 1))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	rows, err := db.ReadColumns("notes", []int{1, 7})
+	if err != nil {
+		t.Fatalf("ReadColumns: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("len(rows) = %d, want 2", len(rows))
+	}
+	if rows[0][1] != nil {
+		t.Errorf("rows[0][1] = %v, want nil for a column past the record end", rows[0][1])
+	}
+}
+
+func TestReadTable_ReservedSerialTypeParsesAsNil(t *testing.T) {
+	// Hand-built record (id=1, <reserved type 10>, "x"): header size 4 with
+	// serial types 1, 10 and 15, body holding just the int byte and the text.
+	rec := []byte{4, 0x01, 10, 15, 0x01, 'x'}
+	var cell bytes.Buffer
+	cell.WriteByte(byte(len(rec))) // payload size
+	cell.WriteByte(1)              // rowid
+	cell.Write(rec)
+
+	masterCells := [][]byte{
+		testTableCell(1, "table", "notes", "notes", int64(2),
+			`CREATE TABLE notes (id INTEGER PRIMARY KEY, extra, flds TEXT)`),
+	}
+	dbData := newTestDB(2)
+	writeTestPage(dbData[:testPageSize], 100, btreeLeafPage, masterCells)
+	writeTestPage(dbData[testPageSize:], 0, btreeLeafPage, [][]byte{cell.Bytes()})
+
+	db, err := Open(dbData)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	rows, err := db.ReadTable("notes")
+	if err != nil {
+		t.Fatalf("ReadTable: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("len(rows) = %d, want 1", len(rows))
+	}
+	row := rows[0]
+	if len(row) != 4 {
+		t.Fatalf("row has %d values, want 4 (rowid, id, nil, flds): %v", len(row), row)
+	}
+	if row[2] != nil {
+		t.Errorf("row[2] = %v, want nil for reserved serial type", row[2])
+	}
+	if s, _ := row[3].(string); s != "x" {
+		t.Errorf("row[3] = %v, want \"x\" (value after the reserved type)", row[3])
+	}
+	if got := db.ReservedValueCount(); got != 1 {
+		t.Errorf("ReservedValueCount = %d, want 1", got)
+	}
+}
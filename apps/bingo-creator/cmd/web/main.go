@@ -2,9 +2,11 @@ package main
 
 import (
 	"bingo-creator/internal/services"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
@@ -46,8 +48,6 @@ func main() {
 			Default: "/static/icon.png",
 		},
 		Scripts: []string{
-			"https://cdnjs.cloudflare.com/ajax/libs/html2canvas/1.4.1/html2canvas.min.js",
-			"https://cdnjs.cloudflare.com/ajax/libs/jspdf/3.0.3/jspdf.umd.min.js",
 			"/static/app.js",
 		},
 		StartURL:  "/bingo-creator/",
@@ -83,6 +83,12 @@ func (h *home) OnMount(ctx app.Context) {
 	h.storage = services.NewStorage()
 	h.gridSize = 5 // Default 5x5 grid
 	h.grid = nil   // No grid initially
+
+	// Restore persisted UI preferences, if any.
+	if gridSize, showHints, ok := h.storage.LoadSettings(); ok {
+		h.gridSize = gridSize
+		h.showHints = showHints
+	}
 }
 
 // Render renders the home component
@@ -141,14 +147,14 @@ func (h *home) renderControls() app.UI {
 						Class("form-select").
 						OnChange(h.onGridSizeChange).
 						Body(
-							app.Option().Value("3").Text("3x3"),
-							app.Option().Value("4").Text("4x4"),
+							app.Option().Value("3").Text("3x3").Selected(h.gridSize == 3),
+							app.Option().Value("4").Text("4x4").Selected(h.gridSize == 4),
 							app.Option().Value("5").Text("5x5").Selected(h.gridSize == 5),
-							app.Option().Value("6").Text("6x6"),
-							app.Option().Value("7").Text("7x7"),
-							app.Option().Value("8").Text("8x8"),
-							app.Option().Value("9").Text("9x9"),
-							app.Option().Value("10").Text("10x10"),
+							app.Option().Value("6").Text("6x6").Selected(h.gridSize == 6),
+							app.Option().Value("7").Text("7x7").Selected(h.gridSize == 7),
+							app.Option().Value("8").Text("8x8").Selected(h.gridSize == 8),
+							app.Option().Value("9").Text("9x9").Selected(h.gridSize == 9),
+							app.Option().Value("10").Text("10x10").Selected(h.gridSize == 10),
 						),
 				),
 			app.Div().
@@ -200,15 +206,20 @@ func (h *home) renderGridPreview() app.UI {
 
 	// Calculate required cells
 	requiredCells := h.gridSize * h.gridSize
-	_ = requiredCells                   // Avoid unused variable error
-	availableCells := requiredCells - 1 // Minus free space
+	availableCells := requiredCells
+	if services.HasCenterFreeSpace(h.gridSize) {
+		availableCells-- // Minus free space
+	}
 
-	// Build grid UI
+	// Build grid UI from the grid's own shape, so rectangular grids render
+	// correctly too.
+	rows, cols := len(h.grid), len(h.grid[0])
 	gridCells := []app.UI{}
-	for row := 0; row < h.gridSize; row++ {
-		for col := 0; col < h.gridSize; col++ {
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
 			cellText := h.grid[row][col]
-			isFreeSpace := row == h.gridSize/2 && col == h.gridSize/2
+			isFreeSpace := services.HasRectCenterFreeSpace(rows, cols) &&
+				row == rows/2 && col == cols/2
 
 			cell := app.Div().
 				Class("grid-cell").
@@ -230,15 +241,19 @@ func (h *home) renderGridPreview() app.UI {
 		Body(
 			app.Div().
 				Class("bingo-grid").
-				Style("grid-template-columns", fmt.Sprintf("repeat(%d, 1fr)", h.gridSize)).
+				Style("grid-template-columns", fmt.Sprintf("repeat(%d, 1fr)", cols)).
 				Body(gridCells...),
 		)
 
 	// Add hint if enabled
 	if h.showHints {
+		hintText := fmt.Sprintf("Items: %d available, %d needed", len(h.items), availableCells)
+		if services.HasCenterFreeSpace(h.gridSize) {
+			hintText += " (center cell is a Free Space)"
+		}
 		hint := app.P().
 			Class("grid-hint").
-			Text(fmt.Sprintf("Items: %d available, %d needed (including Free Space)", len(h.items), availableCells))
+			Text(hintText)
 		return app.Div().Body(
 			gridContainer,
 			hint,
@@ -280,6 +295,7 @@ func (h *home) onGridSizeChange(ctx app.Context, e app.Event) {
 	value := ctx.JSSrc().Get("value").String()
 	if size, err := strconv.Atoi(value); err == nil {
 		h.gridSize = size
+		h.storage.SaveSettings(h.gridSize, h.showHints)
 	}
 	ctx.Update()
 }
@@ -292,6 +308,7 @@ func (h *home) onItemsChange(ctx app.Context, e app.Event) {
 
 func (h *home) onShowHintsChange(ctx app.Context, e app.Event) {
 	h.showHints = ctx.JSSrc().Get("checked").Bool()
+	h.storage.SaveSettings(h.gridSize, h.showHints)
 	ctx.Update()
 }
 
@@ -316,9 +333,15 @@ func (h *home) onExportPDFClick(ctx app.Context, e app.Event) {
 	}
 
 	filename := h.storage.GenerateFilename(h.tripName)
+	title := strings.ReplaceAll(strings.TrimSuffix(filename, ".pdf"), "_", " ")
 
-	// Call the JavaScript PDF export function
-	app.Window().Call("exportBingoPDF", "bingo-grid-container", filename)
+	// Generate the PDF in Go and hand the bytes to JS for download.
+	data, err := services.GridToPDF(h.grid, title)
+	if err != nil {
+		app.Log(fmt.Errorf("export pdf: %w", err))
+		return
+	}
+	app.Window().Call("downloadPDF", base64.StdEncoding.EncodeToString(data), filename)
 }
 
 func (h *home) onClearClick(ctx app.Context, e app.Event) {
@@ -2,6 +2,9 @@ module bingo-creator
 
 go 1.26
 
-require github.com/maxence-charriere/go-app/v10 v10.1.11
+require (
+	github.com/go-pdf/fpdf v0.9.0
+	github.com/maxence-charriere/go-app/v10 v10.1.11
+)
 
 require github.com/google/uuid v1.6.0 // indirect
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// csvHeaderLabels are first-column values that mark a header row rather than
+// a real item when they appear on the first line of the file.
+var csvHeaderLabels = map[string]bool{
+	"item":  true,
+	"items": true,
+	"name":  true,
+	"text":  true,
+	"title": true,
+}
+
+// ParseItemsCSV reads CSV data and returns the first column of each record as
+// item text. Items are trimmed and deduplicated, empty lines are skipped, and
+// a header row (first column like "item" or "name") is detected and ignored.
+// This complements the existing textarea input for users who maintain their
+// item lists in spreadsheets.
+func ParseItemsCSV(data []byte) ([]string, error) {
+	records, err := readItemRecords(data)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]string, 0, len(records))
+	seen := make(map[string]bool)
+	for _, record := range records {
+		item := strings.TrimSpace(record[0])
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// ParseItemsCSVByCategory is the category-aware variant of ParseItemsCSV.
+// The second column is treated as the category; items without one are grouped
+// under the empty string key. Items are trimmed and deduplicated within each
+// category.
+func ParseItemsCSVByCategory(data []byte) (map[string][]string, error) {
+	records, err := readItemRecords(data)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make(map[string][]string)
+	seen := make(map[string]map[string]bool)
+	for _, record := range records {
+		item := strings.TrimSpace(record[0])
+		if item == "" {
+			continue
+		}
+		category := ""
+		if len(record) > 1 {
+			category = strings.TrimSpace(record[1])
+		}
+		if seen[category] == nil {
+			seen[category] = make(map[string]bool)
+		}
+		if seen[category][item] {
+			continue
+		}
+		seen[category][item] = true
+		categories[category] = append(categories[category], item)
+	}
+	return categories, nil
+}
+
+// readItemRecords parses the raw CSV and strips a detected header row.
+func readItemRecords(data []byte) ([][]string, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("csv data is empty")
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1 // allow rows with and without a category column
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse csv: %w", err)
+	}
+
+	// Drop empty records.
+	filtered := records[:0]
+	for _, record := range records {
+		if len(record) > 0 {
+			filtered = append(filtered, record)
+		}
+	}
+	records = filtered
+
+	// Skip a header row if detected.
+	if len(records) > 0 && csvHeaderLabels[strings.ToLower(strings.TrimSpace(records[0][0]))] {
+		records = records[1:]
+	}
+
+	return records, nil
+}
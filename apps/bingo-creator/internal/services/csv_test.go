@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestParseItemsCSV_HeaderRow(t *testing.T) {
+	data := []byte("Item,Category\nAlligator,Animals\nGas station snacks,Food\n")
+
+	items, err := ParseItemsCSV(data)
+	if err != nil {
+		t.Fatalf("ParseItemsCSV: %v", err)
+	}
+
+	want := []string{"Alligator", "Gas station snacks"}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items %v, want %d", len(items), items, len(want))
+	}
+	for i, item := range items {
+		if item != want[i] {
+			t.Errorf("item %d: got %q, want %q", i, item, want[i])
+		}
+	}
+}
+
+func TestParseItemsCSV_QuotedFields(t *testing.T) {
+	data := []byte("\"Stop for gas, again\",Road\n\"He says \"\"are we there yet\"\"\",Quotes\n")
+
+	items, err := ParseItemsCSV(data)
+	if err != nil {
+		t.Fatalf("ParseItemsCSV: %v", err)
+	}
+
+	want := []string{"Stop for gas, again", `He says "are we there yet"`}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items %v, want %d", len(items), items, len(want))
+	}
+	for i, item := range items {
+		if item != want[i] {
+			t.Errorf("item %d: got %q, want %q", i, item, want[i])
+		}
+	}
+}
+
+func TestParseItemsCSV_EmptyLinesAndDuplicates(t *testing.T) {
+	data := []byte("Alligator\n\nAlligator\n  \nBillboard\n")
+
+	items, err := ParseItemsCSV(data)
+	if err != nil {
+		t.Fatalf("ParseItemsCSV: %v", err)
+	}
+
+	want := []string{"Alligator", "Billboard"}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items %v, want %d", len(items), items, len(want))
+	}
+}
+
+func TestParseItemsCSV_Empty(t *testing.T) {
+	if _, err := ParseItemsCSV(nil); err == nil {
+		t.Error("expected error for empty csv data")
+	}
+}
+
+func TestParseItemsCSVByCategory(t *testing.T) {
+	data := []byte("Item,Category\nAlligator,Animals\nArmadillo,Animals\nBillboard\n")
+
+	categories, err := ParseItemsCSVByCategory(data)
+	if err != nil {
+		t.Fatalf("ParseItemsCSVByCategory: %v", err)
+	}
+
+	if got := len(categories["Animals"]); got != 2 {
+		t.Errorf("Animals: got %d items, want 2", got)
+	}
+	if got := len(categories[""]); got != 1 {
+		t.Errorf("uncategorized: got %d items, want 1", got)
+	}
+}
@@ -1,6 +1,9 @@
 package services
 
 import (
+	crand "crypto/rand"
+	"fmt"
+	"math/big"
 	"math/rand" // nosemgrep: go.lang.security.audit.crypto.math_random.math-random-used
 	"strings"
 )
@@ -12,9 +15,30 @@ const (
 	EmptyCell = "EMPTY"
 )
 
+// indexSource yields the random indices driving the Fisher-Yates shuffle, so
+// the same algorithm can run off math/rand or crypto/rand.
+type indexSource interface {
+	// Intn returns a uniform random int in [0, n). n must be > 0.
+	Intn(n int) int
+}
+
+// cryptoSource is an indexSource backed by crypto/rand, for shuffles that
+// must be unpredictable (contests rather than road trips).
+type cryptoSource struct{}
+
+func (cryptoSource) Intn(n int) int {
+	v, err := crand.Int(crand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		// crypto/rand only fails when the OS entropy source is broken;
+		// there is no sensible fallback.
+		panic(fmt.Sprintf("crypto/rand: %v", err))
+	}
+	return int(v.Int64())
+}
+
 // Generator handles bingo card generation
 type Generator struct {
-	rand *rand.Rand
+	rand indexSource
 }
 
 // NewGenerator creates a new Generator instance
@@ -24,11 +48,46 @@ func NewGenerator() *Generator {
 	}
 }
 
+// NewSecureGenerator creates a Generator whose shuffles draw indices from
+// crypto/rand instead of math/rand, for provably unbiased, non-predictable
+// cards. Everything else behaves exactly like NewGenerator.
+func NewSecureGenerator() *Generator {
+	return &Generator{rand: cryptoSource{}}
+}
+
+// DedupeMode controls how aggressively NormalizeItemsWithMode merges items.
+type DedupeMode int
+
+const (
+	// DedupeNone keeps every non-blank line, duplicates included.
+	DedupeNone DedupeMode = iota
+	// DedupeExact merges lines that are identical after trimming.
+	DedupeExact
+	// DedupeCaseInsensitive additionally ignores letter case, so "New York"
+	// and "new york" merge.
+	DedupeCaseInsensitive
+	// DedupeCollapseWhitespace is the most aggressive: case is ignored and
+	// runs of internal whitespace collapse, so "ice  cream" and "Ice cream"
+	// merge.
+	DedupeCollapseWhitespace
+)
+
 // NormalizeItems processes the raw input items:
 // - Trims whitespace from each line
 // - Removes empty lines
 // - Optionally removes duplicates if dedupe is true
 func (g *Generator) NormalizeItems(rawItems string, dedupe bool) []string {
+	mode := DedupeNone
+	if dedupe {
+		mode = DedupeExact
+	}
+	return g.NormalizeItemsWithMode(rawItems, mode)
+}
+
+// NormalizeItemsWithMode is NormalizeItems with control over how duplicates
+// are matched. Whichever mode merges a group, the first-seen spelling is the
+// one kept in the output.
+func (g *Generator) NormalizeItemsWithMode(rawItems string, mode DedupeMode) []string {
 	lines := strings.Split(rawItems, "\n")
 	items := make([]string, 0, len(lines))
 
@@ -39,11 +98,12 @@ func (g *Generator) NormalizeItems(rawItems string, dedupe bool) []string {
 		if trimmed == "" {
 			continue
 		}
-		if dedupe {
-			if seen[trimmed] {
+		if mode != DedupeNone {
+			key := dedupeKey(trimmed, mode)
+			if seen[key] {
 				continue
 			}
-			seen[trimmed] = true
+			seen[key] = true
 		}
 		items = append(items, trimmed)
 	}
@@ -51,6 +111,67 @@ func (g *Generator) NormalizeItems(rawItems string, dedupe bool) []string {
 	return items
 }
 
+// dedupeKey maps an already-trimmed item to its comparison key for the mode.
+func dedupeKey(item string, mode DedupeMode) string {
+	switch mode {
+	case DedupeCaseInsensitive:
+		return strings.ToLower(item)
+	case DedupeCollapseWhitespace:
+		return strings.Join(strings.Fields(strings.ToLower(item)), " ")
+	default:
+		return item
+	}
+}
+
+// DuplicateItem records one repeated item and how many times it appeared.
+type DuplicateItem struct {
+	Item  string
+	Count int
+}
+
+// ItemReport summarizes a raw item list: how many lines it held, how many
+// were blank, how many distinct items remain, and which items repeat.
+type ItemReport struct {
+	TotalLines  int
+	BlankLines  int
+	UniqueItems int
+	Duplicates  []DuplicateItem
+}
+
+// AnalyzeItems reports what NormalizeItems would do to the raw input without
+// discarding the detail: blank lines counted rather than silently dropped,
+// and repeated items listed with their occurrence counts so the UI can show
+// the user exactly what they pasted twice. Matching follows NormalizeItems:
+// whitespace is trimmed before comparing, and case is significant.
+func (g *Generator) AnalyzeItems(raw string) ItemReport {
+	lines := strings.Split(raw, "\n")
+	report := ItemReport{TotalLines: len(lines)}
+
+	counts := make(map[string]int)
+	var order []string
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			report.BlankLines++
+			continue
+		}
+		if counts[trimmed] == 0 {
+			order = append(order, trimmed)
+		}
+		counts[trimmed]++
+	}
+
+	report.UniqueItems = len(order)
+	for _, item := range order {
+		if counts[item] > 1 {
+			report.Duplicates = append(report.Duplicates, DuplicateItem{Item: item, Count: counts[item]})
+		}
+	}
+
+	return report
+}
+
 // Shuffle performs a Fisher-Yates shuffle on the items slice
 func (g *Generator) Shuffle(items []string) []string {
 	result := make([]string, len(items))
@@ -64,19 +185,58 @@ func (g *Generator) Shuffle(items []string) []string {
 	return result
 }
 
+// GridConfig controls how the free-space cell is drawn.
+type GridConfig struct {
+	FreeSpaceText string // text for the center cell; empty means FreeSpace
+	UseFreeSpace  bool   // when false, the center cell draws a normal item
+	// FreeSpaceCount is the number of free-space cells. Zero keeps the classic
+	// behavior (the center cell on odd-sized grids, none on even). Larger
+	// counts fill a fixed pattern: center first, then the four corners, then
+	// the edge midpoints.
+	FreeSpaceCount int
+}
+
+// DefaultGridConfig returns the classic bingo behavior: a center cell
+// labelled "Free Space".
+func DefaultGridConfig() GridConfig {
+	return GridConfig{
+		FreeSpaceText: FreeSpace,
+		UseFreeSpace:  true,
+	}
+}
+
+// HasCenterFreeSpace reports whether a grid of the given size gets a center
+// free-space cell. Even-sized grids have no true center cell, so they get no
+// free space and every cell draws a normal item.
+func HasCenterFreeSpace(size int) bool {
+	return size%2 == 1
+}
+
 // GenerateGrid creates a bingo grid of the specified size with shuffled items
-// Center cell is "Free Space", and empty cells are filled with "EMPTY"
+// Center cell is "Free Space" on odd-sized grids, and empty cells are filled
+// with "EMPTY"
 func (g *Generator) GenerateGrid(items []string, size int) [][]string {
+	return g.GenerateGridWithConfig(items, size, DefaultGridConfig())
+}
+
+// GenerateGridWithConfig creates a bingo grid like GenerateGrid but lets the
+// caller relabel the free-space cells, disable them entirely or ask for more
+// than one via cfg.
+func (g *Generator) GenerateGridWithConfig(items []string, size int, cfg GridConfig) [][]string {
 	// Ensure size is at least 3
 	if size < 3 {
 		size = 3
 	}
 
+	freeSpaceText := cfg.FreeSpaceText
+	if freeSpaceText == "" {
+		freeSpaceText = FreeSpace
+	}
+
 	// Shuffle the items
 	shuffled := g.Shuffle(items)
 
-	// Calculate the center index
-	center := size / 2 // Integer division, 5 -> 2 (0-indexed center)
+	free := freeSpacePositions(size, cfg)
 
 	// Create the grid
 	grid := make([][]string, size)
@@ -88,8 +248,8 @@ func (g *Generator) GenerateGrid(items []string, size int) [][]string {
 	itemIndex := 0
 	for row := 0; row < size; row++ {
 		for col := 0; col < size; col++ {
-			if row == center && col == center {
-				grid[row][col] = FreeSpace
+			if free[[2]int{row, col}] {
+				grid[row][col] = freeSpaceText
 			} else if itemIndex < len(shuffled) {
 				grid[row][col] = shuffled[itemIndex]
 				itemIndex++
@@ -102,6 +262,189 @@ func (g *Generator) GenerateGrid(items []string, size int) [][]string {
 	return grid
 }
 
+// freeSpacePositions returns the free-space cells of a size×size grid under
+// cfg. A zero FreeSpaceCount reproduces the classic layout: the center cell
+// on odd-sized grids, none on even ones. Explicit counts draw from a fixed
+// priority pattern — center (odd sizes only), the four corners, then the edge
+// midpoints — capped at the pattern length.
+func freeSpacePositions(size int, cfg GridConfig) map[[2]int]bool {
+	if !cfg.UseFreeSpace {
+		return nil
+	}
+
+	center := size / 2
+	if cfg.FreeSpaceCount == 0 {
+		if !HasCenterFreeSpace(size) {
+			return nil
+		}
+		return map[[2]int]bool{{center, center}: true}
+	}
+
+	var candidates [][2]int
+	if HasCenterFreeSpace(size) {
+		candidates = append(candidates, [2]int{center, center})
+	}
+	candidates = append(candidates,
+		[2]int{0, 0}, [2]int{0, size - 1}, [2]int{size - 1, 0}, [2]int{size - 1, size - 1},
+		[2]int{0, center}, [2]int{size - 1, center}, [2]int{center, 0}, [2]int{center, size - 1},
+	)
+
+	count := cfg.FreeSpaceCount
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+	positions := make(map[[2]int]bool, count)
+	for _, p := range candidates[:count] {
+		positions[p] = true
+	}
+	return positions
+}
+
+// Position names a grid cell by row and column, both zero-based.
+type Position struct {
+	Row int
+	Col int
+}
+
+// GenerateGridWithLocks creates a bingo grid like GenerateGrid, but cells named
+// in locks always hold their given text while the remaining cells shuffle-fill
+// from items. Each locked value removes one matching entry from the item pool
+// so it doesn't also appear in a random cell. It errors when a lock is out of
+// bounds, collides with the free space, or when the pool can't fill the rest
+// of the grid.
+func (g *Generator) GenerateGridWithLocks(items []string, size int, locks map[Position]string) ([][]string, error) {
+	// Ensure size is at least 3, matching GenerateGrid
+	if size < 3 {
+		size = 3
+	}
+
+	free := freeSpacePositions(size, DefaultGridConfig())
+	for pos := range locks {
+		if pos.Row < 0 || pos.Row >= size || pos.Col < 0 || pos.Col >= size {
+			return nil, fmt.Errorf("lock at (%d,%d) is outside the %dx%d grid", pos.Row, pos.Col, size, size)
+		}
+		if free[[2]int{pos.Row, pos.Col}] {
+			return nil, fmt.Errorf("lock at (%d,%d) collides with the free space", pos.Row, pos.Col)
+		}
+	}
+
+	// Remove one pool entry per locked value so locked items don't repeat.
+	pool := make([]string, 0, len(items))
+	pending := make(map[string]int)
+	for _, text := range locks {
+		pending[text]++
+	}
+	for _, item := range items {
+		if pending[item] > 0 {
+			pending[item]--
+			continue
+		}
+		pool = append(pool, item)
+	}
+
+	required := size*size - len(free) - len(locks)
+	if len(pool) < required {
+		return nil, fmt.Errorf("not enough items for a %dx%d grid with %d locked cells: need %d, have %d", size, size, len(locks), required, len(pool))
+	}
+
+	shuffled := g.Shuffle(pool)
+
+	grid := make([][]string, size)
+	for i := range grid {
+		grid[i] = make([]string, size)
+	}
+
+	itemIndex := 0
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			if free[[2]int{row, col}] {
+				grid[row][col] = FreeSpace
+				continue
+			}
+			if text, ok := locks[Position{Row: row, Col: col}]; ok {
+				grid[row][col] = text
+				continue
+			}
+			grid[row][col] = shuffled[itemIndex]
+			itemIndex++
+		}
+	}
+
+	return grid, nil
+}
+
+// HasRectCenterFreeSpace reports whether a rows×cols grid gets a center
+// free-space cell. Only a grid with both dimensions odd has a true center
+// cell; every other shape gets no free space.
+func HasRectCenterFreeSpace(rows, cols int) bool {
+	return rows%2 == 1 && cols%2 == 1
+}
+
+// GenerateRectGrid creates a rows×cols bingo grid with shuffled items, for
+// non-square landscape or portrait cards. The center cell is a free space only
+// when both dimensions are odd, and like GenerateGridChecked it errors instead
+// of padding when there aren't enough items. Dimensions below 3 are raised to
+// 3, matching GenerateGrid.
+func (g *Generator) GenerateRectGrid(items []string, rows, cols int) ([][]string, error) {
+	if rows < 3 {
+		rows = 3
+	}
+	if cols < 3 {
+		cols = 3
+	}
+
+	required := rows * cols
+	if HasRectCenterFreeSpace(rows, cols) {
+		required-- // one cell is the free space
+	}
+	if len(items) < required {
+		return nil, fmt.Errorf("not enough items for a %dx%d grid: need %d, have %d", rows, cols, required, len(items))
+	}
+
+	shuffled := g.Shuffle(items)
+	centerRow, centerCol := rows/2, cols/2
+
+	grid := make([][]string, rows)
+	itemIndex := 0
+	for row := range grid {
+		grid[row] = make([]string, cols)
+		for col := 0; col < cols; col++ {
+			if HasRectCenterFreeSpace(rows, cols) && row == centerRow && col == centerCol {
+				grid[row][col] = FreeSpace
+				continue
+			}
+			grid[row][col] = shuffled[itemIndex]
+			itemIndex++
+		}
+	}
+	return grid, nil
+}
+
+// GenerateGridChecked is a validating variant of GenerateGrid. It returns an
+// error instead of padding cells with EmptyCell when there aren't enough items
+// to fill the grid (the center free space means size*size-1 items are needed),
+// so the UI can warn the user before rendering a broken-looking card.
+func (g *Generator) GenerateGridChecked(items []string, size int) ([][]string, error) {
+	return g.GenerateGridCheckedWithConfig(items, size, DefaultGridConfig())
+}
+
+// GenerateGridCheckedWithConfig is GenerateGridChecked with a GridConfig, so
+// the item-count validation accounts for however many free-space cells the
+// config yields.
+func (g *Generator) GenerateGridCheckedWithConfig(items []string, size int, cfg GridConfig) ([][]string, error) {
+	// Ensure size is at least 3, matching GenerateGrid
+	if size < 3 {
+		size = 3
+	}
+
+	required := size*size - len(freeSpacePositions(size, cfg))
+	if len(items) < required {
+		return nil, fmt.Errorf("not enough items for a %dx%d grid: need %d, have %d", size, size, required, len(items))
+	}
+
+	return g.GenerateGridWithConfig(items, size, cfg), nil
+}
+
 // SanitizeFilename removes characters that are not safe for filenames
 func SanitizeFilename(name string) string {
 	// Replace spaces and special characters with underscores
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+)
+
+// makeItems returns n distinct placeholder items.
+func makeItems(n int) []string {
+	items := make([]string, n)
+	for i := range items {
+		items[i] = fmt.Sprintf("item %d", i+1)
+	}
+	return items
+}
+
+func TestGenerateGridWithConfig_CustomFreeSpaceText(t *testing.T) {
+	g := NewGenerator()
+	size := 5
+	cfg := GridConfig{FreeSpaceText: "ACME Co.", UseFreeSpace: true}
+
+	grid := g.GenerateGridWithConfig(makeItems(size*size), size, cfg)
+
+	center := size / 2
+	if got := grid[center][center]; got != "ACME Co." {
+		t.Errorf("center cell: got %q, want %q", got, "ACME Co.")
+	}
+}
+
+func TestGenerateGridWithConfig_NoFreeSpace(t *testing.T) {
+	g := NewGenerator()
+	for _, size := range []int{4, 5} { // even and odd grid sizes
+		cfg := GridConfig{UseFreeSpace: false}
+		grid := g.GenerateGridWithConfig(makeItems(size*size), size, cfg)
+
+		for row := range grid {
+			for col, cell := range grid[row] {
+				if cell == FreeSpace {
+					t.Errorf("size %d: cell (%d,%d) is a free space with UseFreeSpace disabled", size, row, col)
+				}
+				if cell == EmptyCell {
+					t.Errorf("size %d: cell (%d,%d) is %q with enough items", size, row, col, EmptyCell)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateGrid_EvenSizesHaveNoFreeSpace(t *testing.T) {
+	g := NewGenerator()
+	for _, size := range []int{4, 6} {
+		grid := g.GenerateGrid(makeItems(size*size), size)
+
+		for row := range grid {
+			for col, cell := range grid[row] {
+				if cell == FreeSpace {
+					t.Errorf("size %d: cell (%d,%d) is a free space, but even grids have no center", size, row, col)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateGrid_DefaultFreeSpace(t *testing.T) {
+	g := NewGenerator()
+	size := 5
+	grid := g.GenerateGrid(makeItems(size*size), size)
+
+	center := size / 2
+	if got := grid[center][center]; got != FreeSpace {
+		t.Errorf("center cell: got %q, want %q", got, FreeSpace)
+	}
+}
+
+func TestGenerateGridChecked_ExactlyEnough(t *testing.T) {
+	g := NewGenerator()
+	size := 5
+	items := makeItems(size*size - 1) // free space covers the last cell
+
+	grid, err := g.GenerateGridChecked(items, size)
+	if err != nil {
+		t.Fatalf("GenerateGridChecked: %v", err)
+	}
+
+	for row := range grid {
+		for col, cell := range grid[row] {
+			if cell == EmptyCell {
+				t.Errorf("cell (%d,%d) is %q with exactly enough items", row, col, EmptyCell)
+			}
+		}
+	}
+}
+
+func TestGenerateGridChecked_OneShort(t *testing.T) {
+	g := NewGenerator()
+	size := 5
+	items := makeItems(size*size - 2) // one fewer than required
+
+	_, err := g.GenerateGridChecked(items, size)
+	if err == nil {
+		t.Fatal("expected error when one item short, got nil")
+	}
+}
+
+func TestGenerateGridChecked_PlentyOfItems(t *testing.T) {
+	g := NewGenerator()
+	size := 5
+	items := makeItems(size * size * 2)
+
+	grid, err := g.GenerateGridChecked(items, size)
+	if err != nil {
+		t.Fatalf("GenerateGridChecked: %v", err)
+	}
+
+	if len(grid) != size {
+		t.Fatalf("got %d rows, want %d", len(grid), size)
+	}
+	for row := range grid {
+		if len(grid[row]) != size {
+			t.Errorf("row %d has %d cells, want %d", row, len(grid[row]), size)
+		}
+	}
+}
+
+func TestAnalyzeItems_WhitespaceVariantsAreDuplicates(t *testing.T) {
+	g := NewGenerator()
+
+	report := g.AnalyzeItems("apple\n  apple  \n\napple\nbanana\n")
+
+	if report.TotalLines != 6 {
+		t.Errorf("TotalLines = %d, want 6", report.TotalLines)
+	}
+	if report.BlankLines != 2 {
+		t.Errorf("BlankLines = %d, want 2 (empty line plus trailing newline)", report.BlankLines)
+	}
+	if report.UniqueItems != 2 {
+		t.Errorf("UniqueItems = %d, want 2", report.UniqueItems)
+	}
+	if len(report.Duplicates) != 1 {
+		t.Fatalf("Duplicates = %v, want one entry", report.Duplicates)
+	}
+	if dup := report.Duplicates[0]; dup.Item != "apple" || dup.Count != 3 {
+		t.Errorf("Duplicates[0] = %+v, want {apple 3}", dup)
+	}
+}
+
+func TestAnalyzeItems_CaseIsSignificant(t *testing.T) {
+	g := NewGenerator()
+
+	// NormalizeItems dedupes case-sensitively, so the report must agree:
+	// "Apple" and "apple" are distinct items, not duplicates.
+	report := g.AnalyzeItems("Apple\napple")
+
+	if report.UniqueItems != 2 {
+		t.Errorf("UniqueItems = %d, want 2", report.UniqueItems)
+	}
+	if len(report.Duplicates) != 0 {
+		t.Errorf("Duplicates = %v, want none for case-differing items", report.Duplicates)
+	}
+}
+
+func TestAnalyzeItems_NoDuplicates(t *testing.T) {
+	g := NewGenerator()
+
+	report := g.AnalyzeItems("a\nb\nc")
+
+	if report.TotalLines != 3 || report.BlankLines != 0 || report.UniqueItems != 3 {
+		t.Errorf("report = %+v, want 3 lines, 0 blank, 3 unique", report)
+	}
+	if len(report.Duplicates) != 0 {
+		t.Errorf("Duplicates = %v, want none", report.Duplicates)
+	}
+}
+
+func TestNormalizeItemsWithMode_Exact(t *testing.T) {
+	g := NewGenerator()
+
+	items := g.NormalizeItemsWithMode("apple\n apple \nApple\nice  cream", DedupeExact)
+
+	want := []string{"apple", "Apple", "ice  cream"}
+	if len(items) != len(want) {
+		t.Fatalf("items = %v, want %v", items, want)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("items[%d] = %q, want %q", i, items[i], want[i])
+		}
+	}
+}
+
+func TestNormalizeItemsWithMode_CaseInsensitive(t *testing.T) {
+	g := NewGenerator()
+
+	items := g.NormalizeItemsWithMode("New York\nnew york\nNEW YORK\nBoston", DedupeCaseInsensitive)
+
+	want := []string{"New York", "Boston"}
+	if len(items) != len(want) {
+		t.Fatalf("items = %v, want %v", items, want)
+	}
+	// First-seen casing wins.
+	if items[0] != "New York" {
+		t.Errorf("items[0] = %q, want the first-seen %q", items[0], "New York")
+	}
+}
+
+func TestNormalizeItemsWithMode_CollapseWhitespace(t *testing.T) {
+	g := NewGenerator()
+
+	items := g.NormalizeItemsWithMode("ice  cream\nIce cream\nice\tcream\nsorbet", DedupeCollapseWhitespace)
+
+	want := []string{"ice  cream", "sorbet"}
+	if len(items) != len(want) {
+		t.Fatalf("items = %v, want %v", items, want)
+	}
+	if items[0] != "ice  cream" {
+		t.Errorf("items[0] = %q, want the first-seen %q", items[0], "ice  cream")
+	}
+}
+
+func TestNormalizeItemsWithMode_NoneKeepsDuplicates(t *testing.T) {
+	g := NewGenerator()
+
+	items := g.NormalizeItemsWithMode("a\na\n\na", DedupeNone)
+
+	if len(items) != 3 {
+		t.Errorf("items = %v, want three entries with dedupe off", items)
+	}
+}
+
+func TestGenerateRectGrid_5x3HasCenterFreeSpace(t *testing.T) {
+	g := NewGenerator()
+	rows, cols := 5, 3
+
+	grid, err := g.GenerateRectGrid(makeItems(rows*cols-1), rows, cols)
+	if err != nil {
+		t.Fatalf("GenerateRectGrid: %v", err)
+	}
+
+	if len(grid) != rows {
+		t.Fatalf("got %d rows, want %d", len(grid), rows)
+	}
+	for row := range grid {
+		if len(grid[row]) != cols {
+			t.Fatalf("row %d has %d cells, want %d", row, len(grid[row]), cols)
+		}
+	}
+	if grid[rows/2][cols/2] != FreeSpace {
+		t.Errorf("center cell = %q, want %q on an odd-by-odd grid", grid[rows/2][cols/2], FreeSpace)
+	}
+}
+
+func TestGenerateRectGrid_4x6HasNoFreeSpace(t *testing.T) {
+	g := NewGenerator()
+	rows, cols := 4, 6
+
+	grid, err := g.GenerateRectGrid(makeItems(rows*cols), rows, cols)
+	if err != nil {
+		t.Fatalf("GenerateRectGrid: %v", err)
+	}
+
+	for row := range grid {
+		for col, cell := range grid[row] {
+			if cell == FreeSpace {
+				t.Errorf("cell (%d,%d) is a free space on an even-dimension grid", row, col)
+			}
+			if cell == EmptyCell {
+				t.Errorf("cell (%d,%d) is %q with exactly enough items", row, col, EmptyCell)
+			}
+		}
+	}
+}
+
+func TestGenerateRectGrid_NotEnoughItems(t *testing.T) {
+	g := NewGenerator()
+
+	if _, err := g.GenerateRectGrid(makeItems(10), 4, 6); err == nil {
+		t.Error("expected error with too few items for a 4x6 grid")
+	}
+}
+
+func TestGenerateGridWithConfig_MultipleFreeSpaces(t *testing.T) {
+	g := NewGenerator()
+	size := 9
+	cfg := GridConfig{UseFreeSpace: true, FreeSpaceCount: 5}
+
+	grid := g.GenerateGridWithConfig(makeItems(size*size-5), size, cfg)
+
+	freeCells := 0
+	for row := range grid {
+		for col, cell := range grid[row] {
+			switch cell {
+			case FreeSpace:
+				freeCells++
+			case EmptyCell:
+				t.Errorf("cell (%d,%d) is %q with exactly enough items", row, col, EmptyCell)
+			}
+		}
+	}
+	if freeCells != 5 {
+		t.Errorf("got %d free cells, want 5", freeCells)
+	}
+
+	// Pattern priority: the center, then the four corners.
+	center := size / 2
+	for _, pos := range [][2]int{{center, center}, {0, 0}, {0, size - 1}, {size - 1, 0}, {size - 1, size - 1}} {
+		if grid[pos[0]][pos[1]] != FreeSpace {
+			t.Errorf("cell (%d,%d) = %q, want %q", pos[0], pos[1], grid[pos[0]][pos[1]], FreeSpace)
+		}
+	}
+}
+
+func TestGenerateGridWithConfig_FourCornersOnEvenGrid(t *testing.T) {
+	g := NewGenerator()
+	size := 10
+	cfg := GridConfig{UseFreeSpace: true, FreeSpaceCount: 4}
+
+	grid := g.GenerateGridWithConfig(makeItems(size*size-4), size, cfg)
+
+	// Even grids have no center cell, so the four corners absorb the count.
+	for _, pos := range [][2]int{{0, 0}, {0, size - 1}, {size - 1, 0}, {size - 1, size - 1}} {
+		if grid[pos[0]][pos[1]] != FreeSpace {
+			t.Errorf("corner (%d,%d) = %q, want %q", pos[0], pos[1], grid[pos[0]][pos[1]], FreeSpace)
+		}
+	}
+}
+
+func TestGenerateGridCheckedWithConfig_CountsFreeCells(t *testing.T) {
+	g := NewGenerator()
+	size := 9
+	cfg := GridConfig{UseFreeSpace: true, FreeSpaceCount: 5}
+
+	if _, err := g.GenerateGridCheckedWithConfig(makeItems(size*size-5), size, cfg); err != nil {
+		t.Errorf("exactly enough items should pass: %v", err)
+	}
+	if _, err := g.GenerateGridCheckedWithConfig(makeItems(size*size-6), size, cfg); err == nil {
+		t.Error("one item short should error")
+	}
+}
+
+func TestGenerateGridWithLocks_PlacesLockedItems(t *testing.T) {
+	g := NewGenerator()
+	items := makeItems(25)
+	locks := map[Position]string{
+		{Row: 0, Col: 0}: "Mascot",
+		{Row: 4, Col: 4}: "Finale",
+	}
+
+	grid, err := g.GenerateGridWithLocks(items, 5, locks)
+	if err != nil {
+		t.Fatalf("GenerateGridWithLocks: %v", err)
+	}
+
+	if grid[0][0] != "Mascot" {
+		t.Errorf("cell (0,0): got %q, want %q", grid[0][0], "Mascot")
+	}
+	if grid[4][4] != "Finale" {
+		t.Errorf("cell (4,4): got %q, want %q", grid[4][4], "Finale")
+	}
+	if grid[2][2] != FreeSpace {
+		t.Errorf("center cell: got %q, want %q", grid[2][2], FreeSpace)
+	}
+}
+
+func TestGenerateGridWithLocks_LockedItemNotDuplicated(t *testing.T) {
+	g := NewGenerator()
+	items := append(makeItems(24), "Mascot")
+	locks := map[Position]string{{Row: 1, Col: 3}: "Mascot"}
+
+	grid, err := g.GenerateGridWithLocks(items, 5, locks)
+	if err != nil {
+		t.Fatalf("GenerateGridWithLocks: %v", err)
+	}
+
+	count := 0
+	for _, row := range grid {
+		for _, cell := range row {
+			if cell == "Mascot" {
+				count++
+			}
+		}
+	}
+	if count != 1 {
+		t.Errorf("locked item appears %d times, want 1", count)
+	}
+}
+
+func TestGenerateGridWithLocks_FreeSpaceCollision(t *testing.T) {
+	g := NewGenerator()
+	locks := map[Position]string{{Row: 2, Col: 2}: "Mascot"}
+
+	if _, err := g.GenerateGridWithLocks(makeItems(25), 5, locks); err == nil {
+		t.Error("expected error for lock on the free space")
+	}
+}
+
+func TestGenerateGridWithLocks_OutOfBounds(t *testing.T) {
+	g := NewGenerator()
+	locks := map[Position]string{{Row: 5, Col: 0}: "Mascot"}
+
+	if _, err := g.GenerateGridWithLocks(makeItems(25), 5, locks); err == nil {
+		t.Error("expected error for out-of-bounds lock")
+	}
+}
+
+func TestGenerateGridWithLocks_NotEnoughItems(t *testing.T) {
+	g := NewGenerator()
+	locks := map[Position]string{{Row: 0, Col: 0}: "Mascot"}
+
+	// 5x5 with free space and one lock needs 23 pool items.
+	if _, err := g.GenerateGridWithLocks(makeItems(22), 5, locks); err == nil {
+		t.Error("expected error for insufficient items")
+	}
+}
+
+func TestNewSecureGenerator_ShuffleIsValidPermutation(t *testing.T) {
+	g := NewSecureGenerator()
+	items := makeItems(25)
+
+	for run := 0; run < 50; run++ {
+		shuffled := g.Shuffle(items)
+		if len(shuffled) != len(items) {
+			t.Fatalf("run %d: got %d items, want %d", run, len(shuffled), len(items))
+		}
+
+		seen := make(map[string]bool, len(shuffled))
+		for _, item := range shuffled {
+			if seen[item] {
+				t.Fatalf("run %d: duplicate item %q in shuffle", run, item)
+			}
+			seen[item] = true
+		}
+		for _, item := range items {
+			if !seen[item] {
+				t.Fatalf("run %d: item %q lost in shuffle", run, item)
+			}
+		}
+	}
+}
+
+func TestNewSecureGenerator_GeneratesGrids(t *testing.T) {
+	g := NewSecureGenerator()
+	grid := g.GenerateGrid(makeItems(25), 5)
+
+	if len(grid) != 5 {
+		t.Fatalf("got %d rows, want 5", len(grid))
+	}
+	if grid[2][2] != FreeSpace {
+		t.Errorf("center cell: got %q, want %q", grid[2][2], FreeSpace)
+	}
+}
+
+func TestCryptoSource_IntnRange(t *testing.T) {
+	var src cryptoSource
+	for i := 0; i < 200; i++ {
+		if got := src.Intn(7); got < 0 || got >= 7 {
+			t.Fatalf("Intn(7) = %d, out of range", got)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// GridExport is the JSON shape of a generated bingo card, used for sharing
+// and for saving/restoring a specific card.
+type GridExport struct {
+	TripName string     `json:"tripName"`
+	Size     int        `json:"size"`
+	Cells    [][]string `json:"cells"`
+}
+
+// GridToJSON serializes a generated grid with its trip name to JSON.
+func GridToJSON(grid [][]string, tripName string) ([]byte, error) {
+	if len(grid) == 0 {
+		return nil, fmt.Errorf("grid is empty")
+	}
+	for i, row := range grid {
+		if len(row) != len(grid) {
+			return nil, fmt.Errorf("grid is not square: row %d has %d cells, want %d", i, len(row), len(grid))
+		}
+	}
+
+	export := GridExport{
+		TripName: tripName,
+		Size:     len(grid),
+		Cells:    grid,
+	}
+	return json.Marshal(export)
+}
+
+// GridFromJSON reconstructs a grid and its trip name from JSON produced by
+// GridToJSON, validating that the declared size matches the cells.
+func GridFromJSON(data []byte) ([][]string, string, error) {
+	var export GridExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, "", fmt.Errorf("failed to parse grid json: %w", err)
+	}
+
+	if export.Size != len(export.Cells) {
+		return nil, "", fmt.Errorf("grid size mismatch: declared %d, have %d rows", export.Size, len(export.Cells))
+	}
+	for i, row := range export.Cells {
+		if len(row) != export.Size {
+			return nil, "", fmt.Errorf("grid size mismatch: row %d has %d cells, want %d", i, len(row), export.Size)
+		}
+	}
+
+	return export.Cells, export.TripName, nil
+}
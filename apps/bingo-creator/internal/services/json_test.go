@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestGridJSON_RoundTrip(t *testing.T) {
+	g := NewGenerator()
+	size := 5
+	grid := g.GenerateGrid(makeItems(size*size-1), size)
+
+	data, err := GridToJSON(grid, "Austin_NOLA_2024")
+	if err != nil {
+		t.Fatalf("GridToJSON: %v", err)
+	}
+
+	restored, tripName, err := GridFromJSON(data)
+	if err != nil {
+		t.Fatalf("GridFromJSON: %v", err)
+	}
+
+	if tripName != "Austin_NOLA_2024" {
+		t.Errorf("trip name: got %q, want %q", tripName, "Austin_NOLA_2024")
+	}
+	if len(restored) != size {
+		t.Fatalf("got %d rows, want %d", len(restored), size)
+	}
+	for row := range grid {
+		for col := range grid[row] {
+			if restored[row][col] != grid[row][col] {
+				t.Errorf("cell (%d,%d): got %q, want %q", row, col, restored[row][col], grid[row][col])
+			}
+		}
+	}
+
+	// The free space must survive the round trip.
+	center := size / 2
+	if restored[center][center] != FreeSpace {
+		t.Errorf("center cell: got %q, want %q", restored[center][center], FreeSpace)
+	}
+}
+
+func TestGridToJSON_Invalid(t *testing.T) {
+	if _, err := GridToJSON(nil, "trip"); err == nil {
+		t.Error("expected error for empty grid")
+	}
+	if _, err := GridToJSON([][]string{{"a", "b"}, {"c"}}, "trip"); err == nil {
+		t.Error("expected error for non-square grid")
+	}
+}
+
+func TestGridFromJSON_SizeMismatch(t *testing.T) {
+	if _, _, err := GridFromJSON([]byte(`{"tripName":"t","size":3,"cells":[["a"]]}`)); err == nil {
+		t.Error("expected error for size mismatch")
+	}
+	if _, _, err := GridFromJSON([]byte(`not json`)); err == nil {
+		t.Error("expected error for invalid json")
+	}
+}
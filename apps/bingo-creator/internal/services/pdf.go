@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// GridToPDF draws the bingo grid as a vector PDF on US Letter paper and
+// returns the raw file bytes. Generating the PDF in Go keeps the text
+// selectable and removes the jsPDF/html2canvas CDN dependencies the old JS
+// exporter needed. Rectangular grids (GenerateRectGrid) are supported; cells
+// stay square and the grid is sized to fit whichever dimension is tighter.
+func GridToPDF(grid [][]string, title string) ([]byte, error) {
+	rows := len(grid)
+	if rows == 0 || len(grid[0]) == 0 {
+		return nil, fmt.Errorf("grid is empty")
+	}
+	cols := len(grid[0])
+	for i, row := range grid {
+		if len(row) != cols {
+			return nil, fmt.Errorf("grid is ragged: row %d has %d cells, want %d", i, len(row), cols)
+		}
+	}
+
+	if title == "" {
+		title = "Bingo Card"
+	}
+
+	pdf := fpdf.New("P", "in", "Letter", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+
+	// Layout mirrors the old JS exporter: 8.5x11in page, 0.5in margins,
+	// title band above a centered square grid.
+	const (
+		pageWidth   = 8.5
+		pageHeight  = 11.0
+		margin      = 0.5
+		titleHeight = 0.4
+	)
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Text(pageWidth/2-pdf.GetStringWidth(title)/2, margin, title)
+
+	gridWidth := pageWidth - margin*2
+	gridStartY := margin + titleHeight
+	maxGridHeight := pageHeight - gridStartY - margin
+
+	cellSize := gridWidth / float64(cols)
+	if byHeight := maxGridHeight / float64(rows); byHeight < cellSize {
+		cellSize = byHeight
+	}
+	gridStartX := (pageWidth - cellSize*float64(cols)) / 2
+
+	pdf.SetLineWidth(0.02)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetFont("Helvetica", "", 12)
+
+	centerRow, centerCol := rows/2, cols/2
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			x := gridStartX + float64(col)*cellSize
+			y := gridStartY + float64(row)*cellSize
+
+			if HasRectCenterFreeSpace(rows, cols) && row == centerRow && col == centerCol {
+				pdf.SetFillColor(240, 240, 240)
+			} else {
+				pdf.SetFillColor(255, 255, 255)
+			}
+			pdf.Rect(x, y, cellSize, cellSize, "FD")
+
+			drawCellText(pdf, grid[row][col], x, y, cellSize)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("render pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawCellText wraps and centers a cell's text within its square, shrinking
+// the font for longer items the same way the old JS exporter did.
+func drawCellText(pdf *fpdf.Fpdf, text string, x, y, cellSize float64) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return
+	}
+
+	fontSize := cellSize * 0.3 * 72 // pt
+	if fontSize > 12 {
+		fontSize = 12
+	}
+	switch {
+	case len(text) > 20:
+		fontSize *= 0.7
+	case len(text) > 15:
+		fontSize *= 0.8
+	}
+	if fontSize < 6 {
+		fontSize = 6
+	}
+	pdf.SetFont("Helvetica", "", fontSize)
+
+	maxWidth := cellSize * 0.9
+	lines := pdf.SplitText(text, maxWidth)
+	lineHeight := fontSize / 72 * 1.2 // in
+	totalHeight := float64(len(lines)) * lineHeight
+	textY := y + cellSize/2 - totalHeight/2 + lineHeight*0.8
+	for _, line := range lines {
+		pdf.Text(x+cellSize/2-pdf.GetStringWidth(line)/2, textY, line)
+		textY += lineHeight
+	}
+}
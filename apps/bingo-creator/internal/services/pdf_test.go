@@ -0,0 +1,76 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGridToPDF_Header(t *testing.T) {
+	grid, err := NewGenerator().GenerateGridChecked(makeItems(9), 3)
+	if err != nil {
+		t.Fatalf("GenerateGridChecked: %v", err)
+	}
+
+	data, err := GridToPDF(grid, "Test Card")
+	if err != nil {
+		t.Fatalf("GridToPDF: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		t.Errorf("output does not start with %%PDF: %q", data[:min(8, len(data))])
+	}
+}
+
+func TestGridToPDF_ScalesWithGridSize(t *testing.T) {
+	small, err := NewGenerator().GenerateGridChecked(makeItems(9), 3)
+	if err != nil {
+		t.Fatalf("NewGenerator().GenerateGridChecked(3): %v", err)
+	}
+	large, err := NewGenerator().GenerateGridChecked(makeItems(64), 8)
+	if err != nil {
+		t.Fatalf("NewGenerator().GenerateGridChecked(8): %v", err)
+	}
+
+	smallPDF, err := GridToPDF(small, "Small")
+	if err != nil {
+		t.Fatalf("GridToPDF(3x3): %v", err)
+	}
+	largePDF, err := GridToPDF(large, "Large")
+	if err != nil {
+		t.Fatalf("GridToPDF(8x8): %v", err)
+	}
+
+	if len(largePDF) <= len(smallPDF) {
+		t.Errorf("8x8 PDF (%d bytes) not larger than 3x3 PDF (%d bytes)", len(largePDF), len(smallPDF))
+	}
+}
+
+func TestGridToPDF_Invalid(t *testing.T) {
+	if _, err := GridToPDF(nil, "Empty"); err == nil {
+		t.Error("expected error for empty grid")
+	}
+	if _, err := GridToPDF([][]string{{"a", "b"}, {"c"}}, "Ragged"); err == nil {
+		t.Error("expected error for non-square grid")
+	}
+}
+
+func TestGridToPDF_RectangularGrid(t *testing.T) {
+	grid, err := NewGenerator().GenerateRectGrid(makeItems(15), 5, 3)
+	if err != nil {
+		t.Fatalf("GenerateRectGrid: %v", err)
+	}
+
+	data, err := GridToPDF(grid, "Wide Card")
+	if err != nil {
+		t.Fatalf("GridToPDF: %v", err)
+	}
+	if len(data) == 0 || string(data[:5]) != "%PDF-" {
+		t.Errorf("output does not look like a PDF (%d bytes)", len(data))
+	}
+}
+
+func TestGridToPDF_RaggedGrid(t *testing.T) {
+	grid := [][]string{{"a", "b"}, {"c"}}
+	if _, err := GridToPDF(grid, ""); err == nil {
+		t.Error("expected error for a ragged grid")
+	}
+}
@@ -1,24 +1,137 @@
 package services
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
 )
 
+// KVStore is a minimal key-value store abstraction over browser
+// localStorage, so Storage logic can run under go test outside WASM.
+type KVStore interface {
+	Get(key string) string
+	Set(key, value string)
+	Remove(key string)
+	Keys() []string
+}
+
+// browserStore is the real localStorage-backed implementation.
+type browserStore struct{}
+
+func (browserStore) Get(key string) string {
+	return app.Window().Get("localStorage").Call("getItem", key).String()
+}
+
+func (browserStore) Set(key, value string) {
+	app.Window().Get("localStorage").Call("setItem", key, value)
+}
+
+func (browserStore) Remove(key string) {
+	app.Window().Get("localStorage").Call("removeItem", key)
+}
+
+func (browserStore) Keys() []string {
+	ls := app.Window().Get("localStorage")
+	n := ls.Get("length").Int()
+	keys := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		keys = append(keys, ls.Call("key", i).String())
+	}
+	return keys
+}
+
+// MemoryStore is an in-memory KVStore for tests and non-browser use.
+type MemoryStore struct {
+	data map[string]string
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string]string)}
+}
+
+// Get implements KVStore.
+func (m *MemoryStore) Get(key string) string {
+	return m.data[key]
+}
+
+// Set implements KVStore.
+func (m *MemoryStore) Set(key, value string) {
+	m.data[key] = value
+}
+
+// Remove implements KVStore.
+func (m *MemoryStore) Remove(key string) {
+	delete(m.data, key)
+}
+
+// Keys implements KVStore.
+func (m *MemoryStore) Keys() []string {
+	keys := make([]string, 0, len(m.data))
+	for k := range m.data {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// defaultStoragePrefix namespaces every key a Storage writes; deployments
+// sharing an origin can pick their own via NewStorageWithPrefix.
+const defaultStoragePrefix = "bingo-creator"
+
 // Storage handles persisting state to localStorage
 type Storage struct {
 	prefix string
+	store  KVStore
 }
 
-// NewStorage creates a new Storage instance
+// NewStorage creates a new Storage instance backed by browser localStorage
 func NewStorage() *Storage {
+	return NewStorageWithStore(browserStore{})
+}
+
+// NewStorageWithPrefix creates a browser-backed Storage whose keys live under
+// the given prefix instead of the default, so two deployments sharing an
+// origin don't collide in localStorage. The prefix is reduced to key-safe
+// characters; an empty or fully unsafe prefix falls back to the default.
+func NewStorageWithPrefix(prefix string) *Storage {
+	return NewStorageWithPrefixAndStore(prefix, browserStore{})
+}
+
+// NewStorageWithStore creates a Storage backed by the given KVStore
+func NewStorageWithStore(store KVStore) *Storage {
+	return NewStorageWithPrefixAndStore(defaultStoragePrefix, store)
+}
+
+// NewStorageWithPrefixAndStore creates a Storage with both the prefix and the
+// KVStore chosen by the caller.
+func NewStorageWithPrefixAndStore(prefix string, store KVStore) *Storage {
 	return &Storage{
-		prefix: "bingo-creator",
+		prefix: sanitizePrefix(prefix),
+		store:  store,
 	}
 }
 
+// sanitizePrefix reduces a prefix to characters safe in a localStorage key:
+// letters, digits, hyphens and underscores. Anything else is dropped, and a
+// prefix with nothing left falls back to the default.
+func sanitizePrefix(prefix string) string {
+	var builder strings.Builder
+	for _, r := range prefix {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			builder.WriteRune(r)
+		}
+	}
+	if builder.Len() == 0 {
+		return defaultStoragePrefix
+	}
+	return builder.String()
+}
+
 // StorageKey returns the full key for localStorage
 func (s *Storage) StorageKey(tripName string) string {
 	sanitized := SanitizeFilename(tripName)
@@ -31,14 +144,24 @@ func (s *Storage) StorageKeyItems(tripName string) string {
 	return fmt.Sprintf("%s_items_%s", s.prefix, sanitized)
 }
 
-// GetCount retrieves the export count for a trip name from localStorage
+// StorageKeyExports returns the full key for the export history
+func (s *Storage) StorageKeyExports(tripName string) string {
+	sanitized := SanitizeFilename(tripName)
+	return fmt.Sprintf("%s_exports_%s", s.prefix, sanitized)
+}
+
+// GetCount retrieves the export count for a trip name: the length of the
+// export history when one exists, falling back to the legacy integer counter
+// for trips last exported before histories were recorded.
 func (s *Storage) GetCount(tripName string) int {
-	key := s.StorageKey(tripName)
-	value := app.Window().Get("localStorage").Call("getItem", key).String()
+	if history := s.GetExportHistory(tripName); history != nil {
+		return len(history)
+	}
+
+	value := s.store.Get(s.StorageKey(tripName))
 	if value == "" {
 		return 0
 	}
-
 	count, err := strconv.Atoi(value)
 	if err != nil {
 		return 0
@@ -46,25 +169,124 @@ func (s *Storage) GetCount(tripName string) int {
 	return count
 }
 
-// IncrementCount increments the export count for a trip name
+// IncrementCount increments the export count for a trip name by recording an
+// export at the current time.
 func (s *Storage) IncrementCount(tripName string) int {
-	count := s.GetCount(tripName) + 1
-	key := s.StorageKey(tripName)
-	app.Window().Get("localStorage").Call("setItem", key, count)
-	return count
+	s.RecordExport(tripName)
+	return s.GetCount(tripName)
+}
+
+// RecordExport appends the current time to the trip's export history, so
+// organizers can see when each card was exported rather than just how often.
+func (s *Storage) RecordExport(tripName string) {
+	history := s.GetExportHistory(tripName)
+	history = append(history, time.Now().UTC())
+
+	stamps := make([]string, len(history))
+	for i, t := range history {
+		stamps[i] = t.Format(time.RFC3339Nano)
+	}
+	data, err := json.Marshal(stamps)
+	if err != nil {
+		return
+	}
+	s.store.Set(s.StorageKeyExports(tripName), string(data))
+}
+
+// GetExportHistory returns the trip's export timestamps in the order they were
+// recorded, or nil when none have been (including trips that only have a
+// legacy integer count). Unparseable entries are skipped.
+func (s *Storage) GetExportHistory(tripName string) []time.Time {
+	value := s.store.Get(s.StorageKeyExports(tripName))
+	if value == "" {
+		return nil
+	}
+
+	var stamps []string
+	if err := json.Unmarshal([]byte(value), &stamps); err != nil {
+		return nil
+	}
+	history := make([]time.Time, 0, len(stamps))
+	for _, stamp := range stamps {
+		if t, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+			history = append(history, t)
+		}
+	}
+	return history
 }
 
 // SetItems stores the items for a trip name
 func (s *Storage) SetItems(tripName string, items string) {
 	key := s.StorageKeyItems(tripName)
-	app.Window().Get("localStorage").Call("setItem", key, items)
+	s.store.Set(key, items)
 }
 
 // GetItems retrieves the items for a trip name from localStorage
 func (s *Storage) GetItems(tripName string) string {
 	key := s.StorageKeyItems(tripName)
-	value := app.Window().Get("localStorage").Call("getItem", key).String()
-	return value
+	return s.store.Get(key)
+}
+
+// ListTrips returns the sanitized trip names that have items saved in
+// localStorage, backing a "load previous card" dropdown.
+func (s *Storage) ListTrips() []string {
+	prefix := fmt.Sprintf("%s_items_", s.prefix)
+	var trips []string
+	for _, key := range s.store.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			trips = append(trips, strings.TrimPrefix(key, prefix))
+		}
+	}
+	sort.Strings(trips)
+	return trips
+}
+
+// DeleteTrip removes the stored items and export count for a trip name, so
+// stale entries don't accumulate in localStorage forever.
+func (s *Storage) DeleteTrip(tripName string) {
+	s.store.Remove(s.StorageKeyItems(tripName))
+	s.store.Remove(s.StorageKey(tripName))
+	s.store.Remove(s.StorageKeyExports(tripName))
+}
+
+// ClearAll removes every key under the bingo-creator prefix.
+func (s *Storage) ClearAll() {
+	for _, key := range s.store.Keys() {
+		if strings.HasPrefix(key, s.prefix) {
+			s.store.Remove(key)
+		}
+	}
+}
+
+// uiSettings is the JSON shape of the persisted UI preferences.
+type uiSettings struct {
+	GridSize  int  `json:"gridSize"`
+	ShowHints bool `json:"showHints"`
+}
+
+// SaveSettings persists the UI preferences (grid size and hints) so they
+// survive a page reload.
+func (s *Storage) SaveSettings(gridSize int, showHints bool) {
+	data, err := json.Marshal(uiSettings{GridSize: gridSize, ShowHints: showHints})
+	if err != nil {
+		return
+	}
+	s.store.Set(fmt.Sprintf("%s_settings", s.prefix), string(data))
+}
+
+// LoadSettings restores the persisted UI preferences. ok is false when no
+// valid settings have been saved, in which case callers keep their defaults.
+func (s *Storage) LoadSettings() (gridSize int, showHints bool, ok bool) {
+	value := s.store.Get(fmt.Sprintf("%s_settings", s.prefix))
+	if value == "" {
+		return 0, false, false
+	}
+
+	var settings uiSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return 0, false, false
+	}
+	return settings.GridSize, settings.ShowHints, true
 }
 
 // GenerateFilename creates the PDF filename for an export
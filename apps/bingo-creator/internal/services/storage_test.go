@@ -0,0 +1,260 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestStorage returns a Storage backed by an in-memory store.
+func newTestStorage() (*Storage, *MemoryStore) {
+	store := NewMemoryStore()
+	return NewStorageWithStore(store), store
+}
+
+func TestStorage_ListTrips(t *testing.T) {
+	s, _ := newTestStorage()
+
+	s.SetItems("Austin NOLA 2024", "Alligator\nBillboard")
+	s.SetItems("Road Trip", "Gas station\nCow")
+	s.IncrementCount("Austin NOLA 2024") // count keys must not appear as trips
+
+	trips := s.ListTrips()
+	want := []string{"Austin_NOLA_2024", "Road_Trip"}
+	if len(trips) != len(want) {
+		t.Fatalf("got %d trips %v, want %d", len(trips), trips, len(want))
+	}
+	for i, trip := range trips {
+		if trip != want[i] {
+			t.Errorf("trip %d: got %q, want %q", i, trip, want[i])
+		}
+	}
+}
+
+func TestStorage_ListTrips_Empty(t *testing.T) {
+	s, _ := newTestStorage()
+	if trips := s.ListTrips(); len(trips) != 0 {
+		t.Errorf("got %v, want no trips", trips)
+	}
+}
+
+func TestStorage_CountRoundTrip(t *testing.T) {
+	s, _ := newTestStorage()
+
+	if got := s.GetCount("trip"); got != 0 {
+		t.Errorf("initial count: got %d, want 0", got)
+	}
+	if got := s.IncrementCount("trip"); got != 1 {
+		t.Errorf("first increment: got %d, want 1", got)
+	}
+	if got := s.IncrementCount("trip"); got != 2 {
+		t.Errorf("second increment: got %d, want 2", got)
+	}
+	if got := s.GetCount("trip"); got != 2 {
+		t.Errorf("count after increments: got %d, want 2", got)
+	}
+}
+
+func TestStorage_DeleteTrip(t *testing.T) {
+	s, fake := newTestStorage()
+
+	s.SetItems("trip", "a\nb")
+	s.IncrementCount("trip")
+	s.SetItems("other", "c")
+
+	s.DeleteTrip("trip")
+
+	if got := s.GetItems("trip"); got != "" {
+		t.Errorf("items after delete: got %q, want empty", got)
+	}
+	if got := s.GetCount("trip"); got != 0 {
+		t.Errorf("count after delete: got %d, want 0", got)
+	}
+	if got := s.GetItems("other"); got != "c" {
+		t.Errorf("other trip affected by delete: got %q, want %q", got, "c")
+	}
+	if len(fake.data) != 1 {
+		t.Errorf("got %d keys left, want 1: %v", len(fake.data), fake.data)
+	}
+}
+
+func TestStorage_ClearAll(t *testing.T) {
+	s, fake := newTestStorage()
+
+	s.SetItems("trip", "a")
+	s.IncrementCount("trip")
+	s.SetItems("other", "b")
+	fake.Set("unrelated_key", "keep") // not under the bingo-creator prefix
+
+	s.ClearAll()
+
+	if len(s.ListTrips()) != 0 {
+		t.Errorf("trips remain after ClearAll: %v", s.ListTrips())
+	}
+	if got := fake.Get("unrelated_key"); got != "keep" {
+		t.Errorf("ClearAll removed an unrelated key")
+	}
+}
+
+func TestStorage_SettingsRoundTrip(t *testing.T) {
+	s, _ := newTestStorage()
+
+	s.SaveSettings(7, true)
+
+	gridSize, showHints, ok := s.LoadSettings()
+	if !ok {
+		t.Fatal("LoadSettings: ok = false after SaveSettings")
+	}
+	if gridSize != 7 {
+		t.Errorf("gridSize: got %d, want 7", gridSize)
+	}
+	if !showHints {
+		t.Error("showHints: got false, want true")
+	}
+}
+
+func TestStorage_LoadSettings_Missing(t *testing.T) {
+	s, fake := newTestStorage()
+
+	if _, _, ok := s.LoadSettings(); ok {
+		t.Error("LoadSettings: ok = true with nothing saved")
+	}
+
+	fake.Set("bingo-creator_settings", "not json")
+	if _, _, ok := s.LoadSettings(); ok {
+		t.Error("LoadSettings: ok = true for corrupt settings")
+	}
+}
+
+func TestStorage_GenerateFilename(t *testing.T) {
+	s, _ := newTestStorage()
+
+	if got := s.GenerateFilename("Austin NOLA 2024"); got != "bingo_card_Austin_NOLA_2024_1.pdf" {
+		t.Errorf("first filename: got %q", got)
+	}
+	if got := s.GenerateFilename("Austin NOLA 2024"); got != "bingo_card_Austin_NOLA_2024_2.pdf" {
+		t.Errorf("second filename: got %q", got)
+	}
+}
+
+func TestStorage_ItemsRoundTrip(t *testing.T) {
+	s, _ := newTestStorage()
+
+	s.SetItems("trip", "a\nb\nc")
+	if got := s.GetItems("trip"); got != "a\nb\nc" {
+		t.Errorf("items: got %q, want %q", got, "a\nb\nc")
+	}
+}
+
+func TestStorage_ExportHistoryRoundTrip(t *testing.T) {
+	s, _ := newTestStorage()
+
+	if history := s.GetExportHistory("trip"); history != nil {
+		t.Errorf("initial history: got %v, want nil", history)
+	}
+
+	before := time.Now().Add(-time.Second)
+	s.RecordExport("trip")
+	s.RecordExport("trip")
+	s.RecordExport("trip")
+	after := time.Now().Add(time.Second)
+
+	history := s.GetExportHistory("trip")
+	if len(history) != 3 {
+		t.Fatalf("got %d exports, want 3", len(history))
+	}
+	for i, stamp := range history {
+		if stamp.Before(before) || stamp.After(after) {
+			t.Errorf("export %d timestamp %v outside recording window", i, stamp)
+		}
+		if i > 0 && stamp.Before(history[i-1]) {
+			t.Errorf("export %d recorded before export %d", i, i-1)
+		}
+	}
+
+	if got := s.GetCount("trip"); got != 3 {
+		t.Errorf("count from history: got %d, want 3", got)
+	}
+}
+
+func TestStorage_GetCount_LegacyFallback(t *testing.T) {
+	s, fake := newTestStorage()
+
+	// Trips exported before histories existed only have the integer counter.
+	fake.Set(s.StorageKey("old trip"), "7")
+
+	if got := s.GetCount("old trip"); got != 7 {
+		t.Errorf("legacy count: got %d, want 7", got)
+	}
+	if history := s.GetExportHistory("old trip"); history != nil {
+		t.Errorf("legacy trip history: got %v, want nil", history)
+	}
+}
+
+func TestStorage_ExportHistory_Corrupt(t *testing.T) {
+	s, fake := newTestStorage()
+
+	fake.Set(s.StorageKeyExports("trip"), "not json")
+	if history := s.GetExportHistory("trip"); history != nil {
+		t.Errorf("corrupt history: got %v, want nil", history)
+	}
+}
+
+func TestNewStorageWithPrefix_Namespacing(t *testing.T) {
+	store := NewMemoryStore()
+	s := NewStorageWithPrefixAndStore("event-2026", store)
+
+	if got := s.StorageKey("trip"); got != "event-2026_count_trip" {
+		t.Errorf("StorageKey: got %q", got)
+	}
+	if got := s.StorageKeyItems("trip"); got != "event-2026_items_trip" {
+		t.Errorf("StorageKeyItems: got %q", got)
+	}
+	if got := s.StorageKeyExports("trip"); got != "event-2026_exports_trip" {
+		t.Errorf("StorageKeyExports: got %q", got)
+	}
+}
+
+func TestNewStorageWithPrefix_Sanitized(t *testing.T) {
+	if s := NewStorageWithPrefixAndStore("my app!", NewMemoryStore()); s.prefix != "myapp" {
+		t.Errorf("unsafe characters: got prefix %q, want %q", s.prefix, "myapp")
+	}
+	if s := NewStorageWithPrefixAndStore("", NewMemoryStore()); s.prefix != defaultStoragePrefix {
+		t.Errorf("empty prefix: got %q, want default %q", s.prefix, defaultStoragePrefix)
+	}
+	if s := NewStorageWithPrefixAndStore("!!!", NewMemoryStore()); s.prefix != defaultStoragePrefix {
+		t.Errorf("fully unsafe prefix: got %q, want default %q", s.prefix, defaultStoragePrefix)
+	}
+}
+
+func TestStorage_PrefixesAreIsolated(t *testing.T) {
+	store := NewMemoryStore()
+	a := NewStorageWithPrefixAndStore("alpha", store)
+	b := NewStorageWithPrefixAndStore("beta", store)
+
+	a.SetItems("trip", "one\ntwo")
+	a.RecordExport("trip")
+	a.SaveSettings(7, true)
+
+	if got := b.GetItems("trip"); got != "" {
+		t.Errorf("beta sees alpha's items: %q", got)
+	}
+	if got := b.GetCount("trip"); got != 0 {
+		t.Errorf("beta sees alpha's count: %d", got)
+	}
+	if trips := b.ListTrips(); len(trips) != 0 {
+		t.Errorf("beta sees alpha's trips: %v", trips)
+	}
+	if _, _, ok := b.LoadSettings(); ok {
+		t.Error("beta sees alpha's settings")
+	}
+
+	// Clearing one namespace leaves the other intact.
+	b.SetItems("other", "x")
+	a.ClearAll()
+	if got := b.GetItems("other"); got != "x" {
+		t.Errorf("ClearAll crossed namespaces: got %q", got)
+	}
+	if got := a.GetItems("trip"); got != "" {
+		t.Errorf("alpha items survived ClearAll: %q", got)
+	}
+}
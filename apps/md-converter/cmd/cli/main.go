@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"md-converter/internal/services"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "md-converter:", err)
+		os.Exit(1)
+	}
+}
+
+// run implements the CLI so it can be exercised from tests without exiting
+// the process.
+func run(args []string) error {
+	fs := flag.NewFlagSet("md-converter", flag.ContinueOnError)
+	presetName := fs.String("preset", services.DevicePresets[0].Name, "device preset name (see -list-presets)")
+	format := fs.String("format", "epub", "output format: epub or txt (XTC/XTCH rasterization runs in the browser)")
+	output := fs.String("o", "", "output file path (default: input name with the format's extension)")
+	colophon := fs.Bool("colophon", false, "append an \"About this document\" page with conversion details")
+	listPresets := fs.Bool("list-presets", false, "list available device presets and exit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *listPresets {
+		for _, p := range services.DevicePresets {
+			fmt.Printf("%s (%dx%d)\n", p.Name, p.Width, p.Height)
+		}
+		return nil
+	}
+
+	if *format != "epub" && *format != "txt" {
+		return fmt.Errorf("unsupported format %q: XTC/XTCH rasterization runs in the browser, only epub and txt are available here", *format)
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one markdown file argument, got %d", fs.NArg())
+	}
+	inputPath := fs.Arg(0)
+
+	preset, err := presetByName(*presetName)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("read input: %w", err)
+	}
+
+	if *format == "txt" {
+		sections, err := services.ParseMD(data)
+		if err != nil {
+			return fmt.Errorf("convert %s: %w", inputPath, err)
+		}
+		outputPath := *output
+		if outputPath == "" {
+			outputPath = strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + ".txt"
+		}
+		text := services.SectionsToText(sections)
+		if err := os.WriteFile(outputPath, text, 0o644); err != nil {
+			return fmt.Errorf("write output: %w", err)
+		}
+		fmt.Printf("Wrote %s (%d sections, %d bytes)\n", outputPath, len(sections), len(text))
+		return nil
+	}
+
+	title := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	result, err := services.Convert(data, services.ConvertOptions{
+		Preset:     preset,
+		Title:      title,
+		Colophon:   *colophon,
+		SourceFile: filepath.Base(inputPath),
+	})
+	if err != nil {
+		return fmt.Errorf("convert %s: %w", inputPath, err)
+	}
+
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + ".epub"
+	}
+	if err := os.WriteFile(outputPath, result.EPUBData, 0o644); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+
+	fmt.Printf("Wrote %s (%d sections, %d bytes)\n", outputPath, result.SectionCount, result.EPUBSize)
+	return nil
+}
+
+// presetByName finds a device preset by case-insensitive name.
+func presetByName(name string) (services.DevicePreset, error) {
+	for _, p := range services.DevicePresets {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	names := make([]string, len(services.DevicePresets))
+	for i, p := range services.DevicePresets {
+		names[i] = p.Name
+	}
+	return services.DevicePreset{}, fmt.Errorf("unknown preset %q: available presets are %s", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRun_ConvertsMarkdownFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "doc.md")
+	output := filepath.Join(dir, "doc.epub")
+	md := "# Title\n\nSome text.\n\n# Second\n\nMore text.\n"
+	if err := os.WriteFile(input, []byte(md), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	if err := run([]string{"-preset", "Kindle", "-o", output, input}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("PK")) {
+		t.Errorf("output is not a zip/epub, starts with %q", data[:min(2, len(data))])
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(input, []byte("# T\n\nbody\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	if err := run([]string{"-preset", "No Such Device", input}); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+	if err := run([]string{"-format", "xtc", input}); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+	if err := run([]string{filepath.Join(dir, "missing.md")}); err == nil {
+		t.Error("expected error for missing input file")
+	}
+	if err := run([]string{}); err == nil {
+		t.Error("expected error with no input file")
+	}
+}
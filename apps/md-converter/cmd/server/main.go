@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"md-converter/internal/services"
+)
+
+// maxUploadBytes caps the request body; Markdown documents are small and the
+// limit mostly guards against accidental binary uploads.
+const maxUploadBytes = 20 << 20
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	flag.Parse()
+
+	log.Printf("md-converter API listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, newMux()))
+}
+
+// newMux builds the API routes, separated from main so tests can drive the
+// handlers through httptest.
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /convert", handleConvert)
+	return mux
+}
+
+// handleConvert converts a multipart .md upload ("file" field) and streams
+// the result back. Optional form fields: "preset" (device preset name) and
+// "format" ("epub", the default, or "txt").
+func handleConvert(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+	if err := r.ParseMultipartForm(maxUploadBytes); err != nil {
+		httpError(w, http.StatusBadRequest, "invalid multipart upload: %v", err)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "missing \"file\" field: %v", err)
+		return
+	}
+	defer file.Close()
+
+	if !acceptableUpload(header.Filename, header.Header.Get("Content-Type")) {
+		httpError(w, http.StatusUnsupportedMediaType, "expected a Markdown or text file, got %q", header.Filename)
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "read upload: %v", err)
+		return
+	}
+
+	preset := services.DevicePresets[0]
+	if name := r.FormValue("preset"); name != "" {
+		found := false
+		for _, p := range services.DevicePresets {
+			if strings.EqualFold(p.Name, name) {
+				preset, found = p, true
+				break
+			}
+		}
+		if !found {
+			httpError(w, http.StatusBadRequest, "unknown preset %q", name)
+			return
+		}
+	}
+
+	format := r.FormValue("format")
+	if format == "" {
+		format = "epub"
+	}
+	if format != "epub" && format != "txt" {
+		httpError(w, http.StatusBadRequest, "unsupported format %q: epub and txt are available", format)
+		return
+	}
+
+	sections, err := services.ParseMD(data)
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "parse markdown: %v", err)
+		return
+	}
+
+	stem := strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+	if stem == "" || stem == "." {
+		stem = "document"
+	}
+
+	if format == "txt" {
+		text := services.SectionsToText(sections)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", stem+".txt"))
+		_, _ = w.Write(text)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/epub+zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", stem+".epub"))
+	// Stream the archive as it is built; the headers are already out, so a
+	// late failure can only be logged.
+	if err := services.GenerateEPUBTo(w, sections, services.ConvertOptions{Preset: preset, Title: stem}); err != nil {
+		log.Printf("convert %s: %v", header.Filename, err)
+	}
+}
+
+// acceptableUpload reports whether the uploaded part looks like Markdown or
+// plain text, by extension or by the part's declared content type.
+func acceptableUpload(filename, contentType string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".md", ".markdown", ".txt":
+		return true
+	}
+	return strings.HasPrefix(contentType, "text/")
+}
+
+// httpError writes a plain-text error response with the given status.
+func httpError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	http.Error(w, fmt.Sprintf(format, args...), status)
+}
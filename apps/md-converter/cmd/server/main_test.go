@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// multipartUpload builds a multipart body with one file part and the given
+// extra form fields.
+func multipartUpload(t *testing.T, filename, content string, fields map[string]string) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	if _, err := part.Write([]byte(content)); err != nil {
+		t.Fatalf("write form file: %v", err)
+	}
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			t.Fatalf("write field %s: %v", k, err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatalf("close multipart writer: %v", err)
+	}
+	return &buf, mw.FormDataContentType()
+}
+
+func TestConvertHandler_Success(t *testing.T) {
+	body, contentType := multipartUpload(t, "doc.md", "# Title\n\nSome text.\n", map[string]string{"preset": "Kindle"})
+	req := httptest.NewRequest(http.MethodPost, "/convert", body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+
+	newMux().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/epub+zip" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.Contains(got, "doc.epub") {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if !bytes.HasPrefix(rec.Body.Bytes(), []byte("PK")) {
+		t.Errorf("body is not a zip/epub")
+	}
+}
+
+func TestConvertHandler_TextFormat(t *testing.T) {
+	body, contentType := multipartUpload(t, "doc.md", "# Title\n\nSome text.\n", map[string]string{"format": "txt"})
+	req := httptest.NewRequest(http.MethodPost, "/convert", body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+
+	newMux().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "Title\n=====") {
+		t.Errorf("body missing underlined title:\n%s", rec.Body.String())
+	}
+}
+
+func TestConvertHandler_MalformedUpload(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/convert", strings.NewReader("not multipart at all"))
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+
+	newMux().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestConvertHandler_WrongFileType(t *testing.T) {
+	body, contentType := multipartUpload(t, "image.png", "\x89PNG", nil)
+	req := httptest.NewRequest(http.MethodPost, "/convert", body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+
+	newMux().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want 415", rec.Code)
+	}
+}
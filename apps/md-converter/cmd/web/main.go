@@ -2,9 +2,11 @@ package main
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log"
 	"md-converter/internal/services"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -63,8 +65,44 @@ type inputMethod int
 const (
 	methodFile inputMethod = iota
 	methodURL
+	methodPreview
+	methodPaste
 )
 
+// previewMaxSections limits how many sections large documents preview before
+// the user asks for all of them.
+const previewMaxSections = 25
+
+// excludeStorageKey is the localStorage key for the persisted section
+// exclusion patterns.
+const excludeStorageKey = "md-converter_exclude_patterns"
+
+// urlHistoryKey is the localStorage key for the recently loaded URLs, stored
+// as a JSON array, most recent first.
+const urlHistoryKey = "md-converter_recent_urls"
+
+// urlHistoryMax caps how many recent URLs are remembered.
+const urlHistoryMax = 10
+
+// spacingStorageKey is the localStorage key for the persisted spacing
+// overrides, stored as a JSON spacingState.
+const spacingStorageKey = "md-converter_spacing"
+
+// spacingState is the JSON shape of the persisted spacing overrides. Zero
+// values mean "keep the stylesheet default".
+type spacingState struct {
+	Margin      int     `json:"margin"`
+	LineHeight  float64 `json:"lineHeight"`
+	ParaSpacing float64 `json:"paraSpacing"`
+}
+
+// queuedFile is one entry in the multi-file drop queue.
+type queuedFile struct {
+	name   string
+	data   []byte
+	status string // "ready", "converted" or "error"
+}
+
 // home is the main md-converter component.
 type home struct {
 	app.Compo
@@ -74,29 +112,133 @@ type home struct {
 	fileData []byte
 	fileName string
 	fileURL  string
-	dragOver bool
+	// pasteText holds the paste tab's textarea contents so they survive tab
+	// switches within the session.
+	pasteText string
+	// sourceURL remembers where the loaded bytes were fetched from, for the
+	// colophon page; empty for local files.
+	sourceURL string
+	dragOver  bool
+	// recentURLs mirrors the persisted URL history, most recent first.
+	recentURLs []string
+	// convertAfterLoad chains a conversion onto the next completed load
+	// (the "refetch & convert" shortcut).
+	convertAfterLoad bool
+	// URL fetch lifecycle: fetching is true between Load and the JS callback;
+	// fetchSeq invalidates stale timeout timers; fetchFailed shows the retry
+	// button. fetchTimeoutSec defaults to 20.
+	fetching        bool
+	fetchFailed     bool
+	fetchSeq        int
+	fetchTimeoutSec int
+	// queue lists every loaded file; activeFile is the index of the one the
+	// convert button operates on, or -1 for non-queue input (URL, paste).
+	queue      []queuedFile
+	activeFile int
+
+	// locale is the active UI language code; empty falls back to English via
+	// tr's defaultLocale lookup.
+	locale string
 
 	// Settings
-	presetIndex int
-	formatIndex int
-	landscape   bool
+	presetIndex      int
+	formatIndex      int
+	fontIndex        int
+	headingIndex     int
+	compressionIndex int
+	budgetIndex      int
+	volumeIndex      int
+	footnoteLinks    bool
+	numberSections   bool
+	fixedLayout      bool
+	verticalCJK      bool
+	highlightCode    bool
+	unsafeHTML       bool
+	stackTables      bool
+	showWordCounts   bool
+	cover            bool
+	colophon         bool
+	landscape        bool
+	excludePatterns  string
+	pageBreakMarker  string
+	// Spacing overrides; zero values keep the generated stylesheet's
+	// defaults (preset margin, 1.6/1.8 line-heights, 0.5em paragraphs).
+	marginPx    int
+	lineHeight  float64
+	paraSpacing float64
 
 	// Conversion state
 	converting   bool
 	converted    bool
 	sectionCount int
+	excluded     int
 	epubData     []byte
 	epubName     string
-	statusMsg    string
-	errorMsg     string
+	// volumes holds the per-volume EPUBs when volume splitting produced more
+	// than one; empty for a single-file conversion.
+	volumes     []services.Volume
+	statusMsg   string
+	errorMsg    string
+	sizeWarning string
+	// xtcWarnings lists sections estimated to exceed the XTC per-page
+	// budget, computed at convert time so the result panel can flag them
+	// before the JS generator fails on them.
+	xtcWarnings []string
 
 	// XTC generation state
 	generatingXTC bool
 	xtcComplete   bool
 	xtcExt        string
+
+	// Preview state
+	previewSections []services.Section
+	previewErr      string
+	previewAll      bool
+
+	// Content-derived settings hints for the loaded file; dismissedHints
+	// remembers which ones the user closed until the next load.
+	suggestions    []services.Suggestion
+	dismissedHints map[string]bool
 }
 
 func (h *home) OnMount(ctx app.Context) {
+	if h.fetchTimeoutSec == 0 {
+		h.fetchTimeoutSec = 20
+	}
+
+	// Restore the persisted UI language; unknown codes (a removed locale in
+	// old stored state) keep the English default.
+	if v := app.Window().Get("localStorage").Call("getItem", localeStorageKey); v.Truthy() && knownLocale(v.String()) {
+		h.locale = v.String()
+	}
+
+	// Restore the persisted exclusion patterns, if any.
+	if v := app.Window().Get("localStorage").Call("getItem", excludeStorageKey); v.Truthy() {
+		h.excludePatterns = v.String()
+	}
+
+	// Restore the URL history.
+	if v := app.Window().Get("localStorage").Call("getItem", urlHistoryKey); v.Truthy() {
+		var urls []string
+		if err := json.Unmarshal([]byte(v.String()), &urls); err == nil {
+			h.recentURLs = urls
+		}
+	}
+
+	// Restore the persisted spacing overrides. Values from old stored state
+	// are clamped to the slider ranges rather than rejected.
+	if v := app.Window().Get("localStorage").Call("getItem", spacingStorageKey); v.Truthy() {
+		var s spacingState
+		if err := json.Unmarshal([]byte(v.String()), &s); err == nil {
+			h.marginPx = min(max(s.Margin, 0), 200)
+			if s.LineHeight != 0 {
+				s.LineHeight = min(max(s.LineHeight, 1.0), 3.0)
+			}
+			h.lineHeight = s.LineHeight
+			h.paraSpacing = min(max(s.ParaSpacing, 0), 3.0)
+		}
+	}
+
 	// Register JS callback for file reading result.
 	app.Window().Set("onFileRead", app.FuncOf(func(this app.Value, args []app.Value) interface{} {
 		if len(args) < 2 {
@@ -112,11 +254,61 @@ func (h *home) OnMount(ctx app.Context) {
 			return nil
 		}
 		ctx.Dispatch(func(ctx app.Context) {
+			if h.sourceURL != "" {
+				// Raw hosts often report useless names like "download";
+				// derive something readable from the content or the URL.
+				if title := deriveURLTitle(string(data), h.sourceURL); title != "" {
+					name = title + ".md"
+				}
+			}
+			if h.method == methodFile || h.method == methodPreview {
+				name = h.dedupeName(name)
+				h.queue = append(h.queue, queuedFile{name: name, data: data, status: "ready"})
+				h.activeFile = len(h.queue) - 1
+			} else {
+				h.activeFile = -1
+			}
+			h.fetching = false
+			h.fetchFailed = false
+			h.fetchSeq++ // cancel any pending fetch timeout
 			h.fileData = data
 			h.fileName = name
 			h.errorMsg = ""
 			h.converted = false
-			h.statusMsg = fmt.Sprintf("Loaded: %s (%s)", name, formatBytes(len(data)))
+			h.previewAll = false
+			if h.method == methodPreview {
+				h.refreshPreview()
+			}
+			h.refreshSuggestions()
+			h.statusMsg = h.trf("status.loaded", name, formatBytes(len(data)))
+			if h.sourceURL != "" {
+				h.recordRecentURL(h.sourceURL)
+			}
+			if h.convertAfterLoad {
+				h.convertAfterLoad = false
+				h.onConvert(ctx, app.Event{})
+			}
+		})
+		return nil
+	}))
+
+	// Callback for URL fetch failures, with distinct messages produced on the
+	// JS side for network errors, HTTP statuses and non-text content types.
+	app.Window().Set("onFetchError", app.FuncOf(func(this app.Value, args []app.Value) interface{} {
+		msg := "fetch failed"
+		if len(args) > 0 && args[0].String() != "" {
+			msg = args[0].String()
+		}
+		ctx.Dispatch(func(ctx app.Context) {
+			if !h.fetching {
+				return // timeout already reported
+			}
+			h.fetching = false
+			h.fetchFailed = true
+			h.fetchSeq++
+			h.convertAfterLoad = false
+			h.statusMsg = ""
+			h.errorMsg = "Fetch failed: " + msg
 		})
 		return nil
 	}))
@@ -168,7 +360,7 @@ func (h *home) Render() app.UI {
 func (h *home) renderHeader() app.UI {
 	return app.Header().Class("app-header").Body(
 		app.H1().Class("app-title").Text("md-converter"),
-		app.P().Class("app-subtitle").Text("Convert Markdown files to e-ink optimised EPUB or XTC files"),
+		app.P().Class("app-subtitle").Text(h.tr("subtitle")),
 	)
 }
 
@@ -178,18 +370,33 @@ func (h *home) renderInputSection() app.UI {
 		app.Div().Class("tab-bar").Body(
 			app.Button().
 				Class(h.tabClass(methodFile)).
-				Text("📁 Upload File").
+				Text("📁 "+h.tr("tab.upload")).
 				OnClick(func(ctx app.Context, e app.Event) {
 					h.method = methodFile
 					ctx.Update()
 				}),
 			app.Button().
 				Class(h.tabClass(methodURL)).
-				Text("🔗 Load from URL").
+				Text("🔗 "+h.tr("tab.url")).
 				OnClick(func(ctx app.Context, e app.Event) {
 					h.method = methodURL
 					ctx.Update()
 				}),
+			app.Button().
+				Class(h.tabClass(methodPaste)).
+				Text("✏️ "+h.tr("tab.paste")).
+				OnClick(func(ctx app.Context, e app.Event) {
+					h.method = methodPaste
+					ctx.Update()
+				}),
+			app.Button().
+				Class(h.tabClass(methodPreview)).
+				Text("👁 "+h.tr("tab.preview")).
+				OnClick(func(ctx app.Context, e app.Event) {
+					h.method = methodPreview
+					h.refreshPreview()
+					ctx.Update()
+				}),
 		),
 
 		// File upload panel
@@ -201,6 +408,16 @@ func (h *home) renderInputSection() app.UI {
 		app.If(h.method == methodURL, func() app.UI {
 			return h.renderURLInput()
 		}),
+
+		// Paste panel
+		app.If(h.method == methodPaste, func() app.UI {
+			return h.renderPasteInput()
+		}),
+
+		// Preview panel
+		app.If(h.method == methodPreview, func() app.UI {
+			return h.renderPreview()
+		}),
 	)
 }
 
@@ -217,12 +434,12 @@ func (h *home) renderDropZone() app.UI {
 		dropClass = "drop-zone drag-over"
 	}
 
-	label := "Drag & drop your .md file here, or"
+	label := h.tr("drop.prompt")
 	if h.fileName != "" {
-		label = "✓ " + h.fileName + " — or choose another file"
+		label = "✓ " + h.fileName + " " + h.tr("drop.replace")
 	}
 
-	return app.Div().
+	dropZone := app.Div().
 		Class(dropClass).
 		OnDragOver(h.onDragOver).
 		OnDragLeave(h.onDragLeave).
@@ -231,39 +448,380 @@ func (h *home) renderDropZone() app.UI {
 			app.Div().Class("drop-zone-content").Body(
 				app.Div().Class("drop-icon").Text("📂"),
 				app.P().Class("drop-label").Text(label),
-				app.Label().Class("btn btn-secondary").For("file-input").Text("Browse Files"),
+				app.Label().Class("btn btn-secondary").For("file-input").Text(h.tr("drop.browse")),
 				app.Input().
 					ID("file-input").
 					Type("file").
-					Accept(".md").
+					Accept(".md,.html,.htm,.epub").
+					Multiple(true).
 					Style("display", "none").
 					OnChange(h.onFileChange),
 			),
 		)
+
+	if len(h.queue) < 2 {
+		// Single file keeps the original one-click flow with no queue chrome.
+		return dropZone
+	}
+	return app.Div().Body(dropZone, h.renderQueue())
+}
+
+// renderQueue lists every loaded file with its status; clicking an entry makes
+// it the active conversion input.
+func (h *home) renderQueue() app.UI {
+	items := make([]app.UI, len(h.queue))
+	for i, f := range h.queue {
+		i := i
+		icon := "📄"
+		switch f.status {
+		case "converted":
+			icon = "✅"
+		case "error":
+			icon = "⚠"
+		}
+		class := "btn btn-tab"
+		if i == h.activeFile {
+			class = "btn btn-tab btn-tab-active"
+		}
+		items[i] = app.Li().Body(
+			app.Button().
+				Class(class).
+				Text(fmt.Sprintf("%s %s (%s)", icon, f.name, formatBytes(len(f.data)))).
+				OnClick(func(ctx app.Context, e app.Event) {
+					h.selectQueued(i)
+					ctx.Update()
+				}),
+		)
+	}
+	return app.Div().Class("file-queue").Body(
+		app.P().Class("status-msg").Text(h.trf("queue.count", len(h.queue))),
+		app.Ul().Style("list-style", "none").Style("padding", "0").Body(items...),
+	)
 }
 
 func (h *home) renderURLInput() app.UI {
+	datalistOptions := make([]app.UI, len(h.recentURLs))
+	for i, u := range h.recentURLs {
+		datalistOptions[i] = app.Option().Value(u)
+	}
+
 	return app.Div().Class("url-input-section").Body(
 		app.Div().Class("form-group").Body(
-			app.Label().For("md-url").Text("Direct URL to .md file"),
+			app.Label().For("md-url").Text(h.tr("url.label")),
 			app.Div().Class("url-row").Body(
 				app.Input().
 					ID("md-url").
 					Class("form-input").
 					Type("url").
+					List("recent-urls").
 					Placeholder("https://example.com/document.md").
 					Value(h.fileURL).
 					OnChange(func(ctx app.Context, e app.Event) {
 						h.fileURL = ctx.JSSrc().Get("value").String()
 						ctx.Update()
 					}),
+				app.DataList().ID("recent-urls").Body(datalistOptions...),
 				app.Button().
 					Class("btn btn-secondary").
-					Text("Load").
-					Disabled(h.fileURL == "").
+					Text(h.tr("url.load")).
+					Disabled(h.fileURL == "" || h.fetching).
 					OnClick(h.onLoadURL),
+				app.Button().
+					Class("btn btn-secondary").
+					Text("⚡ "+h.tr("url.refetch")).
+					Disabled(h.fileURL == "" || h.fetching).
+					OnClick(func(ctx app.Context, e app.Event) {
+						h.convertAfterLoad = true
+						h.onLoadURL(ctx, e)
+					}),
+				app.If(h.fetchFailed, func() app.UI {
+					return app.Button().
+						Class("btn btn-secondary").
+						Text("↻ " + h.tr("url.retry")).
+						OnClick(h.onLoadURL)
+				}),
 			),
 		),
+		app.Div().Class("form-group").Body(
+			app.Label().For("fetch-timeout").Text(h.tr("url.timeout")),
+			app.Input().
+				ID("fetch-timeout").
+				Class("form-input").
+				Type("number").
+				Min(1).
+				Value(h.fetchTimeoutSec).
+				OnChange(func(ctx app.Context, e app.Event) {
+					if n, err := strconv.Atoi(ctx.JSSrc().Get("value").String()); err == nil && n > 0 {
+						h.fetchTimeoutSec = n
+					}
+					ctx.Update()
+				}),
+		),
+		app.If(len(h.recentURLs) > 0, func() app.UI {
+			return h.renderRecentURLs()
+		}),
+	)
+}
+
+// renderRecentURLs lists the URL history with one-click refetch and a clear
+// control for shared machines.
+func (h *home) renderRecentURLs() app.UI {
+	items := make([]app.UI, len(h.recentURLs))
+	for i, u := range h.recentURLs {
+		u := u
+		items[i] = app.Li().Body(
+			app.Button().
+				Class("btn btn-tab").
+				Text(u).
+				OnClick(func(ctx app.Context, e app.Event) {
+					h.fileURL = u
+					h.onLoadURL(ctx, e)
+				}),
+		)
+	}
+	return app.Div().Class("recent-urls").Body(
+		app.P().Class("status-msg").Text(h.tr("url.recent")),
+		app.Ul().Style("list-style", "none").Style("padding", "0").Body(items...),
+		app.Button().
+			Class("btn btn-tab").
+			Text("🗑 "+h.tr("url.clear")).
+			OnClick(func(ctx app.Context, e app.Event) {
+				h.clearRecentURLs()
+				ctx.Update()
+			}),
+	)
+}
+
+func (h *home) renderPasteInput() app.UI {
+	return app.Div().Class("paste-input-section").Body(
+		app.Div().Class("form-group").Body(
+			app.Label().For("md-paste").Text(h.tr("paste.label")),
+			app.Textarea().
+				ID("md-paste").
+				Class("form-input").
+				Rows(12).
+				Placeholder("# My Document\n\nPaste Markdown here…").
+				Text(h.pasteText).
+				OnChange(func(ctx app.Context, e app.Event) {
+					h.pasteText = ctx.JSSrc().Get("value").String()
+					ctx.Update()
+				}),
+			app.Button().
+				Class("btn btn-secondary").
+				Text(h.tr("paste.use")).
+				Disabled(strings.TrimSpace(h.pasteText) == "").
+				OnClick(h.onUsePastedText),
+		),
+	)
+}
+
+// onUsePastedText makes the pasted Markdown the conversion input, exactly as
+// if a file of that content had been uploaded.
+func (h *home) onUsePastedText(ctx app.Context, e app.Event) {
+	text := h.pasteText
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	title := firstHeadingTitle(text)
+	if title == "" {
+		title = "Pasted Markdown"
+	}
+	h.fileData = []byte(text)
+	h.fileName = title + ".md"
+	h.sourceURL = ""
+	h.activeFile = -1
+	h.errorMsg = ""
+	h.converted = false
+	h.previewAll = false
+	h.refreshSuggestions()
+	h.statusMsg = h.trf("status.loaded", h.fileName, formatBytes(len(h.fileData)))
+	ctx.Update()
+}
+
+// deriveURLTitle picks a title for a URL-loaded document: front matter wins,
+// then the first heading, then the URL's last meaningful path segment.
+func deriveURLTitle(text, url string) string {
+	if title := frontMatterTitle(text); title != "" {
+		return title
+	}
+	if title := firstHeadingTitle(text); title != "" {
+		return title
+	}
+	return services.TitleFromURL(url)
+}
+
+// frontMatterTitle returns the title field of a leading YAML front matter
+// block, or "" when there is no block or no title in it.
+func frontMatterTitle(text string) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return ""
+	}
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "---" || trimmed == "..." {
+			return ""
+		}
+		if rest, ok := strings.CutPrefix(trimmed, "title:"); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"'`)
+		}
+	}
+	return ""
+}
+
+// firstHeadingTitle returns the text of the first ATX heading in the Markdown,
+// or "" when there is none.
+func firstHeadingTitle(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		return strings.TrimSpace(strings.TrimLeft(line, "#"))
+	}
+	return ""
+}
+
+// refreshPreview re-runs the parser on the loaded bytes using the same
+// sanitization as EPUB generation, so the preview can't hide discrepancies.
+func (h *home) refreshPreview() {
+	h.previewErr = ""
+	if len(h.fileData) == 0 {
+		h.previewSections = nil
+		return
+	}
+	preview := services.PreviewSections
+	if services.IsHTMLPath(h.fileName) {
+		preview = services.PreviewSectionsHTML
+	}
+	sections, err := preview(h.fileData)
+	if err != nil {
+		h.previewSections = nil
+		h.previewErr = err.Error()
+		return
+	}
+	h.previewSections = sections
+}
+
+// refreshSuggestions re-derives the content-based settings hints for the
+// loaded bytes and resets earlier dismissals. Hints are advisory: they never
+// change the user's current selections.
+func (h *home) refreshSuggestions() {
+	h.suggestions = nil
+	h.dismissedHints = nil
+	if len(h.fileData) == 0 {
+		return
+	}
+	preview := services.PreviewSections
+	if services.IsHTMLPath(h.fileName) {
+		preview = services.PreviewSectionsHTML
+	}
+	sections, err := preview(h.fileData)
+	if err != nil {
+		return // the convert path surfaces parse errors
+	}
+	h.suggestions = services.SuggestSettings(sections)
+}
+
+// renderSuggestions lists the undismissed hints, each with a close button.
+func (h *home) renderSuggestions() app.UI {
+	var hints []app.UI
+	for _, s := range h.suggestions {
+		if h.dismissedHints[s.ID] {
+			continue
+		}
+		id := s.ID
+		hints = append(hints, app.Div().Class("hint-msg").Body(
+			app.Span().Text("💡 "+s.Message),
+			app.Button().
+				Class("hint-dismiss").
+				Title(h.tr("hint.dismiss")).
+				Text("✕").
+				OnClick(func(ctx app.Context, e app.Event) {
+					if h.dismissedHints == nil {
+						h.dismissedHints = make(map[string]bool)
+					}
+					h.dismissedHints[id] = true
+					ctx.Update()
+				}),
+		))
+	}
+	if len(hints) == 0 {
+		return app.Div()
+	}
+	return app.Div().Class("hint-list").Body(hints...)
+}
+
+func (h *home) renderPreview() app.UI {
+	if len(h.fileData) == 0 {
+		return app.Div().Class("preview-section").Body(
+			app.P().Class("status-msg").Text(h.tr("preview.empty")),
+		)
+	}
+	if h.previewErr != "" {
+		return app.Div().Class("preview-section").Body(
+			app.P().Class("error-msg").Text("⚠ " + h.previewErr),
+		)
+	}
+
+	preset := h.selectedPreset()
+
+	shown := h.previewSections
+	truncated := false
+	if !h.previewAll && len(shown) > previewMaxSections {
+		shown = shown[:previewMaxSections]
+		truncated = true
+	}
+
+	sectionEls := make([]app.UI, 0, len(shown)+1)
+	for _, section := range shown {
+		sectionEls = append(sectionEls,
+			app.Div().Class("preview-page").Body(
+				app.H3().Text(section.Title),
+				app.Div().Body(app.Raw("<div>"+section.Content+"</div>")),
+			),
+		)
+	}
+	if truncated {
+		sectionEls = append(sectionEls,
+			app.Button().
+				Class("btn btn-secondary").
+				Text(h.trf("preview.showall", len(h.previewSections))).
+				OnClick(func(ctx app.Context, e app.Event) {
+					h.previewAll = true
+					ctx.Update()
+				}),
+		)
+	}
+
+	// Apply the spacing overrides so the preview tracks the sliders live.
+	margin := preset.Margin
+	if h.marginPx != 0 {
+		margin = h.marginPx
+	}
+	container := app.Div().
+		Class("preview-container").
+		Style("max-width", fmt.Sprintf("%dpx", preset.Width)).
+		Style("font-size", fmt.Sprintf("%dpt", preset.FontSize)).
+		Style("max-height", "60vh").
+		Style("overflow-y", "auto").
+		Style("border", "1px solid #ccc").
+		Style("padding", fmt.Sprintf("%dpx", margin))
+	if h.lineHeight != 0 {
+		container = container.Style("line-height", strconv.FormatFloat(h.lineHeight, 'f', -1, 64))
+	}
+	body := []app.UI{container.Body(sectionEls...)}
+	if h.paraSpacing != 0 {
+		// Paragraph margins can't be set inline on the container, so scope a
+		// rule to the preview instead.
+		rule := fmt.Sprintf("<style>.preview-container p { margin: %sem 0; }</style>",
+			strconv.FormatFloat(h.paraSpacing, 'f', -1, 64))
+		body = append([]app.UI{app.Raw(rule)}, body...)
+	}
+
+	return app.Div().Class("preview-section").Body(
+		app.P().Class("status-msg").Text(h.trf("preview.count", len(h.previewSections), preset.Name)),
+		app.Div().Body(body...),
 	)
 }
 
@@ -277,6 +835,51 @@ func (h *home) renderSettings() app.UI {
 			Selected(h.presetIndex == i)
 	}
 
+	fonts := []string{"Device default", "Serif", "Sans", "Monospace"}
+	fontOptions := make([]app.UI, len(fonts))
+	for i, f := range fonts {
+		fontOptions[i] = app.Option().
+			Value(fmt.Sprintf("%d", i)).
+			Text(f).
+			Selected(h.fontIndex == i)
+	}
+
+	headings := []string{"Device default", "Compact", "Normal", "Large"}
+	headingOptions := make([]app.UI, len(headings))
+	for i, s := range headings {
+		headingOptions[i] = app.Option().
+			Value(fmt.Sprintf("%d", i)).
+			Text(s).
+			Selected(h.headingIndex == i)
+	}
+
+	compressions := []string{"Default (best size)", "Fast (quicker conversion)", "Store (no compression)"}
+	compressionOptions := make([]app.UI, len(compressions))
+	for i, c := range compressions {
+		compressionOptions[i] = app.Option().
+			Value(fmt.Sprintf("%d", i)).
+			Text(c).
+			Selected(h.compressionIndex == i)
+	}
+
+	budgets := []string{"No size limit", "5 MB", "10 MB", "20 MB", "50 MB"}
+	budgetOptions := make([]app.UI, len(budgets))
+	for i, b := range budgets {
+		budgetOptions[i] = app.Option().
+			Value(fmt.Sprintf("%d", i)).
+			Text(b).
+			Selected(h.budgetIndex == i)
+	}
+
+	volumes := []string{"No volume split", "~500 KB per volume", "~1 MB per volume", "~2 MB per volume"}
+	volumeOptions := make([]app.UI, len(volumes))
+	for i, v := range volumes {
+		volumeOptions[i] = app.Option().
+			Value(fmt.Sprintf("%d", i)).
+			Text(v).
+			Selected(h.volumeIndex == i)
+	}
+
 	formats := []string{"EPUB", "XTC (1-bit)", "XTCH (2-bit HQ)"}
 	formatOptions := make([]app.UI, len(formats))
 	for i, f := range formats {
@@ -286,10 +889,28 @@ func (h *home) renderSettings() app.UI {
 			Selected(h.formatIndex == i)
 	}
 
+	// Sliders show the effective value; an untouched slider sits at the
+	// default and is labelled as such.
+	preset := h.selectedPreset()
+	marginValue, marginLabel := preset.Margin, h.trf("settings.margin-default", preset.Margin)
+	if h.marginPx != 0 {
+		marginValue, marginLabel = h.marginPx, fmt.Sprintf("%d px", h.marginPx)
+	}
+	lineHeightValue, lineHeightLabel := 1.8, h.tr("settings.lineheight-default")
+	if h.lineHeight != 0 {
+		lineHeightValue = h.lineHeight
+		lineHeightLabel = strconv.FormatFloat(h.lineHeight, 'f', -1, 64)
+	}
+	paraSpacingValue, paraSpacingLabel := 0.5, h.tr("settings.paraspacing-default")
+	if h.paraSpacing != 0 {
+		paraSpacingValue = h.paraSpacing
+		paraSpacingLabel = strconv.FormatFloat(h.paraSpacing, 'f', -1, 64) + " em"
+	}
+
 	return app.Div().Class("settings-section").Body(
 		app.Div().Class("form-group").Body(
 			app.Label().For("device-preset").Body(
-				app.Span().Text("📱 E-Ink Device Preset"),
+				app.Span().Text("📱 "+h.tr("settings.preset")),
 			),
 			app.Select().
 				ID("device-preset").
@@ -297,9 +918,29 @@ func (h *home) renderSettings() app.UI {
 				OnChange(h.onPresetChange).
 				Body(options...),
 		),
+		app.Div().Class("form-group").Body(
+			app.Label().For("font-family").Body(
+				app.Span().Text("🔤 "+h.tr("settings.font")),
+			),
+			app.Select().
+				ID("font-family").
+				Class("form-select").
+				OnChange(h.onFontChange).
+				Body(fontOptions...),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().For("heading-size").Body(
+				app.Span().Text("🔠 "+h.tr("settings.headings")),
+			),
+			app.Select().
+				ID("heading-size").
+				Class("form-select").
+				OnChange(h.onHeadingChange).
+				Body(headingOptions...),
+		),
 		app.Div().Class("form-group").Body(
 			app.Label().For("output-format").Body(
-				app.Span().Text("📄 Output Format"),
+				app.Span().Text("📄 "+h.tr("settings.format")),
 			),
 			app.Select().
 				ID("output-format").
@@ -307,6 +948,227 @@ func (h *home) renderSettings() app.UI {
 				OnChange(h.onFormatChange).
 				Body(formatOptions...),
 		),
+		app.Div().Class("form-group").Body(
+			app.Label().For("compression-mode").Body(
+				app.Span().Text("🗜 "+h.tr("settings.compression")),
+			),
+			app.Select().
+				ID("compression-mode").
+				Class("form-select").
+				OnChange(h.onCompressionChange).
+				Body(compressionOptions...),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().For("size-budget").Body(
+				app.Span().Text("📦 "+h.tr("settings.budget")),
+			),
+			app.Select().
+				ID("size-budget").
+				Class("form-select").
+				OnChange(h.onBudgetChange).
+				Body(budgetOptions...),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().For("volume-split").Body(
+				app.Span().Text("📚 "+h.tr("settings.volumes")),
+			),
+			app.Select().
+				ID("volume-split").
+				Class("form-select").
+				OnChange(h.onVolumeChange).
+				Body(volumeOptions...),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().Class("checkbox-label").Body(
+				app.Input().
+					Type("checkbox").
+					Checked(h.footnoteLinks).
+					OnChange(func(ctx app.Context, e app.Event) {
+						h.footnoteLinks = ctx.JSSrc().Get("checked").Bool()
+						ctx.Update()
+					}),
+				app.Span().Text(" 🔗 "+h.tr("settings.footnotes")),
+			),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().Class("checkbox-label").Body(
+				app.Input().
+					Type("checkbox").
+					Checked(h.numberSections).
+					OnChange(func(ctx app.Context, e app.Event) {
+						h.numberSections = ctx.JSSrc().Get("checked").Bool()
+						ctx.Update()
+					}),
+				app.Span().Text(" 🔢 "+h.tr("settings.numbering")),
+			),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().Class("checkbox-label").Body(
+				app.Input().
+					Type("checkbox").
+					Checked(h.verticalCJK).
+					OnChange(func(ctx app.Context, e app.Event) {
+						h.verticalCJK = ctx.JSSrc().Get("checked").Bool()
+						ctx.Update()
+					}),
+				app.Span().Text(" 🇯🇵 "+h.tr("settings.vertical")),
+			),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().Class("checkbox-label").Body(
+				app.Input().
+					Type("checkbox").
+					Checked(h.highlightCode).
+					OnChange(func(ctx app.Context, e app.Event) {
+						h.highlightCode = ctx.JSSrc().Get("checked").Bool()
+						ctx.Update()
+					}),
+				app.Span().Text(" 💻 "+h.tr("settings.highlight")),
+			),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().Class("checkbox-label").Body(
+				app.Input().
+					Type("checkbox").
+					Checked(h.unsafeHTML).
+					OnChange(func(ctx app.Context, e app.Event) {
+						h.unsafeHTML = ctx.JSSrc().Get("checked").Bool()
+						ctx.Update()
+					}),
+				app.Span().Text(" ⚠ "+h.tr("settings.unsafe")),
+			),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().Class("checkbox-label").Body(
+				app.Input().
+					Type("checkbox").
+					Checked(h.stackTables).
+					OnChange(func(ctx app.Context, e app.Event) {
+						h.stackTables = ctx.JSSrc().Get("checked").Bool()
+						ctx.Update()
+					}),
+				app.Span().Text(" 📋 "+h.tr("settings.stack")),
+			),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().Class("checkbox-label").Body(
+				app.Input().
+					Type("checkbox").
+					Checked(h.showWordCounts).
+					OnChange(func(ctx app.Context, e app.Event) {
+						h.showWordCounts = ctx.JSSrc().Get("checked").Bool()
+						ctx.Update()
+					}),
+				app.Span().Text(" 📊 "+h.tr("settings.wordcounts")),
+			),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().Class("checkbox-label").Body(
+				app.Input().
+					Type("checkbox").
+					Checked(h.cover).
+					OnChange(func(ctx app.Context, e app.Event) {
+						h.cover = ctx.JSSrc().Get("checked").Bool()
+						ctx.Update()
+					}),
+				app.Span().Text(" 📕 "+h.tr("settings.cover")),
+			),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().Class("checkbox-label").Body(
+				app.Input().
+					Type("checkbox").
+					Checked(h.colophon).
+					OnChange(func(ctx app.Context, e app.Event) {
+						h.colophon = ctx.JSSrc().Get("checked").Bool()
+						ctx.Update()
+					}),
+				app.Span().Text(" 📜 "+h.tr("settings.colophon")),
+			),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().For("exclude-patterns").Body(
+				app.Span().Text("🚫 "+h.tr("settings.exclude")),
+			),
+			app.Input().
+				ID("exclude-patterns").
+				Class("form-input").
+				Type("text").
+				Placeholder("license,changelog,contributing").
+				OnChange(h.onExcludeChange).
+				Attr("value", h.excludePatterns),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().For("pagebreak-marker").Body(
+				app.Span().Text("✂ "+h.tr("settings.pagebreak")),
+			),
+			app.Input().
+				ID("pagebreak-marker").
+				Class("form-input").
+				Type("text").
+				Placeholder(`\pagebreak`).
+				OnChange(func(ctx app.Context, e app.Event) {
+					h.pageBreakMarker = ctx.JSSrc().Get("value").String()
+					ctx.Update()
+				}).
+				Attr("value", h.pageBreakMarker),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().For("margin-px").Body(
+				app.Span().Text("↔ "+h.trf("settings.margin", marginLabel)),
+			),
+			app.Input().
+				ID("margin-px").
+				Type("range").
+				Min(0).Max(200).Step(4).
+				Value(marginValue).
+				OnInput(h.onMarginChange),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().For("line-height").Body(
+				app.Span().Text("↕ "+h.trf("settings.lineheight", lineHeightLabel)),
+			),
+			app.Input().
+				ID("line-height").
+				Type("range").
+				Min(1.0).Max(3.0).Step(0.1).
+				Value(lineHeightValue).
+				OnInput(h.onLineHeightChange),
+		),
+		app.Div().Class("form-group").Body(
+			app.Label().For("para-spacing").Body(
+				app.Span().Text("¶ "+h.trf("settings.paraspacing", paraSpacingLabel)),
+			),
+			app.Input().
+				ID("para-spacing").
+				Type("range").
+				Min(0.0).Max(3.0).Step(0.05).
+				Value(paraSpacingValue).
+				OnInput(h.onParaSpacingChange),
+		),
+		app.If(h.marginPx != 0 || h.lineHeight != 0 || h.paraSpacing != 0, func() app.UI {
+			return app.Div().Class("form-group").Body(
+				app.Button().
+					Class("btn btn-secondary").
+					Text(h.tr("settings.reset-spacing")).
+					OnClick(h.onSpacingReset),
+			)
+		}),
+		app.If(h.formatIndex != 0, func() app.UI {
+			// Fixed layout only makes sense when rasterizing to XTC/XTCH.
+			return app.Div().Class("form-group").Body(
+				app.Label().Class("checkbox-label").Body(
+					app.Input().
+						Type("checkbox").
+						Checked(h.fixedLayout).
+						OnChange(func(ctx app.Context, e app.Event) {
+							h.fixedLayout = ctx.JSSrc().Get("checked").Bool()
+							ctx.Update()
+						}),
+					app.Span().Text(" 📐 "+h.tr("settings.fixed")),
+				),
+			)
+		}),
 		app.Div().Class("form-group").Body(
 			app.Label().Class("checkbox-label").Body(
 				app.Input().
@@ -314,19 +1176,50 @@ func (h *home) renderSettings() app.UI {
 					Checked(h.landscape).
 					OnChange(func(ctx app.Context, e app.Event) {
 						h.landscape = ctx.JSSrc().Get("checked").Bool()
+						h.settingsChanged(ctx)
 						ctx.Update()
 					}),
-				app.Span().Text(" 🔄 Landscape Orientation"),
+				app.Span().Text(" 🔄 "+h.tr("settings.landscape")),
 			),
 		),
+		app.Div().Class("form-group").Body(
+			app.Button().
+				Class("btn btn-secondary").
+				Text("🎨 "+h.tr("settings.downloadcss")).
+				OnClick(h.onDownloadCSS),
+		),
 	)
 }
 
+// onDownloadCSS hands the stylesheet for the current settings to the download
+// shim without running a conversion, for sideloading device-wide. The bytes
+// are exactly what a conversion with these settings embeds in the EPUB.
+func (h *home) onDownloadCSS(ctx app.Context, e app.Event) {
+	css := services.GenerateCSS(h.currentOptions(""))
+	name := "styles_" + presetSlug(h.selectedPreset().Name) + ".css"
+	app.Window().Call("downloadEPUB", base64.StdEncoding.EncodeToString([]byte(css)), name)
+}
+
+// presetSlug turns a preset name into a filename-safe fragment
+// ("Onyx Boox Page" → "onyx_boox_page").
+func presetSlug(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			sb.WriteRune(r)
+		case sb.Len() > 0 && !strings.HasSuffix(sb.String(), "_"):
+			sb.WriteByte('_')
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "_")
+}
+
 func (h *home) renderConvertButton() app.UI {
 	hasInput := len(h.fileData) > 0
-	buttonText := "Convert Document"
+	buttonText := h.tr("convert.button")
 	if h.converting {
-		buttonText = "Converting…"
+		buttonText = h.tr("convert.busy")
 	}
 
 	var statusEl app.UI = app.Div()
@@ -344,6 +1237,7 @@ func (h *home) renderConvertButton() app.UI {
 			Disabled(!hasInput || h.converting).
 			OnClick(h.onConvert),
 		statusEl,
+		h.renderSuggestions(),
 	)
 }
 
@@ -352,25 +1246,25 @@ func (h *home) renderResult() app.UI {
 		return app.Div()
 	}
 
-	title := "Document Processed"
+	title := h.tr("result.processed")
 	if h.formatIndex == 0 {
-		title = "Conversion Complete"
+		title = h.tr("result.complete")
 	}
 
-	btnText := "⬇ Download " + h.epubName
+	btnText := h.trf("result.download", h.epubName)
 	if h.formatIndex != 0 {
 		formatName := "XTC"
 		if h.formatIndex == 2 {
 			formatName = "XTCH"
 		}
-		btnText = "⚙ Generate & Download " + formatName
+		btnText = h.trf("result.generate", formatName)
 	}
 
 	var statusRow app.UI = app.Div()
 	if h.formatIndex != 0 && h.xtcComplete {
 		statusRow = app.Div().Class("stat-badge stat-badge-ok").Style("margin-top", "1rem").Body(
-			app.Span().Class("stat-label").Text("Image Generation"),
-			app.Span().Class("stat-value").Text("✓ Complete ("+h.xtcExt+")"),
+			app.Span().Class("stat-label").Text(h.tr("result.imagegen")),
+			app.Span().Class("stat-value").Text(h.trf("result.imagegen-done", h.xtcExt)),
 		)
 	}
 
@@ -381,23 +1275,46 @@ func (h *home) renderResult() app.UI {
 		),
 		app.Div().Class("result-stats").Body(
 			app.Div().Class("stat-badge").Body(
-				app.Span().Class("stat-label").Text("Sections"),
+				app.Span().Class("stat-label").Text(h.tr("result.sections")),
 				app.Span().Class("stat-value").Text(fmt.Sprintf("%d", h.sectionCount)),
 			),
 			app.Div().Class("stat-badge").Body(
-				app.Span().Class("stat-label").Text("Pages"),
+				app.Span().Class("stat-label").Text(h.tr("result.pages")),
 				app.Span().Class("stat-value").Text(fmt.Sprintf("%d", h.sectionCount)),
 			),
+			app.Div().Class("stat-badge").Body(
+				app.Span().Class("stat-label").Text(h.tr("result.size")),
+				app.Span().Class("stat-value").Text(formatBytes(len(h.epubData))),
+			),
 			app.Div().Class("stat-badge stat-badge-ok").Body(
-				app.Span().Class("stat-label").Text("Validation"),
-				app.Span().Class("stat-value").Text("✓ Counts match"),
+				app.Span().Class("stat-label").Text(h.tr("result.validation")),
+				app.Span().Class("stat-value").Text(h.tr("result.counts-match")),
 			),
 		),
+		app.If(h.excluded > 0, func() app.UI {
+			return app.P().Class("status-msg").Text(h.trf("result.excluded", h.excluded))
+		}),
+		app.If(h.sizeWarning != "", func() app.UI {
+			return app.P().Class("error-msg").Text("⚠ " + h.sizeWarning)
+		}),
+		app.If(h.formatIndex != 0 && len(h.xtcWarnings) > 0, func() app.UI {
+			warnings := make([]app.UI, len(h.xtcWarnings))
+			for i, w := range h.xtcWarnings {
+				warnings[i] = app.P().Class("error-msg").Text("⚠ " + w)
+			}
+			return app.Div().Body(warnings...)
+		}),
 		statusRow,
 		app.Button().
 			Class(h.actionBtnClass()).
 			Text(btnText).
 			OnClick(h.onDownload),
+		app.If(h.formatIndex == 0 && len(h.volumes) > 1, func() app.UI {
+			return app.Button().
+				Class("btn btn-success btn-download").
+				Text(h.trf("result.volumes", len(h.volumes))).
+				OnClick(h.onDownloadVolumes)
+		}),
 	)
 }
 
@@ -420,22 +1337,50 @@ func (h *home) renderGeneratingOverlay() app.UI {
 		Style("align-items", "center").Style("justify-content", "center").
 		Style("z-index", "9999").Body(
 		app.Div().Class("spinner").Text("⏳").Style("font-size", "4rem").Style("margin-bottom", "1rem"),
-		app.H2().Style("color", "white").Text("Generating XTC images..."),
-		app.P().Style("color", "#ccc").Text("This might take a minute relying on your hardware. Please wait."),
+		app.H2().Style("color", "white").Text(h.tr("overlay.title")),
+		app.P().Style("color", "#ccc").Text(h.tr("overlay.wait")),
 	)
 }
 
 func (h *home) renderFooter() app.UI {
+	localeOptions := make([]app.UI, len(uiLocales))
+	for i, l := range uiLocales {
+		selected := h.locale == l.Code || (h.locale == "" && l.Code == defaultLocale)
+		localeOptions[i] = app.Option().
+			Value(l.Code).
+			Text(l.Name).
+			Selected(selected)
+	}
+
 	return app.Footer().Class("app-footer").Body(
 		app.P().Body(
 			app.Span().Text("⚡ "),
-			app.Strong().Text("Fast & Private"),
-			app.Span().Text(" — your Markdown files are processed entirely in your browser and never leave your device"),
+			app.Strong().Text(h.tr("footer.fast")),
+			app.Span().Text(h.tr("footer.privacy")),
+		),
+		app.P().Class("footer-credit").Text(h.tr("footer.credit")),
+		app.Div().Class("form-group").Body(
+			app.Label().For("ui-locale").Text("🌐 "+h.tr("footer.language")),
+			app.Select().
+				ID("ui-locale").
+				Class("form-select").
+				OnChange(h.onLocaleChange).
+				Body(localeOptions...),
 		),
-		app.P().Class("footer-credit").Text("Built with Go + WebAssembly using go-app"),
 	)
 }
 
+// onLocaleChange switches the UI language and persists the choice.
+func (h *home) onLocaleChange(ctx app.Context, e app.Event) {
+	code := ctx.JSSrc().Get("value").String()
+	if !knownLocale(code) {
+		return
+	}
+	h.locale = code
+	app.Window().Get("localStorage").Call("setItem", localeStorageKey, code)
+	ctx.Update()
+}
+
 // ── Event Handlers ──────────────────────────────────────────────────────────
 
 func (h *home) onDragOver(ctx app.Context, e app.Event) {
@@ -457,30 +1402,86 @@ func (h *home) onDrop(ctx app.Context, e app.Event) {
 	ctx.Update()
 
 	files := e.Get("dataTransfer").Get("files")
-	if files.Length() == 0 {
-		return
+	h.sourceURL = ""
+	// Read every dropped file; each lands in the queue via onFileRead.
+	for i := 0; i < files.Length(); i++ {
+		app.Window().Call("readFileAsBase64", files.Index(i))
 	}
-	file := files.Index(0)
-	app.Window().Call("readFileAsBase64", file)
 }
 
 func (h *home) onFileChange(ctx app.Context, e app.Event) {
 	files := ctx.JSSrc().Get("files")
-	if files.Length() == 0 {
+	h.sourceURL = ""
+	for i := 0; i < files.Length(); i++ {
+		app.Window().Call("readFileAsBase64", files.Index(i))
+	}
+}
+
+// dedupeName gives repeated filenames a numeric suffix before the extension,
+// so a second "notes.md" queues as "notes-2.md".
+func (h *home) dedupeName(name string) string {
+	taken := make(map[string]bool, len(h.queue))
+	for _, f := range h.queue {
+		taken[f.name] = true
+	}
+	if !taken[name] {
+		return name
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s-%d%s", stem, n, ext)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// selectQueued makes the given queue entry the active conversion input.
+func (h *home) selectQueued(i int) {
+	if i < 0 || i >= len(h.queue) {
 		return
 	}
-	file := files.Index(0)
-	app.Window().Call("readFileAsBase64", file)
+	h.activeFile = i
+	h.fileData = h.queue[i].data
+	h.fileName = h.queue[i].name
+	h.converted = false
+	h.previewAll = false
+	h.errorMsg = ""
+	if h.method == methodPreview {
+		h.refreshPreview()
+	}
+	h.refreshSuggestions()
+	h.statusMsg = h.trf("status.selected", h.fileName, formatBytes(len(h.fileData)))
 }
 
 func (h *home) onLoadURL(ctx app.Context, e app.Event) {
 	if h.fileURL == "" {
 		return
 	}
-	h.statusMsg = "Fetching from URL…"
+	h.statusMsg = h.tr("status.fetching")
 	h.errorMsg = ""
+	h.sourceURL = h.fileURL
+	h.fetching = true
+	h.fetchFailed = false
+	h.fetchSeq++
+	seq := h.fetchSeq
 	ctx.Update()
 
+	// Guard against a fetch that never calls back (hung server, dropped
+	// connection): after the timeout, clear the status and offer a retry.
+	timeout := time.Duration(h.fetchTimeoutSec) * time.Second
+	ctx.After(timeout, func(ctx app.Context) {
+		if seq != h.fetchSeq || !h.fetching {
+			return // this fetch already completed or was superseded
+		}
+		h.fetching = false
+		h.fetchFailed = true
+		h.convertAfterLoad = false
+		h.statusMsg = ""
+		h.errorMsg = h.trf("status.timeout", h.fetchTimeoutSec)
+	})
+
 	url := h.fileURL
 	ctx.Async(func() {
 		result := app.Window().Call("fetchURLAsBase64", url)
@@ -489,6 +1490,28 @@ func (h *home) onLoadURL(ctx app.Context, e app.Event) {
 	})
 }
 
+// recordRecentURL moves url to the front of the history, deduplicated and
+// capped at urlHistoryMax, and persists the result.
+func (h *home) recordRecentURL(url string) {
+	urls := make([]string, 0, len(h.recentURLs)+1)
+	urls = append(urls, url)
+	for _, u := range h.recentURLs {
+		if u != url && len(urls) < urlHistoryMax {
+			urls = append(urls, u)
+		}
+	}
+	h.recentURLs = urls
+	if data, err := json.Marshal(urls); err == nil {
+		app.Window().Get("localStorage").Call("setItem", urlHistoryKey, string(data))
+	}
+}
+
+// clearRecentURLs wipes the URL history from memory and localStorage.
+func (h *home) clearRecentURLs() {
+	h.recentURLs = nil
+	app.Window().Get("localStorage").Call("removeItem", urlHistoryKey)
+}
+
 func (h *home) onPresetChange(ctx app.Context, e app.Event) {
 	val := ctx.JSSrc().Get("value").String()
 	for i, p := range services.DevicePresets {
@@ -498,9 +1521,184 @@ func (h *home) onPresetChange(ctx app.Context, e app.Event) {
 			break
 		}
 	}
+	h.settingsChanged(ctx)
+	ctx.Update()
+}
+
+func (h *home) onFontChange(ctx app.Context, e app.Event) {
+	val := ctx.JSSrc().Get("value").String()
+	for i := range []string{"Device default", "Serif", "Sans", "Monospace"} {
+		if fmt.Sprintf("%d", i) == val {
+			h.fontIndex = i
+			break
+		}
+	}
+	ctx.Update()
+}
+
+func (h *home) onHeadingChange(ctx app.Context, e app.Event) {
+	val := ctx.JSSrc().Get("value").String()
+	for i := 0; i < 4; i++ {
+		if fmt.Sprintf("%d", i) == val {
+			h.headingIndex = i
+			break
+		}
+	}
+	ctx.Update()
+}
+
+// selectedPreset returns the current device preset with the font-family and
+// heading-size selectors applied. "Device default" keeps the preset's own
+// values.
+func (h *home) selectedPreset() services.DevicePreset {
+	preset := services.DevicePresets[h.presetIndex]
+	switch h.fontIndex {
+	case 1:
+		preset.FontFamily = services.FontFamilySerif
+	case 2:
+		preset.FontFamily = services.FontFamilySans
+	case 3:
+		preset.FontFamily = services.FontFamilyMono
+	}
+	switch h.headingIndex {
+	case 1:
+		preset.HeadingScale = services.HeadingScaleCompact
+	case 2:
+		preset.HeadingScale = services.HeadingScaleNormal
+	case 3:
+		preset.HeadingScale = services.HeadingScaleLarge
+	}
+	return preset
+}
+
+func (h *home) onBudgetChange(ctx app.Context, e app.Event) {
+	val := ctx.JSSrc().Get("value").String()
+	for i := 0; i < 5; i++ {
+		if fmt.Sprintf("%d", i) == val {
+			h.budgetIndex = i
+			break
+		}
+	}
+	ctx.Update()
+}
+
+func (h *home) onExcludeChange(ctx app.Context, e app.Event) {
+	h.excludePatterns = ctx.JSSrc().Get("value").String()
+	app.Window().Get("localStorage").Call("setItem", excludeStorageKey, h.excludePatterns)
+	ctx.Update()
+}
+
+func (h *home) onMarginChange(ctx app.Context, e app.Event) {
+	if v, err := strconv.Atoi(ctx.JSSrc().Get("value").String()); err == nil {
+		h.marginPx = min(max(v, 0), 200)
+	}
+	h.saveSpacing()
+	ctx.Update()
+}
+
+func (h *home) onLineHeightChange(ctx app.Context, e app.Event) {
+	if v, err := strconv.ParseFloat(ctx.JSSrc().Get("value").String(), 64); err == nil {
+		h.lineHeight = min(max(v, 1.0), 3.0)
+	}
+	h.saveSpacing()
+	ctx.Update()
+}
+
+func (h *home) onParaSpacingChange(ctx app.Context, e app.Event) {
+	if v, err := strconv.ParseFloat(ctx.JSSrc().Get("value").String(), 64); err == nil {
+		h.paraSpacing = min(max(v, 0), 3.0)
+	}
+	h.saveSpacing()
+	ctx.Update()
+}
+
+func (h *home) onSpacingReset(ctx app.Context, e app.Event) {
+	h.marginPx = 0
+	h.lineHeight = 0
+	h.paraSpacing = 0
+	h.saveSpacing()
+	ctx.Update()
+}
+
+// saveSpacing persists the spacing overrides alongside the other remembered
+// settings.
+func (h *home) saveSpacing() {
+	data, err := json.Marshal(spacingState{
+		Margin:      h.marginPx,
+		LineHeight:  h.lineHeight,
+		ParaSpacing: h.paraSpacing,
+	})
+	if err != nil {
+		return
+	}
+	app.Window().Get("localStorage").Call("setItem", spacingStorageKey, string(data))
+}
+
+// selectedBudget returns the chosen size budget in bytes, or 0 for no limit.
+func (h *home) selectedBudget() int {
+	switch h.budgetIndex {
+	case 1:
+		return 5 * 1024 * 1024
+	case 2:
+		return 10 * 1024 * 1024
+	case 3:
+		return 20 * 1024 * 1024
+	case 4:
+		return 50 * 1024 * 1024
+	default:
+		return 0
+	}
+}
+
+func (h *home) onVolumeChange(ctx app.Context, e app.Event) {
+	val := ctx.JSSrc().Get("value").String()
+	for i := 0; i < 4; i++ {
+		if fmt.Sprintf("%d", i) == val {
+			h.volumeIndex = i
+			break
+		}
+	}
+	ctx.Update()
+}
+
+// selectedVolumeBudget maps the volume-split selector to a per-volume content
+// budget in bytes; 0 keeps the output in one file.
+func (h *home) selectedVolumeBudget() int {
+	switch h.volumeIndex {
+	case 1:
+		return 500 * 1024
+	case 2:
+		return 1024 * 1024
+	case 3:
+		return 2 * 1024 * 1024
+	default:
+		return 0
+	}
+}
+
+func (h *home) onCompressionChange(ctx app.Context, e app.Event) {
+	val := ctx.JSSrc().Get("value").String()
+	for i := range []string{"default", "fast", "store"} {
+		if fmt.Sprintf("%d", i) == val {
+			h.compressionIndex = i
+			break
+		}
+	}
 	ctx.Update()
 }
 
+// selectedCompression maps the compression setting to a services mode.
+func (h *home) selectedCompression() services.CompressionMode {
+	switch h.compressionIndex {
+	case 1:
+		return services.CompressionFast
+	case 2:
+		return services.CompressionStore
+	default:
+		return services.CompressionDefault
+	}
+}
+
 func (h *home) onFormatChange(ctx app.Context, e app.Event) {
 	val := ctx.JSSrc().Get("value").String()
 	for i := range []string{"EPUB", "XTC", "XTCH"} {
@@ -509,47 +1707,132 @@ func (h *home) onFormatChange(ctx app.Context, e app.Event) {
 			break
 		}
 	}
+	h.settingsChanged(ctx)
 	ctx.Update()
 }
 
+// settingsChanged invalidates a stale conversion result after a device,
+// format or orientation change and re-runs the conversion with the retained
+// file data, so the result panel never shows stats for settings that no
+// longer apply. No-op while a conversion is in flight — onConvert guards
+// against re-entry and the in-flight run already has its options captured.
+func (h *home) settingsChanged(ctx app.Context) {
+	if !h.converted && !h.xtcComplete {
+		return
+	}
+	h.converted = false
+	h.xtcComplete = false
+	h.epubData = nil
+	h.sizeWarning = ""
+	if h.converting || len(h.fileData) == 0 {
+		return
+	}
+	h.statusMsg = h.tr("status.reconverting")
+	h.onConvert(ctx, app.Event{})
+}
+
+// currentOptions assembles the ConvertOptions for the current UI selections,
+// so conversion and the standalone CSS download work from the same settings.
+func (h *home) currentOptions(title string) services.ConvertOptions {
+	return services.ConvertOptions{
+		Preset:          h.selectedPreset(),
+		Title:           title,
+		Compression:     h.selectedCompression(),
+		FootnoteLinks:   h.footnoteLinks,
+		NumberSections:  h.numberSections,
+		SizeBudget:      h.selectedBudget(),
+		FixedLayout:     h.fixedLayout && h.formatIndex != 0,
+		VerticalCJK:     h.verticalCJK,
+		HighlightCode:   h.highlightCode,
+		UnsafeHTML:      h.unsafeHTML,
+		StackTables:     h.stackTables,
+		ExcludePatterns: h.excludePatterns,
+		ShowWordCounts:  h.showWordCounts,
+		PageBreakMarker: h.pageBreakMarker,
+		Margin:          h.marginPx,
+		LineHeight:      h.lineHeight,
+		ParaSpacing:     h.paraSpacing,
+		Cover:           h.cover,
+		Colophon:        h.colophon,
+		SourceURL:       h.sourceURL,
+		SourceFile:      h.fileName,
+		VolumeBudget:    h.selectedVolumeBudget(),
+	}
+}
+
 func (h *home) onConvert(ctx app.Context, e app.Event) {
-	if len(h.fileData) == 0 {
+	if len(h.fileData) == 0 || h.converting {
 		return
 	}
 	h.converting = true
 	h.converted = false
 	h.xtcComplete = false
 	h.errorMsg = ""
-	h.statusMsg = "Converting…"
+	h.statusMsg = h.tr("convert.busy")
 	ctx.Update()
 
 	data := h.fileData
-	preset := services.DevicePresets[h.presetIndex]
-	title := strings.TrimSuffix(h.fileName, ".md")
+	preset := h.selectedPreset()
+	title := strings.TrimSuffix(h.fileName, filepath.Ext(h.fileName))
 	if title == "" {
 		title = "Markdown Document"
 	}
 
+	opts := h.currentOptions(title)
+	isHTML := services.IsHTMLPath(h.fileName)
+	isEPUB := services.IsEPUBPath(h.fileName)
+	active := h.activeFile
+	wantXTC := h.formatIndex != 0
 	ctx.Async(func() {
-		result, err := services.Convert(data, preset, title)
+		var result services.ConversionResult
+		var err error
+		switch {
+		case isEPUB:
+			result, err = services.ConvertEPUB(data, opts)
+		case isHTML:
+			result, err = services.ConvertHTML(data, opts)
+		default:
+			result, err = services.Convert(data, opts)
+		}
+
+		// Estimate XTC capacity before the JS generator runs, so oversized
+		// sections are flagged in the result panel instead of failing late
+		// with an opaque error.
+		var xtcWarnings []string
+		if wantXTC && err == nil && !isHTML && !isEPUB {
+			if sections, serr := services.ParseSections(data, opts); serr == nil {
+				xtcWarnings = services.XTCCapacityWarnings(sections, preset)
+			}
+		}
+
 		ctx.Dispatch(func(ctx app.Context) {
 			h.converting = false
 			if err != nil {
 				h.errorMsg = err.Error()
 				h.statusMsg = ""
+				h.setQueueStatus(active, "error")
 				return
 			}
 			if valErr := services.ValidateSectionCount(result); valErr != nil {
 				h.errorMsg = valErr.Error()
 				h.statusMsg = ""
+				h.setQueueStatus(active, "error")
 				return
 			}
+			h.setQueueStatus(active, "converted")
 			h.converted = true
 			h.sectionCount = result.SectionCount
+			h.excluded = result.Excluded
 			h.epubData = result.EPUBData
 			h.epubName = title + ".epub"
+			h.volumes = result.Volumes
 			h.statusMsg = ""
 			h.errorMsg = ""
+			h.sizeWarning = ""
+			if result.SizeWarning != nil {
+				h.sizeWarning = result.SizeWarning.Message()
+			}
+			h.xtcWarnings = xtcWarnings
 		})
 	})
 }
@@ -580,6 +1863,30 @@ func (h *home) onDownload(ctx app.Context, e app.Event) {
 	}
 }
 
+// onDownloadVolumes packs the volume EPUBs into a zip and hands it to the
+// same JS download shim the single EPUB uses.
+func (h *home) onDownloadVolumes(ctx app.Context, e app.Event) {
+	if len(h.volumes) < 2 {
+		return
+	}
+	data, err := services.VolumesZip(h.volumes)
+	if err != nil {
+		h.errorMsg = err.Error()
+		ctx.Update()
+		return
+	}
+	name := strings.TrimSuffix(h.epubName, ".epub") + "-volumes.zip"
+	app.Window().Call("downloadEPUB", base64.StdEncoding.EncodeToString(data), name)
+}
+
+// setQueueStatus records a conversion outcome on the queue entry, if the
+// input came from the queue at all.
+func (h *home) setQueueStatus(i int, status string) {
+	if i >= 0 && i < len(h.queue) {
+		h.queue[i].status = status
+	}
+}
+
 // ── Helpers ──────────────────────────────────────────────────────────────────
 
 func formatBytes(n int) string {
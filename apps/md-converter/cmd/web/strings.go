@@ -0,0 +1,233 @@
+package main
+
+import "fmt"
+
+// localeStorageKey is the localStorage key for the persisted UI language.
+const localeStorageKey = "md-converter_locale"
+
+// defaultLocale is the language every message id exists in; lookups fall back
+// to it when the active locale is missing a translation.
+const defaultLocale = "en"
+
+// uiLocales lists the languages offered by the footer selector, in display
+// order. Each code must have a table in messages.
+var uiLocales = []struct {
+	Code string
+	Name string
+}{
+	{"en", "English"},
+	{"de", "Deutsch"},
+}
+
+// knownLocale reports whether code has a message table.
+func knownLocale(code string) bool {
+	_, ok := messages[code]
+	return ok
+}
+
+// tr returns the message for id in the active locale, falling back to English
+// and finally to the id itself so a missing entry shows up as a visible bug
+// rather than a blank label.
+func (h *home) tr(id string) string {
+	if m, ok := messages[h.locale]; ok {
+		if s, ok := m[id]; ok {
+			return s
+		}
+	}
+	if s, ok := messages[defaultLocale][id]; ok {
+		return s
+	}
+	return id
+}
+
+// trf is tr for messages with fmt verbs; the same verbs must appear in every
+// locale's entry (order included), which strings_test.go enforces.
+func (h *home) trf(id string, args ...interface{}) string {
+	return fmt.Sprintf(h.tr(id), args...)
+}
+
+// messages maps locale → message id → text. English is the reference table:
+// every id used by the UI must exist here. Other locales may be partial and
+// fall back to English per message.
+var messages = map[string]map[string]string{
+	"en": {
+		"subtitle": "Convert Markdown files to e-ink optimised EPUB or XTC files",
+
+		"tab.upload":  "Upload File",
+		"tab.url":     "Load from URL",
+		"tab.paste":   "Paste Text",
+		"tab.preview": "Preview",
+
+		"drop.prompt":  "Drag & drop your .md file here, or",
+		"drop.replace": "— or choose another file",
+		"drop.browse":  "Browse Files",
+		"queue.count":  "%d files queued — pick one to convert",
+
+		"url.label":   "Direct URL to .md file",
+		"url.load":    "Load",
+		"url.refetch": "Refetch & Convert",
+		"url.retry":   "Retry",
+		"url.timeout": "Fetch timeout (seconds)",
+		"url.recent":  "Recently used:",
+		"url.clear":   "Clear history",
+
+		"paste.label": "Paste Markdown text",
+		"paste.use":   "Use this text",
+
+		"hint.dismiss": "Dismiss hint",
+
+		"preview.empty":   "Load a Markdown file or URL first to preview it.",
+		"preview.showall": "Show all %d sections",
+		"preview.count":   "%d sections — previewing at %s settings",
+
+		"settings.preset":              "E-Ink Device Preset",
+		"settings.font":                "Font Family",
+		"settings.headings":            "Heading Size",
+		"settings.format":              "Output Format",
+		"settings.compression":         "EPUB Compression",
+		"settings.budget":              "Size Budget",
+		"settings.volumes":             "Volume Split",
+		"settings.footnotes":           "Footnote-style links (show URLs for offline reading)",
+		"settings.numbering":           "Number sections hierarchically (1, 1.1, 2, …)",
+		"settings.vertical":            "Vertical writing mode (CJK, right-to-left columns)",
+		"settings.highlight":           "Syntax highlighting for code blocks (grayscale)",
+		"settings.unsafe":              "Allow raw HTML in Markdown (sanitized; trusted documents only)",
+		"settings.stack":               "Stack wide tables as lists on narrow screens",
+		"settings.wordcounts":          "Show word counts in the table of contents",
+		"settings.cover":               "Add cover page with the document title",
+		"settings.colophon":            `Add "About this document" page (source URL, conversion details)`,
+		"settings.exclude":             "Exclude sections (comma-separated title globs, e.g. license,chang*)",
+		"settings.pagebreak":           "Page-break marker (a line forcing a new page; <!-- pagebreak --> always works)",
+		"settings.margin":              "Page margin: %s",
+		"settings.margin-default":      "%d px (preset default)",
+		"settings.lineheight":          "Line height: %s",
+		"settings.lineheight-default":  "1.6 / 1.8 (default)",
+		"settings.paraspacing":         "Paragraph spacing: %s",
+		"settings.paraspacing-default": "0.5 em (default)",
+		"settings.reset-spacing":       "Reset spacing to defaults",
+		"settings.fixed":               "Fixed layout (pin pages to device pixel dimensions)",
+		"settings.landscape":           "Landscape Orientation",
+		"settings.downloadcss":         "Download CSS for sideloading",
+
+		"convert.button": "Convert Document",
+		"convert.busy":   "Converting…",
+
+		"status.loaded":       "Loaded: %s (%s)",
+		"status.selected":     "Selected: %s (%s)",
+		"status.fetching":     "Fetching from URL…",
+		"status.reconverting": "Settings changed — re-converting…",
+		"status.timeout":      "Fetch timed out after %ds — the server didn't respond",
+
+		"result.processed":     "Document Processed",
+		"result.complete":      "Conversion Complete",
+		"result.download":      "⬇ Download %s",
+		"result.generate":      "⚙ Generate & Download %s",
+		"result.sections":      "Sections",
+		"result.pages":         "Pages",
+		"result.size":          "Size",
+		"result.validation":    "Validation",
+		"result.counts-match":  "✓ Counts match",
+		"result.excluded":      "🚫 %d section(s) excluded by title pattern",
+		"result.volumes":       "⬇ Download %d volumes (.zip)",
+		"result.imagegen":      "Image Generation",
+		"result.imagegen-done": "✓ Complete (%s)",
+
+		"overlay.title": "Generating XTC images...",
+		"overlay.wait":  "This might take a minute relying on your hardware. Please wait.",
+
+		"footer.fast":     "Fast & Private",
+		"footer.privacy":  " — your Markdown files are processed entirely in your browser and never leave your device",
+		"footer.credit":   "Built with Go + WebAssembly using go-app",
+		"footer.language": "Language",
+	},
+	"de": {
+		"subtitle": "Markdown-Dateien in E-Ink-optimierte EPUB- oder XTC-Dateien umwandeln",
+
+		"tab.upload":  "Datei hochladen",
+		"tab.url":     "Von URL laden",
+		"tab.paste":   "Text einfügen",
+		"tab.preview": "Vorschau",
+
+		"drop.prompt":  "Ziehe deine .md-Datei hierher, oder",
+		"drop.replace": "— oder wähle eine andere Datei",
+		"drop.browse":  "Dateien durchsuchen",
+		"queue.count":  "%d Dateien in der Warteschlange — wähle eine zum Umwandeln",
+
+		"url.label":   "Direkte URL zur .md-Datei",
+		"url.load":    "Laden",
+		"url.refetch": "Neu laden & umwandeln",
+		"url.retry":   "Erneut versuchen",
+		"url.timeout": "Abruf-Timeout (Sekunden)",
+		"url.recent":  "Zuletzt verwendet:",
+		"url.clear":   "Verlauf löschen",
+
+		"paste.label": "Markdown-Text einfügen",
+		"paste.use":   "Diesen Text verwenden",
+
+		"hint.dismiss": "Hinweis ausblenden",
+
+		"preview.empty":   "Lade zuerst eine Markdown-Datei oder URL, um sie anzuzeigen.",
+		"preview.showall": "Alle %d Abschnitte anzeigen",
+		"preview.count":   "%d Abschnitte — Vorschau mit %s-Einstellungen",
+
+		"settings.preset":              "E-Ink-Gerätevorlage",
+		"settings.font":                "Schriftfamilie",
+		"settings.headings":            "Überschriftengröße",
+		"settings.format":              "Ausgabeformat",
+		"settings.compression":         "EPUB-Komprimierung",
+		"settings.budget":              "Größenbudget",
+		"settings.volumes":             "Bandaufteilung",
+		"settings.footnotes":           "Links als Fußnoten (URLs zum Offline-Lesen anzeigen)",
+		"settings.numbering":           "Abschnitte hierarchisch nummerieren (1, 1.1, 2, …)",
+		"settings.vertical":            "Vertikale Schreibrichtung (CJK, Spalten von rechts nach links)",
+		"settings.highlight":           "Syntaxhervorhebung für Codeblöcke (Graustufen)",
+		"settings.unsafe":              "Rohes HTML in Markdown erlauben (bereinigt; nur vertrauenswürdige Dokumente)",
+		"settings.stack":               "Breite Tabellen auf schmalen Bildschirmen als Listen stapeln",
+		"settings.wordcounts":          "Wortzahlen im Inhaltsverzeichnis anzeigen",
+		"settings.cover":               "Titelseite mit dem Dokumenttitel hinzufügen",
+		"settings.colophon":            `Seite "Über dieses Dokument" hinzufügen (Quell-URL, Umwandlungsdetails)`,
+		"settings.exclude":             "Abschnitte ausschließen (kommagetrennte Titelmuster, z. B. license,chang*)",
+		"settings.pagebreak":           "Seitenumbruch-Markierung (eine Zeile erzwingt eine neue Seite; <!-- pagebreak --> funktioniert immer)",
+		"settings.margin":              "Seitenrand: %s",
+		"settings.margin-default":      "%d px (Vorlagenstandard)",
+		"settings.lineheight":          "Zeilenhöhe: %s",
+		"settings.lineheight-default":  "1.6 / 1.8 (Standard)",
+		"settings.paraspacing":         "Absatzabstand: %s",
+		"settings.paraspacing-default": "0,5 em (Standard)",
+		"settings.reset-spacing":       "Abstände auf Standard zurücksetzen",
+		"settings.fixed":               "Festes Layout (Seiten auf Gerätepixelmaße fixieren)",
+		"settings.landscape":           "Querformat",
+		"settings.downloadcss":         "CSS zum Sideloaden herunterladen",
+
+		"convert.button": "Dokument umwandeln",
+		"convert.busy":   "Wird umgewandelt…",
+
+		"status.loaded":       "Geladen: %s (%s)",
+		"status.selected":     "Ausgewählt: %s (%s)",
+		"status.fetching":     "Wird von URL abgerufen…",
+		"status.reconverting": "Einstellungen geändert — wird neu umgewandelt…",
+		"status.timeout":      "Abruf nach %ds abgebrochen — der Server hat nicht geantwortet",
+
+		"result.processed":     "Dokument verarbeitet",
+		"result.complete":      "Umwandlung abgeschlossen",
+		"result.download":      "⬇ %s herunterladen",
+		"result.generate":      "⚙ %s erzeugen & herunterladen",
+		"result.sections":      "Abschnitte",
+		"result.pages":         "Seiten",
+		"result.size":          "Größe",
+		"result.validation":    "Validierung",
+		"result.counts-match":  "✓ Anzahl stimmt überein",
+		"result.excluded":      "🚫 %d Abschnitt(e) per Titelmuster ausgeschlossen",
+		"result.volumes":       "⬇ %d Bände herunterladen (.zip)",
+		"result.imagegen":      "Bilderzeugung",
+		"result.imagegen-done": "✓ Fertig (%s)",
+
+		"overlay.title": "XTC-Bilder werden erzeugt...",
+		"overlay.wait":  "Je nach Hardware kann das eine Minute dauern. Bitte warten.",
+
+		"footer.fast":     "Schnell & privat",
+		"footer.privacy":  " — deine Markdown-Dateien werden vollständig im Browser verarbeitet und verlassen dein Gerät nie",
+		"footer.credit":   "Erstellt mit Go + WebAssembly und go-app",
+		"footer.language": "Sprache",
+	},
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestTr_FallsBackToEnglish(t *testing.T) {
+	h := &home{locale: "de"}
+
+	// Temporarily punch a hole in the German table to exercise the fallback.
+	removed := messages["de"]["tab.upload"]
+	delete(messages["de"], "tab.upload")
+	defer func() { messages["de"]["tab.upload"] = removed }()
+
+	if got := h.tr("tab.upload"); got != messages["en"]["tab.upload"] {
+		t.Errorf("tr with missing German entry = %q, want the English %q", got, messages["en"]["tab.upload"])
+	}
+}
+
+func TestTr_UnknownIDStaysVisible(t *testing.T) {
+	h := &home{}
+	if got := h.tr("no.such.id"); got != "no.such.id" {
+		t.Errorf("tr on an unknown id = %q, want the id itself", got)
+	}
+}
+
+func TestMessages_LocalesConsistent(t *testing.T) {
+	reVerb := regexp.MustCompile(`%[a-z]`)
+
+	for code, table := range messages {
+		if code == defaultLocale {
+			continue
+		}
+		for id, text := range table {
+			en, ok := messages[defaultLocale][id]
+			if !ok {
+				t.Errorf("%s has id %q with no English reference entry", code, id)
+				continue
+			}
+			// trf interpolates positionally, so every locale must use the same
+			// fmt verbs in the same order as English.
+			got, want := reVerb.FindAllString(text, -1), reVerb.FindAllString(en, -1)
+			if len(got) != len(want) {
+				t.Errorf("%s %q has verbs %v, English has %v", code, id, got, want)
+				continue
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("%s %q has verbs %v, English has %v", code, id, got, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestUILocales_AllKnown(t *testing.T) {
+	for _, l := range uiLocales {
+		if !knownLocale(l.Code) {
+			t.Errorf("selector offers %q but messages has no table for it", l.Code)
+		}
+	}
+}
@@ -3,8 +3,13 @@ module md-converter
 go 1.26
 
 require (
+	github.com/alecthomas/chroma/v2 v2.2.0
 	github.com/maxence-charriere/go-app/v10 v10.1.11
 	github.com/yuin/goldmark v1.8.4
+	github.com/yuin/goldmark-highlighting/v2 v2.0.0-20230729083705-37449abec8cc
 )
 
-require github.com/google/uuid v1.6.0 // indirect
+require (
+	github.com/dlclark/regexp2 v1.7.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+)
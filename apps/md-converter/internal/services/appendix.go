@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// appendixSuffix is the per-heading marker for appendix sections: a heading
+// like "## License text {.appendix}" is kept out of the main reading flow.
+const appendixSuffix = "{.appendix}"
+
+// MarkAppendices flags sections that should sit outside the main reading
+// flow: those whose heading carries the {.appendix} suffix (which is stripped
+// from the title) and those whose title matches any of the given patterns
+// (same comma-separated glob syntax as ExcludeSections). Appendix sections
+// are written with linear="no" in the spine and grouped under an "Appendices"
+// branch in the navigation; the reading order of the remaining sections is
+// unchanged. The input slice is not modified.
+func MarkAppendices(sections []Section, patterns string) ([]Section, error) {
+	globs := splitPatterns(patterns)
+
+	result := make([]Section, len(sections))
+	for i, section := range sections {
+		if title, ok := cutAppendixSuffix(section.Title); ok {
+			section.Title = title
+			section.Appendix = true
+		} else if len(globs) > 0 {
+			matched, err := matchesAny(globs, section.Title)
+			if err != nil {
+				return nil, fmt.Errorf("bad appendix pattern: %w", err)
+			}
+			section.Appendix = matched
+		}
+		result[i] = section
+	}
+	return result, nil
+}
+
+// cutAppendixSuffix strips a trailing {.appendix} marker from a title,
+// reporting whether it was present.
+func cutAppendixSuffix(title string) (string, bool) {
+	trimmed := strings.TrimSpace(title)
+	if !strings.HasSuffix(trimmed, appendixSuffix) {
+		return title, false
+	}
+	return strings.TrimSpace(strings.TrimSuffix(trimmed, appendixSuffix)), true
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkAppendices_Suffix(t *testing.T) {
+	sections := []Section{
+		{Title: "Introduction"},
+		{Title: "License text {.appendix}"},
+		{Title: "Raw data   {.appendix}"},
+	}
+
+	marked, err := MarkAppendices(sections, "")
+	if err != nil {
+		t.Fatalf("MarkAppendices: %v", err)
+	}
+
+	if marked[0].Appendix {
+		t.Error("Introduction should not be an appendix")
+	}
+	if !marked[1].Appendix || marked[1].Title != "License text" {
+		t.Errorf("section 1: got appendix=%v title=%q", marked[1].Appendix, marked[1].Title)
+	}
+	if !marked[2].Appendix || marked[2].Title != "Raw data" {
+		t.Errorf("section 2: got appendix=%v title=%q", marked[2].Appendix, marked[2].Title)
+	}
+
+	// Input slice is untouched.
+	if sections[1].Appendix || sections[1].Title != "License text {.appendix}" {
+		t.Error("input slice was modified")
+	}
+}
+
+func TestMarkAppendices_Patterns(t *testing.T) {
+	sections := []Section{
+		{Title: "Introduction"},
+		{Title: "Appendix A"},
+		{Title: "Appendix B"},
+	}
+
+	marked, err := MarkAppendices(sections, "appendix *")
+	if err != nil {
+		t.Fatalf("MarkAppendices: %v", err)
+	}
+
+	if marked[0].Appendix {
+		t.Error("Introduction should not match")
+	}
+	if !marked[1].Appendix || !marked[2].Appendix {
+		t.Error("Appendix A/B should match the pattern")
+	}
+}
+
+func TestMarkAppendices_BadPattern(t *testing.T) {
+	if _, err := MarkAppendices([]Section{{Title: "x"}}, "[bad"); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
+
+func TestConvert_AppendixSpineLinearity(t *testing.T) {
+	md := "# Guide\n\nintro\n\n## Setup\n\nbody\n\n## License {.appendix}\n\nlegal\n\n## Usage\n\nbody\n"
+
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Guide"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	opf := readEPUBEntry(t, result.EPUBData, "OEBPS/content.opf")
+	if !strings.Contains(opf, `<itemref idref="license" linear="no"/>`) {
+		t.Errorf("opf missing non-linear appendix itemref:\n%s", opf)
+	}
+	for _, id := range []string{"guide", "setup", "usage"} {
+		if !strings.Contains(opf, `<itemref idref="`+id+`"/>`) {
+			t.Errorf("opf missing linear itemref for %q:\n%s", id, opf)
+		}
+	}
+
+	// Linear reading order is unchanged: setup before usage, license between
+	// them in the spine but marked non-linear.
+	if strings.Index(opf, `idref="setup"`) > strings.Index(opf, `idref="usage"`) {
+		t.Error("linear section order changed in the spine")
+	}
+
+	nav := readEPUBEntry(t, result.EPUBData, "OEBPS/nav.xhtml")
+	if !strings.Contains(nav, "<span>Appendices</span>") {
+		t.Errorf("nav missing Appendices branch:\n%s", nav)
+	}
+	if !strings.Contains(nav, `<li><a href="license.xhtml">License</a></li>`) {
+		t.Errorf("nav missing appendix entry:\n%s", nav)
+	}
+	// The appendix entry only appears inside the branch, not the main list.
+	if strings.Count(nav, "license.xhtml") != 1 {
+		t.Errorf("appendix listed more than once:\n%s", nav)
+	}
+}
+
+func TestConvert_AppendixPatternsOption(t *testing.T) {
+	md := "# Doc\n\nintro\n\n## Raw Data\n\nnumbers\n"
+
+	result, err := Convert([]byte(md), ConvertOptions{AppendixPatterns: "raw *"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	opf := readEPUBEntry(t, result.EPUBData, "OEBPS/content.opf")
+	if !strings.Contains(opf, `<itemref idref="raw-data" linear="no"/>`) {
+		t.Errorf("opf missing non-linear itemref for pattern match:\n%s", opf)
+	}
+}
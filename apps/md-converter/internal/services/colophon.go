@@ -0,0 +1,44 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// Version is the md-converter version stamped onto colophon pages. It is
+// overridable at build time with:
+// -ldflags "-X md-converter/internal/services.Version=<version>"
+var Version = "dev"
+
+// colophonTitle is the nav label and page heading of the generated colophon.
+const colophonTitle = "About this document"
+
+// generateColophon renders the "About this document" page content: a
+// definition list of the conversion details. Fields that are unknown are
+// omitted rather than shown blank.
+func generateColophon(opts ConvertOptions) string {
+	var rows []string
+	add := func(label, value string) {
+		if value == "" {
+			return
+		}
+		rows = append(rows, fmt.Sprintf("<dt>%s</dt><dd>%s</dd>", label, value))
+	}
+
+	if opts.SourceURL != "" {
+		add("Source", fmt.Sprintf(`<a href=%q>%s</a>`,
+			html.EscapeString(opts.SourceURL), html.EscapeString(opts.SourceURL)))
+	}
+	add("Original file", html.EscapeString(opts.SourceFile))
+	// The conversion date is a wall-clock stamp, so reproducible output omits
+	// it rather than showing a misleading fixed date.
+	if !opts.Reproducible {
+		add("Converted", time.Now().UTC().Format("2006-01-02"))
+	}
+	add("Device preset", fmt.Sprintf("%s (%dx%d)", html.EscapeString(opts.Preset.Name), opts.Preset.Width, opts.Preset.Height))
+	add("Converted by", "md-converter "+html.EscapeString(Version))
+
+	return fmt.Sprintf("<dl>%s</dl>", strings.Join(rows, ""))
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvert_ColophonPage(t *testing.T) {
+	md := "# Alpha\n\nbody\n\n# Beta\n\nmore body\n"
+
+	result, err := Convert([]byte(md), ConvertOptions{
+		Title:      "Test",
+		Colophon:   true,
+		SourceURL:  "https://example.com/doc.md",
+		SourceFile: "doc.md",
+	})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	// The colophon is a generated page, not a parsed section.
+	if result.SectionCount != 2 || result.EPUBSections != 2 {
+		t.Errorf("counts: got %d/%d, want 2/2", result.SectionCount, result.EPUBSections)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/colophon.xhtml")
+	for _, want := range []string{"About this document", "https://example.com/doc.md", "doc.md", "md-converter", DevicePresets[0].Name} {
+		if !strings.Contains(page, want) {
+			t.Errorf("colophon missing %q:\n%s", want, page)
+		}
+	}
+
+	nav := readEPUBEntry(t, result.EPUBData, "OEBPS/nav.xhtml")
+	if !strings.Contains(nav, `href="colophon.xhtml"`) {
+		t.Errorf("nav missing colophon entry:\n%s", nav)
+	}
+	opf := readEPUBEntry(t, result.EPUBData, "OEBPS/content.opf")
+	if !strings.Contains(opf, `<itemref idref="colophon"/>`) {
+		t.Errorf("spine missing colophon itemref:\n%s", opf)
+	}
+}
+
+func TestConvert_ColophonOmitsUnknownFields(t *testing.T) {
+	result, err := Convert([]byte("# Alpha\n\nbody\n"), ConvertOptions{Title: "Test", Colophon: true})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/colophon.xhtml")
+	for _, absent := range []string{"Source</dt>", "Original file"} {
+		if strings.Contains(page, absent) {
+			t.Errorf("colophon shows unknown field %q:\n%s", absent, page)
+		}
+	}
+	if strings.Contains(page, "<dd></dd>") {
+		t.Errorf("colophon has blank value:\n%s", page)
+	}
+}
+
+func TestConvert_ColophonOffByDefault(t *testing.T) {
+	result, err := Convert([]byte("# Alpha\n\nbody\n"), ConvertOptions{Title: "Test"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	opf := readEPUBEntry(t, result.EPUBData, "OEBPS/content.opf")
+	if strings.Contains(opf, "colophon") {
+		t.Errorf("colophon present without the option:\n%s", opf)
+	}
+}
+
+func TestConvert_ColophonSlugCollision(t *testing.T) {
+	md := "# Colophon\n\nauthored colophon\n"
+
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Test", Colophon: true})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	authored := readEPUBEntry(t, result.EPUBData, "OEBPS/colophon.xhtml")
+	if !strings.Contains(authored, "authored colophon") {
+		t.Errorf("authored section lost its slug:\n%s", authored)
+	}
+	generated := readEPUBEntry(t, result.EPUBData, "OEBPS/colophon-page.xhtml")
+	if !strings.Contains(generated, "About this document") {
+		t.Errorf("generated colophon missing:\n%s", generated)
+	}
+}
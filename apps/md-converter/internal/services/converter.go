@@ -1,34 +1,370 @@
 package services
 
-import "fmt"
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// ConvertOptions configures a conversion. The zero value gives the original
+// defaults: first device preset, default compression, and none of the
+// optional passes enabled.
+type ConvertOptions struct {
+	Preset           DevicePreset    // target device; zero value falls back to DevicePresets[0]
+	Title            string          // book title; empty falls back to "Markdown Document"
+	Compression      CompressionMode // EPUB entry compression
+	FootnoteLinks    bool            // rewrite external links as footnote references
+	NumberSections   bool            // prefix titles with hierarchical numbers
+	SizeBudget       int             // bytes; exceeding it yields a SizeWarning, 0 disables
+	FixedLayout      bool            // pre-paginated EPUB pinned to preset pixel dimensions
+	MaxSectionChars  int             // split sections larger than this into continuation pages, 0 disables
+	MinSectionChars  int             // merge sections smaller than this into the following section, 0 disables
+	StackTables      bool            // rewrite tables as stacked header:value lists on narrow presets
+	ASCIICheckboxes  bool            // render task-list boxes as [ ]/[x] instead of ☐/☑ glyphs
+	VerticalCJK      bool            // vertical-rl writing mode with CJK styling
+	HighlightCode    bool            // syntax-highlight fenced code blocks (Markdown input only)
+	UnsafeHTML       bool            // pass raw HTML in Markdown through (sanitized) instead of dropping it
+	ExcludePatterns  string          // comma-separated case-insensitive title globs to drop
+	AppendixPatterns string          // comma-separated title globs marked as appendices (out of the main reading flow); the {.appendix} heading suffix always works
+	ShowWordCounts   bool            // annotate TOC entries with per-section word counts
+	PageBreakMarker  string          // line that forces a new page; empty uses the default \pagebreak
+	Margin           int             // page margin override in px; 0 keeps the preset's margin
+	LineHeight       float64         // line-height override; 0 keeps the stylesheet defaults
+	ParaSpacing      float64         // paragraph spacing override in em; 0 keeps the default 0.5em
+	Cover            bool            // prepend a typographic cover page with the book title
+	Colophon         bool            // append an "About this document" page with conversion details
+	SourceURL        string          // where the input was fetched from, shown on the colophon
+	SourceFile       string          // original input file name, shown on the colophon
+	Reproducible     bool            // make output byte-identical across runs: unknown modified times fall back to a fixed epoch instead of time.Now
+	Modified         time.Time       // document last-modified time for dcterms:modified; zero means unknown (conversion time, or the fixed epoch when Reproducible)
+	Published        time.Time       // publication date for dc:date; zero omits it (front matter date fills it for Markdown input)
+	VolumeBudget     int             // bytes of section content per volume; >0 additionally splits the output into multiple EPUBs, 0 disables
+	TOCBackLinks     bool            // append an "↑ Contents" link to every section page, pointing back to the nav/TOC
+	SVGCaptions      bool            // replace inline SVGs with their <title>/aria-label text, for readers without SVG support
+	MathML           bool            // render $...$/$$...$$ LaTeX math as MathML instead of literal text
+	DropDetails      bool            // remove <details> blocks entirely instead of unwrapping them into visible content
+	TOCDepth         int             // deepest heading level listed in the nav/TOC (1-6); 0 means the default 3; deeper sections still get pages
+	DefinitionLists  bool            // enable "Term" / ": definition" definition-list syntax (Markdown input only)
+	RunningHeader    bool            // running "book title · section title" line at the top of every page; injected at render time, so word counts are unaffected
+	RunningFooter    bool            // running "Section n of N" line at the bottom of every page
+	Language         string          // dc:language / xml:lang; empty keeps "en" ("ja" when VerticalCJK); filled from imported EPUB metadata
+}
+
+// withDefaults fills in the fallbacks the zero value implies and clamps the
+// spacing overrides, so out-of-range values from old persisted UI state can't
+// produce an unreadable stylesheet.
+func (o ConvertOptions) withDefaults() ConvertOptions {
+	if o.Preset.Name == "" {
+		o.Preset = DevicePresets[0]
+	}
+	if o.PageBreakMarker == "" {
+		o.PageBreakMarker = defaultPageBreakMarker
+	}
+	o.Margin = min(max(o.Margin, 0), 200)
+	if o.LineHeight != 0 {
+		o.LineHeight = min(max(o.LineHeight, 1.0), 3.0)
+	}
+	o.ParaSpacing = min(max(o.ParaSpacing, 0), 3.0)
+	o.VolumeBudget = max(o.VolumeBudget, 0)
+	if o.TOCDepth == 0 {
+		o.TOCDepth = 3
+	}
+	o.TOCDepth = min(max(o.TOCDepth, 1), 6)
+	return o
+}
 
 // ConversionResult holds the output of a successful .md → .epub conversion.
 type ConversionResult struct {
-	EPUBData     []byte
-	SectionCount int // number of sections parsed from the .md
-	EPUBSections int // number of sections written to the .epub (should equal SectionCount)
+	EPUBData      []byte
+	EPUBSize      int             // final size of the .epub in bytes
+	Compression   CompressionMode // compression mode used for the .epub entries
+	SectionCount  int             // number of sections parsed from the .md
+	EPUBSections  int             // number of sections written to the .epub (should equal SectionCount)
+	Excluded      int             // number of sections dropped by the exclusion filter
+	ContentBytes  int             // total section HTML bytes before output minification
+	MinifiedBytes int             // total section HTML bytes after output minification
+	SizeWarning   *SizeWarning    // non-nil when the .epub exceeded the size budget
+	SectionStats  []SectionStat   // per-section statistics, in spine order
+	Warnings      []string        // non-fatal notes, e.g. an unparseable front matter date
+	Volumes       []Volume        // the output split into volumes when VolumeBudget is set; empty when everything fits in one
+}
+
+// Volume is one EPUB of a volume-split conversion, covering a contiguous
+// range of sections with its own title and navigation.
+type Volume struct {
+	Title    string // book title with " — Vol. k" appended
+	EPUBData []byte
+	Sections int // number of sections in this volume
+}
+
+// SectionStat describes one section as written to the .epub. Words is the
+// count the TOC annotations show when ShowWordCounts is enabled.
+type SectionStat struct {
+	ID    string // section identifier, also the page file name stem
+	Title string // title after numbering/splitting passes
+	Words int    // word count of the stripped section text
 }
 
 // Convert parses the .md file bytes, generates an .epub, and returns the
 // result with section counts for validation.
-func Convert(mdData []byte, preset DevicePreset, title string) (ConversionResult, error) {
-	sections, err := ParseMD(mdData)
+func Convert(mdData []byte, opts ConvertOptions) (ConversionResult, error) {
+	opts = opts.withDefaults()
+	var warnings []string
+	mdData, meta := extractFrontMatter(mdData)
+	if raw, ok := meta["date"]; ok && opts.Published.IsZero() {
+		if date, err := parseDocumentDate(raw); err == nil {
+			opts.Published = date
+		} else {
+			warnings = append(warnings,
+				fmt.Sprintf("front matter date %q is not a recognized format (YYYY-MM-DD, ISO 8601 or RFC 3339) — dc:date omitted", raw))
+		}
+	}
+	mdData = applyPageBreakMarkers(mdData, opts.PageBreakMarker)
+	var mathBlocks []string
+	if opts.MathML {
+		mdData, mathBlocks = extractMath(mdData)
+	}
+	sections, err := ParseMDWithDefinitionLists(mdData, opts.HighlightCode, opts.UnsafeHTML, opts.DefinitionLists)
 	if err != nil {
 		return ConversionResult{}, fmt.Errorf("parse markdown: %w", err)
 	}
+	sections = restoreMath(sections, mathBlocks)
+	result, err := convertSections(sections, opts)
+	if err != nil {
+		return ConversionResult{}, err
+	}
+	result.Warnings = warnings
+	return result, nil
+}
+
+// documentDateLayouts are the date formats accepted from front matter, tried
+// in order: plain date, RFC 3339, and ISO 8601 date-times without a zone.
+var documentDateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// parseDocumentDate parses a front matter date value as a UTC time.
+func parseDocumentDate(val string) (time.Time, error) {
+	for _, layout := range documentDateLayouts {
+		if t, err := time.Parse(layout, val); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", val)
+}
+
+// ConvertDefault is a thin backward-compatible wrapper around Convert with
+// the original positional signature.
+func ConvertDefault(mdData []byte, preset DevicePreset, title string) (ConversionResult, error) {
+	return Convert(mdData, ConvertOptions{Preset: preset, Title: title})
+}
+
+// ConvertHTML is Convert for raw HTML input (saved articles, pandoc output):
+// goldmark is skipped and the sanitized body is split into sections directly.
+// HighlightCode has no effect on HTML input.
+func ConvertHTML(htmlData []byte, opts ConvertOptions) (ConversionResult, error) {
+	opts = opts.withDefaults()
+	sections, err := ParseHTML(htmlData)
+	if err != nil {
+		return ConversionResult{}, fmt.Errorf("parse html: %w", err)
+	}
+	return convertSections(sections, opts)
+}
+
+// ParseSections parses the .md file bytes and runs the same section passes a
+// full conversion would, but stops before EPUB generation. It is the entry
+// point for tools that build their own output (PDF, standalone HTML, …) from
+// the converter's sections; the top-level mdconverter package re-exports it.
+func ParseSections(mdData []byte, opts ConvertOptions) ([]Section, error) {
+	opts = opts.withDefaults()
+	mdData = applyPageBreakMarkers(mdData, opts.PageBreakMarker)
+	var mathBlocks []string
+	if opts.MathML {
+		mdData, mathBlocks = extractMath(mdData)
+	}
+	sections, err := ParseMDWithDefinitionLists(mdData, opts.HighlightCode, opts.UnsafeHTML, opts.DefinitionLists)
+	if err != nil {
+		return nil, fmt.Errorf("parse markdown: %w", err)
+	}
+	sections = restoreMath(sections, mathBlocks)
+	sections, _, err = applySectionPasses(sections, opts)
+	return sections, err
+}
+
+// applySectionPasses runs the shared post-parse pipeline: exclusion, checkbox
+// replacement, tiny-section merging, optional numbering, pagination and link
+// passes. Merging runs before numbering so merged-away headings don't consume
+// numbers; numbering runs before the splitter so continuation pages inherit
+// their section's number; footnotes run last so every page gets its own link
+// list. Returns the transformed sections and the exclusion count.
+func applySectionPasses(sections []Section, opts ConvertOptions) ([]Section, int, error) {
+	sections, excluded, err := ExcludeSections(sections, opts.ExcludePatterns)
+	if err != nil {
+		return nil, 0, fmt.Errorf("exclude sections: %w", err)
+	}
+
+	sections, err = MarkAppendices(sections, opts.AppendixPatterns)
+	if err != nil {
+		return nil, 0, fmt.Errorf("mark appendices: %w", err)
+	}
+
+	sections = ReplaceTaskCheckboxes(sections, opts.ASCIICheckboxes)
+	sections = UnwrapDetails(sections, opts.DropDetails)
+	if opts.SVGCaptions {
+		sections = ReplaceSVGCaptions(sections)
+	}
+	sections = MergeTinySections(sections, opts.MinSectionChars)
+	if opts.StackTables {
+		sections = StackTables(sections, opts.Preset)
+	}
+	if opts.NumberSections {
+		sections = NumberSections(sections)
+	}
+	sections = SplitLargeSections(sections, opts.MaxSectionChars)
+	if opts.FootnoteLinks {
+		sections = RewriteLinksAsFootnotes(sections)
+	}
+	return sections, excluded, nil
+}
 
-	epubData, err := GenerateEPUB(sections, preset, title)
+// convertSections applies the section passes and output minification, then
+// EPUB generation and the size-budget check.
+func convertSections(sections []Section, opts ConvertOptions) (ConversionResult, error) {
+	sections, excluded, err := applySectionPasses(sections, opts)
+	if err != nil {
+		return ConversionResult{}, err
+	}
+	sections, contentBytes, minifiedBytes := MinifySections(sections)
+
+	epubData, err := generateEPUB(sections, opts)
 	if err != nil {
 		return ConversionResult{}, fmt.Errorf("generate epub: %w", err)
 	}
+	if err := ValidateEPUB(epubData); err != nil {
+		return ConversionResult{}, fmt.Errorf("validate epub: %w", err)
+	}
+
+	warning, err := CheckSizeBudget(epubData, opts.SizeBudget)
+	if err != nil {
+		return ConversionResult{}, fmt.Errorf("check size budget: %w", err)
+	}
+
+	volumes, err := generateVolumes(sections, opts)
+	if err != nil {
+		return ConversionResult{}, err
+	}
 
 	return ConversionResult{
-		EPUBData:     epubData,
-		SectionCount: len(sections),
-		EPUBSections: len(sections),
+		EPUBData:      epubData,
+		EPUBSize:      len(epubData),
+		Compression:   opts.Compression,
+		SectionCount:  len(sections),
+		EPUBSections:  len(sections),
+		Excluded:      excluded,
+		ContentBytes:  contentBytes,
+		MinifiedBytes: minifiedBytes,
+		SizeWarning:   warning,
+		SectionStats:  sectionStats(sections),
+		Volumes:       volumes,
 	}, nil
 }
 
+// generateVolumes builds the per-volume EPUBs when a volume budget is set and
+// the sections don't all fit in one. Each volume is a normal conversion of its
+// contiguous section range — its navigation only references its own sections —
+// under the title with " — Vol. k" appended.
+func generateVolumes(sections []Section, opts ConvertOptions) ([]Volume, error) {
+	if opts.VolumeBudget <= 0 {
+		return nil, nil
+	}
+	groups := splitVolumes(sections, opts.VolumeBudget)
+	if len(groups) < 2 {
+		return nil, nil
+	}
+
+	title := opts.Title
+	if title == "" {
+		title = "Markdown Document"
+	}
+
+	volumes := make([]Volume, len(groups))
+	for k, group := range groups {
+		volOpts := opts
+		volOpts.Title = fmt.Sprintf("%s — Vol. %d", title, k+1)
+		data, err := generateEPUB(group, volOpts)
+		if err != nil {
+			return nil, fmt.Errorf("generate volume %d: %w", k+1, err)
+		}
+		volumes[k] = Volume{Title: volOpts.Title, EPUBData: data, Sections: len(group)}
+	}
+	return volumes, nil
+}
+
+// splitVolumes greedily groups sections into contiguous runs of roughly
+// budget content bytes each. A single section larger than the budget goes
+// alone into its own volume.
+func splitVolumes(sections []Section, budget int) [][]Section {
+	var groups [][]Section
+	var current []Section
+	size := 0
+	for _, section := range sections {
+		n := len(section.Content)
+		if len(current) > 0 && size+n > budget {
+			groups = append(groups, current)
+			current, size = nil, 0
+		}
+		current = append(current, section)
+		size += n
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// VolumesZip packs the volume EPUBs into a single zip archive for download,
+// one entry per volume named after its title.
+func VolumesZip(volumes []Volume) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for k, vol := range volumes {
+		name := slugify(vol.Title)
+		if name == "" {
+			name = fmt.Sprintf("volume-%d", k+1)
+		}
+		f, err := w.Create(name + ".epub")
+		if err != nil {
+			return nil, fmt.Errorf("create zip entry for volume %d: %w", k+1, err)
+		}
+		if _, err := f.Write(vol.EPUBData); err != nil {
+			return nil, fmt.Errorf("write volume %d: %w", k+1, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("close volumes zip: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// sectionStats computes the per-section statistics reported alongside the
+// .epub, using the same ids and word counts the generator embeds.
+func sectionStats(sections []Section) []SectionStat {
+	ids := generateSectionIDs(sections)
+	stats := make([]SectionStat, len(sections))
+	for i, section := range sections {
+		stats[i] = SectionStat{
+			ID:    ids[i],
+			Title: section.Title,
+			Words: CountWords(section.Content),
+		}
+	}
+	return stats
+}
+
 // ValidateSectionCount checks that the number of sections parsed from the .md
 // matches the number of sections written to the .epub.
 // Returns nil if counts match, or a descriptive error if they differ.
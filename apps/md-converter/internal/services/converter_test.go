@@ -1,6 +1,10 @@
 package services
 
 import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -14,7 +18,7 @@ This is the first section.
 This is the second section.
 `
 
-	result, err := Convert([]byte(md), DevicePresets[0], "Test Document")
+	result, err := ConvertDefault([]byte(md), DevicePresets[0], "Test Document")
 	if err != nil {
 		t.Fatalf("Convert: %v", err)
 	}
@@ -32,7 +36,7 @@ This is the second section.
 
 func TestConvert_InvalidMD(t *testing.T) {
 	// Empty data
-	_, err := Convert([]byte(""), DevicePresets[0], "Test")
+	_, err := ConvertDefault([]byte(""), DevicePresets[0], "Test")
 	if err == nil {
 		t.Error("want error for empty markdown, got nil")
 	}
@@ -75,7 +79,7 @@ This is a test document.
 
 	for _, preset := range DevicePresets {
 		t.Run(preset.Name, func(t *testing.T) {
-			result, err := Convert([]byte(md), preset, "Preset Test")
+			result, err := ConvertDefault([]byte(md), preset, "Preset Test")
 			if err != nil {
 				t.Fatalf("Convert(%s): %v", preset.Name, err)
 			}
@@ -85,3 +89,167 @@ This is a test document.
 		})
 	}
 }
+
+func TestConvert_ZeroOptionsMatchDefaults(t *testing.T) {
+	md := "# One\n\nbody\n\n# Two\n\nmore\n"
+
+	viaOpts, err := Convert([]byte(md), ConvertOptions{})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	viaWrapper, err := ConvertDefault([]byte(md), DevicePresets[0], "")
+	if err != nil {
+		t.Fatalf("ConvertDefault: %v", err)
+	}
+
+	if viaOpts.SectionCount != viaWrapper.SectionCount {
+		t.Errorf("section counts differ: %d vs %d", viaOpts.SectionCount, viaWrapper.SectionCount)
+	}
+	if viaOpts.Compression != CompressionDefault {
+		t.Errorf("zero-value compression: got %s, want default", viaOpts.Compression)
+	}
+	if viaOpts.Excluded != 0 || viaOpts.SizeWarning != nil {
+		t.Error("zero-value options enabled an optional pass")
+	}
+}
+
+func TestConvert_OptionsFlowThrough(t *testing.T) {
+	md := "# One\n\nbody with a [link](https://example.com/a).\n\n# License\n\nMIT\n"
+
+	result, err := Convert([]byte(md), ConvertOptions{
+		Title:           "Options Test",
+		Compression:     CompressionStore,
+		FootnoteLinks:   true,
+		NumberSections:  true,
+		ExcludePatterns: "license",
+	})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	if result.Compression != CompressionStore {
+		t.Errorf("Compression: got %s, want store", result.Compression)
+	}
+	if result.Excluded != 1 {
+		t.Errorf("Excluded: got %d, want 1", result.Excluded)
+	}
+	if result.SectionCount != 1 {
+		t.Errorf("SectionCount: got %d, want 1", result.SectionCount)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/sec-1-one.xhtml")
+	if !contains(page, "[1]") || !contains(page, "https://example.com/a") {
+		t.Errorf("footnote links option did not flow through:\n%s", page)
+	}
+}
+
+func TestSplitVolumes(t *testing.T) {
+	sections := []Section{
+		{Content: strings.Repeat("a", 40)},
+		{Content: strings.Repeat("b", 40)},
+		{Content: strings.Repeat("c", 40)},
+		{Content: strings.Repeat("d", 40)},
+	}
+
+	groups := splitVolumes(sections, 100)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	if len(groups[0]) != 2 || len(groups[1]) != 2 {
+		t.Errorf("group sizes: got %d and %d, want 2 and 2", len(groups[0]), len(groups[1]))
+	}
+}
+
+func TestSplitVolumes_OversizedSectionGoesAlone(t *testing.T) {
+	sections := []Section{
+		{Content: strings.Repeat("a", 10)},
+		{Content: strings.Repeat("b", 500)}, // alone: larger than the budget
+		{Content: strings.Repeat("c", 10)},
+	}
+
+	groups := splitVolumes(sections, 100)
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3", len(groups))
+	}
+	if len(groups[1]) != 1 || len(groups[1][0].Content) != 500 {
+		t.Errorf("oversized section should be alone in its own volume")
+	}
+}
+
+func TestConvert_VolumeSplit(t *testing.T) {
+	var md strings.Builder
+	md.WriteString("# Wiki\n\n")
+	for i := 1; i <= 6; i++ {
+		fmt.Fprintf(&md, "## Chapter %d\n\n%s\n\n", i, strings.Repeat("Body text. ", 50))
+	}
+
+	result, err := Convert([]byte(md.String()), ConvertOptions{Title: "Wiki", VolumeBudget: 1200})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	if len(result.Volumes) < 2 {
+		t.Fatalf("got %d volumes, want at least 2", len(result.Volumes))
+	}
+	if len(result.EPUBData) == 0 {
+		t.Error("combined EPUB should still be produced")
+	}
+
+	totalSections := 0
+	for k, vol := range result.Volumes {
+		wantTitle := fmt.Sprintf("Wiki — Vol. %d", k+1)
+		if vol.Title != wantTitle {
+			t.Errorf("volume %d title: got %q, want %q", k, vol.Title, wantTitle)
+		}
+		if err := ValidateEPUB(vol.EPUBData); err != nil {
+			t.Errorf("volume %d invalid: %v", k, err)
+		}
+		totalSections += vol.Sections
+
+		// Navigation only references the volume's own sections.
+		nav := readEPUBEntry(t, vol.EPUBData, "OEBPS/nav.xhtml")
+		if got := strings.Count(nav, `.xhtml">Chapter`); got != vol.Sections && got != vol.Sections-1 {
+			// the first volume's heading section is "Wiki", not a chapter
+			t.Errorf("volume %d nav lists %d chapter entries for %d sections:\n%s", k, got, vol.Sections, nav)
+		}
+	}
+	if totalSections != result.SectionCount {
+		t.Errorf("volumes cover %d sections, want %d", totalSections, result.SectionCount)
+	}
+}
+
+func TestConvert_NoVolumeSplitWhenUnderBudget(t *testing.T) {
+	result, err := Convert([]byte("# One\n\nshort\n"), ConvertOptions{VolumeBudget: 1 << 20})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if len(result.Volumes) != 0 {
+		t.Errorf("got %d volumes, want none for a document under budget", len(result.Volumes))
+	}
+}
+
+func TestVolumesZip(t *testing.T) {
+	volumes := []Volume{
+		{Title: "Wiki — Vol. 1", EPUBData: []byte("one")},
+		{Title: "Wiki — Vol. 2", EPUBData: []byte("two")},
+	}
+
+	data, err := VolumesZip(volumes)
+	if err != nil {
+		t.Fatalf("VolumesZip: %v", err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	want := []string{"wiki-vol-1.epub", "wiki-vol-2.epub"}
+	if len(r.File) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(r.File), len(want))
+	}
+	for i, f := range r.File {
+		if f.Name != want[i] {
+			t.Errorf("entry %d: got %q, want %q", i, f.Name, want[i])
+		}
+	}
+}
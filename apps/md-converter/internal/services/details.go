@@ -0,0 +1,74 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GitHub READMEs lean on <details> collapsible blocks, but most reading
+// systems have no disclosure widget: many show only the summary with the body
+// permanently unreachable. UnwrapDetails runs before sanitization strips
+// anything, so the elements are still intact when it rewrites them.
+var (
+	reDetailsOpen = regexp.MustCompile(`(?i)<details[^>]*>`)
+	reDetailsTag  = regexp.MustCompile(`(?i)</?details[^>]*>`)
+	reSummaryTag  = regexp.MustCompile(`(?is)<summary[^>]*>(.*?)</summary>`)
+)
+
+// UnwrapDetails flattens every <details> block into always-visible content:
+// the <summary> becomes a bold lead-in line and the body follows. A block
+// without a summary keeps just its body. With drop set, details blocks are
+// removed entirely instead — changelog dumps inside them can dwarf the rest
+// of the document. Nested blocks are handled innermost-first. The input slice
+// is not modified.
+func UnwrapDetails(sections []Section, drop bool) []Section {
+	result := make([]Section, len(sections))
+	for i, section := range sections {
+		section.Content = unwrapDetails(section.Content, drop)
+		result[i] = section
+	}
+	return result
+}
+
+// unwrapDetails rewrites the details blocks of one content string.
+func unwrapDetails(content string, drop bool) string {
+	for {
+		open := reDetailsOpen.FindStringIndex(content)
+		if open == nil {
+			return content
+		}
+
+		// Find the matching close, counting nested opens.
+		depth := 1
+		innerEnd, blockEnd := -1, -1
+		for _, m := range reDetailsTag.FindAllStringIndex(content[open[1]:], -1) {
+			if content[open[1]+m[0]+1] == '/' {
+				depth--
+			} else {
+				depth++
+			}
+			if depth == 0 {
+				innerEnd = open[1] + m[0]
+				blockEnd = open[1] + m[1]
+				break
+			}
+		}
+		if blockEnd < 0 {
+			// Unclosed block: drop the stray open tag and carry on.
+			content = content[:open[0]] + content[open[1]:]
+			continue
+		}
+
+		repl := ""
+		if !drop {
+			inner := unwrapDetails(content[open[1]:innerEnd], false)
+			if m := reSummaryTag.FindStringSubmatch(inner); m != nil {
+				inner = strings.Replace(inner, m[0], "", 1)
+				repl = "<p><strong>" + strings.TrimSpace(m[1]) + "</strong></p>\n" + strings.TrimSpace(inner)
+			} else {
+				repl = strings.TrimSpace(inner)
+			}
+		}
+		content = content[:open[0]] + repl + content[blockEnd:]
+	}
+}
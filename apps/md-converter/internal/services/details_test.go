@@ -0,0 +1,92 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnwrapDetails_SummaryBecomesLeadIn(t *testing.T) {
+	sections := UnwrapDetails([]Section{{
+		Content: `<details><summary>Install steps</summary><p>run make</p></details>`,
+	}}, false)
+
+	want := "<p><strong>Install steps</strong></p>\n<p>run make</p>"
+	if got := sections[0].Content; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestUnwrapDetails_NoSummary(t *testing.T) {
+	sections := UnwrapDetails([]Section{{
+		Content: `before <details><p>just a body</p></details> after`,
+	}}, false)
+
+	want := "before <p>just a body</p> after"
+	if got := sections[0].Content; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestUnwrapDetails_Nested(t *testing.T) {
+	sections := UnwrapDetails([]Section{{
+		Content: `<details><summary>Outer</summary><details open><summary>Inner</summary><p>deep</p></details></details>`,
+	}}, false)
+
+	got := sections[0].Content
+	for _, want := range []string{
+		"<p><strong>Outer</strong></p>",
+		"<p><strong>Inner</strong></p>",
+		"<p>deep</p>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in %q", want, got)
+		}
+	}
+	if strings.Contains(strings.ToLower(got), "<details") {
+		t.Errorf("details tag survived unwrapping: %q", got)
+	}
+}
+
+func TestUnwrapDetails_Drop(t *testing.T) {
+	sections := UnwrapDetails([]Section{{
+		Content: `<p>keep</p><details><summary>Changelog</summary><p>5000 lines</p></details><p>also keep</p>`,
+	}}, true)
+
+	want := "<p>keep</p><p>also keep</p>"
+	if got := sections[0].Content; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestUnwrapDetails_Unclosed(t *testing.T) {
+	sections := UnwrapDetails([]Section{{
+		Content: `<details><summary>Oops</summary><p>no close tag</p>`,
+	}}, false)
+
+	got := sections[0].Content
+	if strings.Contains(strings.ToLower(got), "<details") {
+		t.Errorf("stray open tag survived: %q", got)
+	}
+	if !strings.Contains(got, "no close tag") {
+		t.Errorf("body lost: %q", got)
+	}
+}
+
+func TestConvert_DetailsUnwrapped(t *testing.T) {
+	md := "# Readme\n\n<details>\n<summary>More info</summary>\n\ncollapsible body text\n\n</details>\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Readme", UnsafeHTML: true})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/readme.xhtml")
+	if !strings.Contains(page, "<strong>More info</strong>") {
+		t.Errorf("summary lead-in missing:\n%s", page)
+	}
+	if !strings.Contains(page, "collapsible body text") {
+		t.Errorf("details body missing:\n%s", page)
+	}
+	if strings.Contains(page, "<details") {
+		t.Errorf("details tag reached the EPUB:\n%s", page)
+	}
+}
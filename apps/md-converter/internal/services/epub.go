@@ -3,107 +3,333 @@ package services
 import (
 	"archive/zip"
 	"bytes"
+	"compress/flate"
+	"crypto/sha256"
 	"fmt"
 	"html"
+	"io"
+	"math"
 	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 )
 
 // DevicePreset holds e-ink device display settings.
 type DevicePreset struct {
-	Name     string
-	Width    int
-	Height   int
-	FontSize int // in pt
-	Margin   int // in px
+	Name         string
+	Width        int
+	Height       int
+	FontSize     int     // in pt
+	Margin       int     // in px
+	FontFamily   string  // CSS font-family stack; empty falls back to FontFamilySerif
+	HeadingScale float64 // h1 size in em; h2–h6 step down evenly to 1.0em; 0 falls back to HeadingScaleNormal
 }
 
-// DevicePresets is the list of supported e-ink device targets.
+// Font family stacks selectable in the UI. An empty string (device default)
+// keeps the preset's own FontFamily.
+const (
+	FontFamilySerif = `Georgia, "Times New Roman", serif`
+	FontFamilySans  = `"Helvetica Neue", "Noto Sans", Arial, sans-serif`
+	FontFamilyMono  = `"Courier New", Courier, monospace`
+	FontFamilyCJK   = `"Hiragino Mincho ProN", "Noto Serif CJK JP", "Yu Mincho", serif`
+)
+
+// Heading scales selectable in the UI: the h1 size in em, with h2–h6 stepping
+// down evenly to 1.0em. Compact keeps multi-word headings on one line on
+// low-resolution screens.
+const (
+	HeadingScaleCompact = 1.3
+	HeadingScaleNormal  = 1.5
+	HeadingScaleLarge   = 1.7
+)
+
+// DevicePresets is the list of supported e-ink device targets. The narrow
+// Xtreink screen defaults to the compact heading scale so h1s don't wrap onto
+// several lines.
 var DevicePresets = []DevicePreset{
-	{Name: "Xtreink X4", Width: 480, Height: 800, FontSize: 12, Margin: 16},
-	{Name: "Onyx Boox Page", Width: 1264, Height: 1680, FontSize: 16, Margin: 24},
-	{Name: "Kindle", Width: 1264, Height: 1680, FontSize: 16, Margin: 24},
-	{Name: "Kobo Clara Reader", Width: 1072, Height: 1448, FontSize: 14, Margin: 20},
+	{Name: "Xtreink X4", Width: 480, Height: 800, FontSize: 12, Margin: 16, FontFamily: FontFamilySerif, HeadingScale: HeadingScaleCompact},
+	{Name: "Onyx Boox Page", Width: 1264, Height: 1680, FontSize: 16, Margin: 24, FontFamily: FontFamilySans, HeadingScale: HeadingScaleNormal},
+	{Name: "Kindle", Width: 1264, Height: 1680, FontSize: 16, Margin: 24, FontFamily: FontFamilySerif, HeadingScale: HeadingScaleNormal},
+	{Name: "Kobo Clara Reader", Width: 1072, Height: 1448, FontSize: 14, Margin: 20, FontFamily: FontFamilySerif, HeadingScale: HeadingScaleNormal},
+}
+
+// CompressionMode selects how EPUB zip entries are compressed.
+type CompressionMode int
+
+const (
+	// CompressionDefault uses the standard Deflate level.
+	CompressionDefault CompressionMode = iota
+	// CompressionFast uses flate.BestSpeed, trading size for conversion speed
+	// (noticeable on very large documents in WASM).
+	CompressionFast
+	// CompressionStore writes entries uncompressed.
+	CompressionStore
+)
+
+// String returns a short human-readable label for the mode.
+func (m CompressionMode) String() string {
+	switch m {
+	case CompressionFast:
+		return "fast"
+	case CompressionStore:
+		return "store"
+	default:
+		return "default"
+	}
 }
 
 // GenerateEPUB produces an EPUB 3 file in memory containing one page per
 // section. Returns the raw .epub bytes.
 func GenerateEPUB(sections []Section, preset DevicePreset, title string) ([]byte, error) {
+	return GenerateEPUBWithCompression(sections, preset, title, CompressionDefault)
+}
+
+// GenerateEPUBWithCompression is GenerateEPUB with an explicit compression
+// mode. The mimetype entry is always stored uncompressed as the EPUB spec
+// requires, regardless of mode.
+func GenerateEPUBWithCompression(sections []Section, preset DevicePreset, title string, mode CompressionMode) ([]byte, error) {
+	return GenerateEPUBWithLayout(sections, preset, title, mode, false)
+}
+
+// GenerateEPUBWithLayout is GenerateEPUBWithCompression with an optional
+// fixed-layout (pre-paginated) mode. Fixed layout pins every page to the
+// preset's exact pixel dimensions so downstream XTC rasterization is
+// predictable: the OPF gets rendition:layout pre-paginated, each page gets a
+// viewport meta at width×height, and the .page CSS is constrained to match.
+func GenerateEPUBWithLayout(sections []Section, preset DevicePreset, title string, mode CompressionMode, fixedLayout bool) ([]byte, error) {
+	return GenerateEPUBWithVerticalText(sections, preset, title, mode, fixedLayout, false)
+}
+
+// GenerateEPUBWithVerticalText is GenerateEPUBWithLayout with an optional
+// vertical writing mode for CJK documents: the body gets writing-mode
+// vertical-rl with a CJK font stack, ruby/rt elements are styled so furigana
+// renders, and the language metadata is set to Japanese.
+func GenerateEPUBWithVerticalText(sections []Section, preset DevicePreset, title string, mode CompressionMode, fixedLayout, verticalCJK bool) ([]byte, error) {
+	return generateEPUB(sections, ConvertOptions{
+		Preset:      preset,
+		Title:       title,
+		Compression: mode,
+		FixedLayout: fixedLayout,
+		VerticalCJK: verticalCJK,
+	})
+}
+
+// generateEPUB is the EPUB writer behind the exported wrappers, driven by the
+// options the conversion pipeline already carries.
+func generateEPUB(sections []Section, opts ConvertOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := GenerateEPUBTo(&buf, sections, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateEPUBTo streams the EPUB to out instead of buffering it in memory,
+// for callers like an HTTP handler that can write the archive as it is built.
+// It performs no post-parse pipeline passes; run the sections through Convert
+// when those are wanted.
+func GenerateEPUBTo(out io.Writer, sections []Section, opts ConvertOptions) error {
+	opts = opts.withDefaults()
+	preset := opts.Preset
+	mode := opts.Compression
+	fixedLayout := opts.FixedLayout
+	verticalCJK := opts.VerticalCJK
+
+	title := opts.Title
 	if title == "" {
 		title = "Markdown Document"
 	}
 
-	var buf bytes.Buffer
-	w := zip.NewWriter(&buf)
+	viewport := ""
+	if fixedLayout {
+		viewport = fmt.Sprintf("width=%d, height=%d", preset.Width, preset.Height)
+	}
+
+	lang := opts.Language
+	if lang == "" {
+		lang = "en"
+		if verticalCJK {
+			lang = "ja"
+		}
+	}
+
+	w := zip.NewWriter(out)
+	if mode == CompressionFast {
+		w.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, flate.BestSpeed)
+		})
+	}
 
 	// 1. mimetype (must be the first file, uncompressed)
 	if err := addUncompressed(w, "mimetype", "application/epub+zip"); err != nil {
-		return nil, err
+		return err
 	}
 
 	// 2. META-INF/container.xml
-	if err := addFile(w, "META-INF/container.xml", containerXML()); err != nil {
-		return nil, err
+	if err := addFile(w, "META-INF/container.xml", containerXML(), mode); err != nil {
+		return err
 	}
 
 	// 3. Styles
-	css := generateCSS(preset)
-	if err := addFile(w, "OEBPS/styles.css", css); err != nil {
-		return nil, err
+	if err := addFile(w, "OEBPS/styles.css", GenerateCSS(opts), mode); err != nil {
+		return err
 	}
 
 	// 4. Section pages
 	manifestItems := make([]string, 0, len(sections)+2)
 	spineItems := make([]string, 0, len(sections)+1)
 
-	// nav page is in spine first
+	sectionIDs := generateSectionIDs(sections)
+	sections = dedupeHeadingIDs(sections)
+	sections = resolveFragmentLinks(sections, sectionIDs)
+
+	// Optional cover page, first in the spine so "go to beginning" readers
+	// have something before the TOC.
+	coverHref := ""
+	if opts.Cover {
+		coverID := "cover"
+		for _, id := range sectionIDs {
+			if id == coverID {
+				coverID = "cover-page" // a section titled "Cover" owns the slug
+				break
+			}
+		}
+		if err := addFile(w, "OEBPS/"+coverID+".xhtml", generateCover(title, lang), mode); err != nil {
+			return err
+		}
+		manifestItems = append(manifestItems,
+			fmt.Sprintf(`<item id=%q href=%q media-type="application/xhtml+xml"/>`, coverID, coverID+".xhtml"),
+		)
+		spineItems = append(spineItems,
+			fmt.Sprintf(`<itemref idref=%q/>`, coverID),
+		)
+		coverHref = coverID + ".xhtml"
+	}
+
+	// nav page is in spine before the sections
 	manifestItems = append(manifestItems,
 		`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>`,
 		`<item id="css" href="styles.css" media-type="text/css"/>`,
 	)
 	spineItems = append(spineItems, `<itemref idref="nav"/>`)
 
-	for i, section := range sections {
-		n := i + 1
-		sectionID := fmt.Sprintf("section_%04d", n)
-		sectionFile := fmt.Sprintf("OEBPS/%s.xhtml", sectionID)
+	// Pages are rendered concurrently in bounded batches — sanitizing and
+	// templating dominate conversion time on large documents — and each batch
+	// is written to the zip and released before the next one is rendered, so
+	// peak memory holds one worker's worth of rendered pages rather than the
+	// whole document (on single-threaded WASM that is exactly one page).
+	// zip.Writer is not safe for concurrent use, so writes stay here.
+	// Optional running header/footer lines, built up front so the footer can
+	// number against the full section count.
+	var chromes []pageChrome
+	if opts.RunningHeader || opts.RunningFooter {
+		chromes = make([]pageChrome, len(sections))
+		for i, section := range sections {
+			if opts.RunningHeader {
+				chromes[i].header = title + " · " + section.Title
+			}
+			if opts.RunningFooter {
+				chromes[i].footer = fmt.Sprintf("Section %d of %d", i+1, len(sections))
+			}
+		}
+	}
 
-		page, err := generateSectionPage(section.Title, section.Content, title)
+	batch := max(runtime.GOMAXPROCS(0), 1)
+	for start := 0; start < len(sections); start += batch {
+		end := min(start+batch, len(sections))
+		var batchChromes []pageChrome
+		if chromes != nil {
+			batchChromes = chromes[start:end]
+		}
+		pages, err := renderSectionPages(sections[start:end], title, viewport, lang, opts.TOCBackLinks, batchChromes)
 		if err != nil {
-			return nil, fmt.Errorf("section %d page: %w", n, err)
+			return fmt.Errorf("sections %d-%d: %w", start+1, end, err)
 		}
 
-		if err := addFile(w, sectionFile, page); err != nil {
-			return nil, err
+		for i := start; i < end; i++ {
+			sectionID := sectionIDs[i]
+			sectionFile := fmt.Sprintf("OEBPS/%s.xhtml", sectionID)
+
+			if err := addFile(w, sectionFile, pages[i-start], mode); err != nil {
+				return err
+			}
+			pages[i-start] = "" // release the page before rendering the next batch
+
+			manifestItems = append(manifestItems,
+				fmt.Sprintf(`<item id=%q href=%q media-type="application/xhtml+xml"/>`, sectionID, sectionID+".xhtml"),
+			)
+			if sections[i].Appendix {
+				// Appendices stay in the spine at their document position but
+				// are skipped by the linear reading flow.
+				spineItems = append(spineItems,
+					fmt.Sprintf(`<itemref idref=%q linear="no"/>`, sectionID),
+				)
+			} else {
+				spineItems = append(spineItems,
+					fmt.Sprintf(`<itemref idref=%q/>`, sectionID),
+				)
+			}
 		}
+	}
 
+	// 5. Optional colophon page, last in the spine. It is a generated page,
+	// not a parsed section, so it stays out of sectionIDs and the counts.
+	colophonHref := ""
+	if opts.Colophon {
+		colophonID := "colophon"
+		for _, id := range sectionIDs {
+			if id == colophonID {
+				colophonID = "colophon-page" // a section titled "Colophon" owns the slug
+				break
+			}
+		}
+		page, err := generateSectionPage(Section{Title: colophonTitle, Content: generateColophon(opts)}, title, viewport, lang, opts.TOCBackLinks, pageChrome{})
+		if err != nil {
+			return fmt.Errorf("colophon page: %w", err)
+		}
+		if err := addFile(w, "OEBPS/"+colophonID+".xhtml", page, mode); err != nil {
+			return err
+		}
 		manifestItems = append(manifestItems,
-			fmt.Sprintf(`<item id=%q href=%q media-type="application/xhtml+xml"/>`, sectionID, sectionID+".xhtml"),
+			fmt.Sprintf(`<item id=%q href=%q media-type="application/xhtml+xml"/>`, colophonID, colophonID+".xhtml"),
 		)
 		spineItems = append(spineItems,
-			fmt.Sprintf(`<itemref idref=%q/>`, sectionID),
+			fmt.Sprintf(`<itemref idref=%q/>`, colophonID),
 		)
+		colophonHref = colophonID + ".xhtml"
 	}
 
-	// 5. Navigation document
-	nav := generateNav(sections, title)
-	if err := addFile(w, "OEBPS/nav.xhtml", nav); err != nil {
-		return nil, err
+	// 6. Navigation document
+	nav := generateNav(sections, sectionIDs, title, opts, colophonHref, coverHref)
+	if err := addFile(w, "OEBPS/nav.xhtml", nav, mode); err != nil {
+		return err
 	}
 
-	// 6. Package document (content.opf)
-	opf := generateOPF(title, manifestItems, spineItems)
-	if err := addFile(w, "OEBPS/content.opf", opf); err != nil {
-		return nil, err
+	// 7. Package document (content.opf)
+	identifier := "md-converter-" + contentFingerprint(sections, opts)
+	published := ""
+	if !opts.Published.IsZero() {
+		published = opts.Published.UTC().Format("2006-01-02")
+	}
+	opf := generateOPF(title, identifier, modifiedTimestamp(opts), published, manifestItems, spineItems, fixedLayout, lang, accessibilityMetadata(sections))
+	if err := addFile(w, "OEBPS/content.opf", opf, mode); err != nil {
+		return err
 	}
 
 	if err := w.Close(); err != nil {
-		return nil, fmt.Errorf("close epub zip: %w", err)
+		return fmt.Errorf("close epub zip: %w", err)
 	}
 
-	return buf.Bytes(), nil
+	return nil
+}
+
+// formatFloat renders a spacing value compactly, without trailing zeros.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
 }
 
 // addUncompressed adds a file to the ZIP with Store (no compression).
@@ -121,8 +347,13 @@ func addUncompressed(w *zip.Writer, name, content string) error {
 	return err
 }
 
-// addFile adds a file to the ZIP with default (Deflate) compression.
-func addFile(w *zip.Writer, name, content string) error {
+// addFile adds a file to the ZIP using the given compression mode. Entry
+// timestamps are deliberately never set — a zero FileHeader.Modified encodes
+// the constant MS-DOS epoch — so the archive bytes depend only on content.
+func addFile(w *zip.Writer, name, content string, mode CompressionMode) error {
+	if mode == CompressionStore {
+		return addUncompressed(w, name, content)
+	}
 	f, err := w.Create(name)
 	if err != nil {
 		return fmt.Errorf("create %s: %w", name, err)
@@ -140,15 +371,60 @@ func containerXML() string {
 </container>`
 }
 
-func generateOPF(title string, manifestItems, spineItems []string) string {
-	date := time.Now().UTC().Format("2006-01-02")
+// contentFingerprint returns a short stable SHA-256 fingerprint of the
+// sections plus the settings that shape the output. Identical input converted
+// with identical settings always produces the same fingerprint, so it serves
+// as the package identifier without breaking dedup-based sync tools the way a
+// timestamp would.
+func contentFingerprint(sections []Section, opts ConvertOptions) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%+v\n", opts)
+	for _, section := range sections {
+		fmt.Fprintf(h, "%d\x00%s\x00%s\x00", section.Level, section.Title, section.Content)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
+// reproducibleEpoch is the dcterms:modified fallback in reproducible mode
+// when no real modification time is known.
+var reproducibleEpoch = time.Unix(0, 0).UTC()
+
+// modifiedTimestamp resolves the dcterms:modified value: an explicit or
+// front-matter-derived Modified time wins, then the fixed epoch when
+// Reproducible is set, and only otherwise the wall clock.
+func modifiedTimestamp(opts ConvertOptions) string {
+	modified := opts.Modified.UTC()
+	if opts.Modified.IsZero() {
+		if opts.Reproducible {
+			modified = reproducibleEpoch
+		} else {
+			modified = time.Now().UTC()
+		}
+	}
+	return modified.Format("2006-01-02T15:04:05Z")
+}
+
+func generateOPF(title, identifier, modified, published string, manifestItems, spineItems []string, fixedLayout bool, lang, accessibilityMeta string) string {
+	dateElem := ""
+	if published != "" {
+		dateElem = fmt.Sprintf("\n    <dc:date>%s</dc:date>", published)
+	}
+	prefixAttr := ""
+	renditionMeta := ""
+	if fixedLayout {
+		prefixAttr = ` prefix="rendition: http://www.idpf.org/vocab/rendition/#"`
+		renditionMeta = `
+    <meta property="rendition:layout">pre-paginated</meta>
+    <meta property="rendition:orientation">portrait</meta>
+    <meta property="rendition:spread">none</meta>`
+	}
 	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
-<package version="3.0" xmlns="http://www.idpf.org/2007/opf" unique-identifier="uid">
+<package version="3.0" xmlns="http://www.idpf.org/2007/opf" unique-identifier="uid"%s>
   <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
     <dc:title>%s</dc:title>
-    <dc:language>en</dc:language>
-    <dc:identifier id="uid">md-converter-%s</dc:identifier>
-    <meta property="dcterms:modified">%sT00:00:00Z</meta>
+    <dc:language>%s</dc:language>
+    <dc:identifier id="uid">%s</dc:identifier>%s
+    <meta property="dcterms:modified">%s</meta>%s%s
   </metadata>
   <manifest>
     %s
@@ -157,20 +433,290 @@ func generateOPF(title string, manifestItems, spineItems []string) string {
     %s
   </spine>
 </package>`,
+		prefixAttr,
 		html.EscapeString(title),
-		date,
-		date,
+		lang,
+		identifier,
+		dateElem,
+		modified,
+		renditionMeta,
+		accessibilityMeta,
 		strings.Join(manifestItems, "\n    "),
 		strings.Join(spineItems, "\n    "),
 	)
 }
 
-func generateNav(sections []Section, title string) string {
+// reImgTag and reAltAttr detect images and their alt text for the
+// accessibility metadata.
+var (
+	reImgTag  = regexp.MustCompile(`(?i)<img\b[^>]*>`)
+	reAltAttr = regexp.MustCompile(`(?i)\balt\s*=\s*("[^"]+"|'[^']+')`)
+)
+
+// accessibilityMetadata returns the schema.org accessibility metas for the
+// OPF. A text-only book claims purely textual access; when images are present
+// the visual access mode is added, and the alternativeText feature is claimed
+// only when every image carries non-empty alt text.
+func accessibilityMetadata(sections []Section) string {
+	hasImages := false
+	allAlt := true
+	for _, section := range sections {
+		for _, img := range reImgTag.FindAllString(section.Content, -1) {
+			hasImages = true
+			if !reAltAttr.MatchString(img) {
+				allAlt = false
+			}
+		}
+	}
+
+	features := []string{"tableOfContents"}
+	modes := []string{"textual"}
+	if hasImages {
+		modes = append(modes, "visual")
+		if allAlt {
+			features = append(features, "alternativeText")
+		}
+	}
+
+	var sb strings.Builder
+	for _, m := range modes {
+		sb.WriteString(fmt.Sprintf("\n    <meta property=\"schema:accessMode\">%s</meta>", m))
+	}
+	sb.WriteString("\n    <meta property=\"schema:accessModeSufficient\">textual</meta>")
+	for _, f := range features {
+		sb.WriteString(fmt.Sprintf("\n    <meta property=\"schema:accessibilityFeature\">%s</meta>", f))
+	}
+	return sb.String()
+}
+
+// reElemID and reFragHref drive the cross-file fragment link resolution.
+var (
+	reElemID   = regexp.MustCompile(`\bid="([^"]+)"`)
+	reFragHref = regexp.MustCompile(`href="#([^"]+)"`)
+)
+
+// resolveFragmentLinks rewrites same-document fragment links (footnote
+// references and back-links, heading anchors) to point at the section file
+// that contains the target id. Each section becomes its own XHTML file, so a
+// bare "#id" only resolves when the target happens to share the file; links
+// whose target is in the same section, or nowhere, are left alone. The input
+// slice is not modified.
+func resolveFragmentLinks(sections []Section, sectionIDs []string) []Section {
+	owner := make(map[string]int)
+	for i, section := range sections {
+		// The section's own heading anchor lives on its page container.
+		if section.HeadingID != "" {
+			if _, ok := owner[section.HeadingID]; !ok {
+				owner[section.HeadingID] = i
+			}
+		}
+		for _, m := range reElemID.FindAllStringSubmatch(section.Content, -1) {
+			if _, ok := owner[m[1]]; !ok {
+				owner[m[1]] = i
+			}
+		}
+	}
+
+	result := make([]Section, len(sections))
+	for i, section := range sections {
+		section.Content = reFragHref.ReplaceAllStringFunc(section.Content, func(link string) string {
+			frag := link[len(`href="#`) : len(link)-1]
+			j, ok := owner[frag]
+			if !ok || j == i {
+				return link
+			}
+			return fmt.Sprintf(`href="%s.xhtml#%s"`, sectionIDs[j], frag)
+		})
+		result[i] = section
+	}
+	return result
+}
+
+// dedupeHeadingIDs keeps each heading anchor id on the first page that claims
+// it and clears the rest, so the continuation pages SplitLargeSections copies
+// a section into can't duplicate an id across the book. The input slice is
+// not modified.
+func dedupeHeadingIDs(sections []Section) []Section {
+	result := make([]Section, len(sections))
+	seen := make(map[string]bool)
+	for i, section := range sections {
+		if section.HeadingID != "" {
+			if seen[section.HeadingID] {
+				section.HeadingID = ""
+			} else {
+				seen[section.HeadingID] = true
+			}
+		}
+		result[i] = section
+	}
+	return result
+}
+
+// generateSectionIDs returns a stable, collision-free identifier for every
+// section, used both as the section file name and the manifest/spine id.
+// Identifiers are slugs of the section titles so they survive re-conversion
+// after sections are excluded; duplicates get a numeric suffix and untitled
+// sections fall back to their position.
+func generateSectionIDs(sections []Section) []string {
+	ids := make([]string, len(sections))
+	taken := make(map[string]bool)
+	nextSuffix := make(map[string]int)
+	for i, section := range sections {
+		slug := slugify(section.Title)
+		if slug == "" {
+			slug = fmt.Sprintf("section-%d", i+1)
+		}
+		candidate := slug
+		n := nextSuffix[slug]
+		if n < 2 {
+			n = 2 // first duplicate becomes "<slug>-2"
+		}
+		for ; taken[candidate]; n++ {
+			candidate = fmt.Sprintf("%s-%d", slug, n)
+			nextSuffix[slug] = n + 1
+		}
+		taken[candidate] = true
+		ids[i] = candidate
+	}
+	return ids
+}
+
+// slugify lowercases the title and collapses every run of non-alphanumeric
+// characters into a single hyphen. A leading "sec-" is prepended when the
+// result would not start with a letter, keeping ids valid XML names.
+func slugify(title string) string {
 	var sb strings.Builder
+	lastHyphen := true // suppress leading hyphens
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			sb.WriteRune(r)
+			lastHyphen = false
+		default:
+			if !lastHyphen {
+				sb.WriteByte('-')
+				lastHyphen = true
+			}
+		}
+	}
+	slug := strings.TrimSuffix(sb.String(), "-")
+	if slug == "" {
+		return ""
+	}
+	if slug[0] < 'a' || slug[0] > 'z' {
+		slug = "sec-" + slug
+	}
+	return slug
+}
+
+// navLabels returns the display label for each section in the navigation
+// document. Duplicate titles (e.g. twenty "Fixed" headings in a changelog)
+// get the nearest ancestor heading appended in parentheses; labels that are
+// still duplicated after that get an ordinal. Page headings stay as authored
+// — only nav entries are disambiguated. The result is deterministic, so
+// repeated conversions give identical output.
+func navLabels(sections []Section) []string {
+	labels := make([]string, len(sections))
+
+	titleCount := make(map[string]int)
+	for _, section := range sections {
+		titleCount[section.Title]++
+	}
+
 	for i, section := range sections {
-		n := i + 1
-		sb.WriteString(fmt.Sprintf(`      <li><a href="section_%04d.xhtml">%s</a></li>`+"\n", n, html.EscapeString(section.Title)))
+		label := section.Title
+		if titleCount[section.Title] > 1 {
+			if ancestor := nearestAncestorTitle(sections, i); ancestor != "" {
+				label = fmt.Sprintf("%s (%s)", section.Title, ancestor)
+			}
+		}
+		labels[i] = label
+	}
+
+	// Ordinal fallback for labels that are still duplicated (same title under
+	// the same ancestor, or no ancestor at all).
+	labelCount := make(map[string]int)
+	for _, label := range labels {
+		labelCount[label]++
+	}
+	occurrence := make(map[string]int)
+	for i, label := range labels {
+		if labelCount[label] > 1 {
+			occurrence[label]++
+			labels[i] = fmt.Sprintf("%s (%d)", label, occurrence[label])
+		}
+	}
+
+	return labels
+}
+
+// nearestAncestorTitle returns the title of the closest preceding section
+// with a shallower heading level, or "" when there is none.
+func nearestAncestorTitle(sections []Section, i int) string {
+	for j := i - 1; j >= 0; j-- {
+		if sections[j].Level < sections[i].Level {
+			return sections[j].Title
+		}
+	}
+	return ""
+}
+
+func generateNav(sections []Section, sectionIDs []string, title string, opts ConvertOptions, colophonHref, coverHref string) string {
+	labels := navLabels(sections)
+	entry := func(i int) string {
+		e := html.EscapeString(labels[i])
+		if opts.ShowWordCounts {
+			e += fmt.Sprintf(` <span class="word-count">(%s words)</span>`, formatThousands(CountWords(sections[i].Content)))
+		}
+		return e
+	}
+
+	// Sections deeper than the TOC depth still exist as pages; they just
+	// aren't listed, so a six-level document doesn't produce an unnavigable
+	// TOC on a six-inch screen.
+	listed := func(i int) bool {
+		return sections[i].Level <= opts.TOCDepth
+	}
+
+	var sb strings.Builder
+	for i := range sections {
+		if sections[i].Appendix || !listed(i) {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf(`      <li><a href="%s.xhtml">%s</a></li>`+"\n", sectionIDs[i], entry(i)))
+	}
+
+	// Appendix sections are grouped under their own branch after the main
+	// reading flow.
+	var appendices strings.Builder
+	for i := range sections {
+		if !sections[i].Appendix || !listed(i) {
+			continue
+		}
+		appendices.WriteString(fmt.Sprintf(`          <li><a href="%s.xhtml">%s</a></li>`+"\n", sectionIDs[i], entry(i)))
+	}
+	if appendices.Len() > 0 {
+		sb.WriteString("      <li><span>Appendices</span>\n        <ol>\n")
+		sb.WriteString(appendices.String())
+		sb.WriteString("        </ol>\n      </li>\n")
+	}
+
+	if colophonHref != "" {
+		sb.WriteString(fmt.Sprintf(`      <li><a href=%q>%s</a></li>`+"\n", colophonHref, colophonTitle))
 	}
+
+	// Landmarks entries track the pages actually generated: the cover only
+	// when enabled, the nav page itself as the TOC, and the first content
+	// section as bodymatter so "go to beginning" skips the front matter.
+	var lm strings.Builder
+	if coverHref != "" {
+		lm.WriteString(fmt.Sprintf(`      <li><a epub:type="cover" href=%q>Cover</a></li>`+"\n", coverHref))
+	}
+	lm.WriteString(`      <li><a epub:type="toc" href="nav.xhtml">Table of Contents</a></li>` + "\n")
+	if len(sectionIDs) > 0 {
+		lm.WriteString(fmt.Sprintf(`      <li><a epub:type="bodymatter" href="%s.xhtml">Begin Reading</a></li>`+"\n", sectionIDs[0]))
+	}
+
 	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE html>
 <html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
@@ -179,6 +725,11 @@ func generateNav(sections []Section, title string) string {
   <nav epub:type="toc" id="toc">
     <h1>%s</h1>
     <ol>
+%s    </ol>
+  </nav>
+  <nav epub:type="landmarks" id="landmarks" hidden="hidden">
+    <h1>Landmarks</h1>
+    <ol>
 %s    </ol>
   </nav>
 </body>
@@ -186,41 +737,133 @@ func generateNav(sections []Section, title string) string {
 		html.EscapeString(title),
 		html.EscapeString(title),
 		sb.String(),
+		lm.String(),
 	)
 }
 
+// generateCover renders the optional typographic cover page: just the book
+// title, positioned by the .cover stylesheet rules.
+func generateCover(title, lang string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang=%q>
+<head>
+  <title>%s</title>
+  <link rel="stylesheet" type="text/css" href="styles.css"/>
+</head>
+<body>
+  <div class="cover">
+    <h1>%s</h1>
+  </div>
+</body>
+</html>`, lang, html.EscapeString(title), html.EscapeString(title))
+}
+
 // sectionPageTmpl is the XHTML template for a single section page.
 var sectionPageTmpl = template.Must(template.New("section").Parse(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE html>
-<html xmlns="http://www.w3.org/1999/xhtml">
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="{{.Lang}}">
 <head>
   <title>{{.Title}} — {{.BookTitle}}</title>
-  <link rel="stylesheet" type="text/css" href="styles.css"/>
+{{if .Viewport}}  <meta name="viewport" content="{{.Viewport}}"/>
+{{end}}  <link rel="stylesheet" type="text/css" href="styles.css"/>
 </head>
 <body>
-  <div class="page">
-    <div class="section-title">{{.Title}}</div>
+  <div class="page"{{if .PageID}} id="{{.PageID}}"{{end}}>
+{{if .Header}}    <div class="page-header">{{.Header}}</div>
+{{end}}    <div class="section-title">{{.Title}}</div>
     <div class="section-content">{{.Content}}</div>
-  </div>
+{{if .TOCLink}}    <div class="toc-link"><a href="nav.xhtml">↑ Contents</a></div>
+{{end}}{{if .Footer}}    <div class="page-footer">{{.Footer}}</div>
+{{end}}  </div>
 </body>
 </html>`))
 
+// sectionPageData feeds sectionPageTmpl. The template is text/template, so
+// Title and BookTitle must already be XML-escaped; Content stays raw because
+// it is the sanitized HTML body.
 type sectionPageData struct {
-	Title     string
-	BookTitle string
+	Title     string // pre-escaped
+	BookTitle string // pre-escaped
 	Content   string // may contain HTML
+	Viewport  string // viewport meta content for fixed layout; empty omits the tag
+	Lang      string // xml:lang for the page
+	PageID    string // heading anchor id carried onto the page container; empty omits the attribute
+	TOCLink   bool   // render the "↑ Contents" link back to nav.xhtml (TOCBackLinks option)
+	Header    string // pre-escaped running header line; empty omits the div
+	Footer    string // pre-escaped running footer line; empty omits the div
+}
+
+// pageChrome is the optional running header/footer text for one section page
+// (RunningHeader/RunningFooter options). It exists only in the rendered XHTML,
+// never in Section.Content, so word counts and fingerprints ignore it.
+type pageChrome struct {
+	header string // "book title · section title"
+	footer string // "Section n of N"
 }
 
-func generateSectionPage(title string, content string, bookTitle string) (string, error) {
-	// Strip or sanitize HTML tags to produce clean readable text.
-	// We keep basic formatting but remove scripts/styles.
-	safeContent := sanitizeHTML(content)
+// renderSectionPages renders every section's XHTML page through a bounded
+// worker pool and returns the results in section order. Rendering is pure
+// string work, so it parallelizes cleanly; on single-threaded targets (WASM)
+// the pool degrades to the serial path. The output is byte-identical to
+// calling generateSectionPage in a loop.
+func renderSectionPages(sections []Section, bookTitle, viewport, lang string, tocLink bool, chromes []pageChrome) ([]string, error) {
+	pages := make([]string, len(sections))
+	errs := make([]error, len(sections))
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(sections) {
+		workers = len(sections)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				var chrome pageChrome
+				if i < len(chromes) {
+					chrome = chromes[i]
+				}
+				pages[i], errs[i] = generateSectionPage(sections[i], bookTitle, viewport, lang, tocLink, chrome)
+			}
+		}()
+	}
+	for i := range sections {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("section %d page: %w", i+1, err)
+		}
+	}
+	return pages, nil
+}
+
+func generateSectionPage(section Section, bookTitle string, viewport, lang string, tocLink bool, chrome pageChrome) (string, error) {
+	// Strip or sanitize HTML tags to produce clean readable text, then
+	// rebalance tags left unpaired by section splitting.
+	safeContent := repairHTML(replaceImageFallbacks(sanitizeHTML(section.Content)))
 
 	var buf bytes.Buffer
 	err := sectionPageTmpl.Execute(&buf, sectionPageData{
-		Title:     title,
-		BookTitle: bookTitle,
+		Title:     html.EscapeString(section.Title),
+		BookTitle: html.EscapeString(bookTitle),
 		Content:   safeContent,
+		Viewport:  viewport,
+		Lang:      lang,
+		PageID:    html.EscapeString(section.HeadingID),
+		TOCLink:   tocLink,
+		Header:    html.EscapeString(chrome.header),
+		Footer:    html.EscapeString(chrome.footer),
 	})
 	if err != nil {
 		return "", err
@@ -231,29 +874,149 @@ func generateSectionPage(title string, content string, bookTitle string) (string
 // sanitizeHTML removes script/style tags and returns safe HTML suitable for
 // embedding in XHTML. It preserves basic Markdown-generated HTML elements.
 var (
-	reScript = regexp.MustCompile(`(?is)<script[^>]*>.*?</script>`)
-	reStyle  = regexp.MustCompile(`(?is)<style[^>]*>.*?</style>`)
+	reScript    = regexp.MustCompile(`(?is)<script[^>]*>.*?</script>`)
+	reStyle     = regexp.MustCompile(`(?is)<style[^>]*>.*?</style>`)
+	reComment   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	reEventAttr = regexp.MustCompile(`(?i)\son[a-z]+\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+	reJSURL     = regexp.MustCompile(`(?i)\s((xlink:)?href|src)\s*=\s*("javascript:[^"]*"|'javascript:[^']*')`)
+	reOpenTag   = regexp.MustCompile(`(?i)<[a-z][a-z0-9]*(\s[^>]*)?>`)
 )
 
 func sanitizeHTML(raw string) string {
 	s := reScript.ReplaceAllString(raw, "")
 	s = reStyle.ReplaceAllString(s, "")
+	s = stripComments(s)
+	s = sanitizeSVGs(s)
+	// Raw HTML passed through by the UnsafeHTML option can carry event
+	// handlers and javascript: URLs; drop those attributes from every tag.
+	// The replacement is confined to tags so that entity-escaped code samples
+	// mentioning onclick and friends stay untouched.
+	s = reOpenTag.ReplaceAllStringFunc(s, func(tag string) string {
+		tag = reEventAttr.ReplaceAllString(tag, "")
+		return reJSURL.ReplaceAllString(tag, "")
+	})
 	// Preserve basic HTML elements generated by Markdown
 	return s
 }
 
-func generateCSS(preset DevicePreset) string {
-	return fmt.Sprintf(`/* md-converter — E-Ink Optimised Stylesheet */
+// reSrcAttr extracts an image tag's src attribute value.
+var reSrcAttr = regexp.MustCompile(`(?i)\bsrc\s*=\s*("([^"]*)"|'([^']*)')`)
+
+// replaceImageFallbacks rewrites images that are not embedded in the book —
+// anything without a data: URI src — as a visible bracketed caption, so
+// readers see "[Image: Architecture diagram]" instead of a broken icon or
+// nothing. The caption falls back to the src basename when the alt text is
+// empty. Embedded data: images render on their own and are left untouched.
+func replaceImageFallbacks(s string) string {
+	return reImgTag.ReplaceAllStringFunc(s, func(tag string) string {
+		src := ""
+		if m := reSrcAttr.FindStringSubmatch(tag); m != nil {
+			src = m[2] + m[3] // one of the quote alternatives matched
+		}
+		if strings.HasPrefix(strings.ToLower(src), "data:") {
+			return tag
+		}
+
+		caption := "[Image]"
+		if m := reAltAttr.FindStringSubmatch(tag); m != nil {
+			caption = "[Image: " + strings.Trim(m[1], `"'`) + "]"
+		} else if base := srcBasename(src); base != "" {
+			caption = "[Image: " + base + "]"
+		}
+		return fmt.Sprintf(`<span class="image-caption">%s</span>`, caption)
+	})
+}
+
+// srcBasename returns the last path segment of an image src, without query
+// string or fragment, or "" when there is none.
+func srcBasename(src string) string {
+	if i := strings.IndexAny(src, "?#"); i >= 0 {
+		src = src[:i]
+	}
+	if i := strings.LastIndex(src, "/"); i >= 0 {
+		src = src[i+1:]
+	}
+	return src
+}
+
+// stripComments removes HTML comments (<!-- TOC -->, commented-out blocks and
+// the like), which some e-readers display literally. Escaped entities such as
+// &lt;!-- in code blocks are left alone.
+func stripComments(s string) string {
+	return reComment.ReplaceAllString(s, "")
+}
+
+// GenerateCSS returns the stylesheet an EPUB built with opts embeds, byte for
+// byte, so it can also be downloaded standalone and sideloaded as a
+// device-wide stylesheet. Fixed-layout page pinning is included when selected.
+func GenerateCSS(opts ConvertOptions) string {
+	opts = opts.withDefaults()
+	css := generateCSS(opts)
+	if opts.FixedLayout {
+		css += fmt.Sprintf(`
+/* Fixed layout: pin pages to the device's exact pixel dimensions */
+.page {
+    width: %dpx;
+    height: %dpx;
+    overflow: hidden;
+}
+`, opts.Preset.Width, opts.Preset.Height)
+	}
+	return css
+}
+
+func generateCSS(opts ConvertOptions) string {
+	preset := opts.Preset
+	verticalCJK := opts.VerticalCJK
+
+	fontFamily := preset.FontFamily
+	if fontFamily == "" {
+		fontFamily = FontFamilySerif
+	}
+	if verticalCJK {
+		fontFamily = FontFamilyCJK
+	}
+
+	// Spacing overrides; the zero values reproduce the original stylesheet.
+	margin := preset.Margin
+	if opts.Margin > 0 {
+		margin = opts.Margin
+	}
+	bodyLineHeight, contentLineHeight := "1.6", "1.8"
+	if opts.LineHeight > 0 {
+		bodyLineHeight = formatFloat(opts.LineHeight)
+		contentLineHeight = bodyLineHeight
+	}
+	paraSpacing := "0.5em"
+	if opts.ParaSpacing > 0 {
+		paraSpacing = formatFloat(opts.ParaSpacing) + "em"
+	}
+
+	// Heading sizes interpolate from the preset's scale (the h1 size) down to
+	// 1.0em at h6; the section title uses the same scale over the body size so
+	// pages and in-page headings shrink together on small screens.
+	headingScale := preset.HeadingScale
+	if headingScale == 0 {
+		headingScale = HeadingScaleNormal
+	}
+	var headingRules strings.Builder
+	for level := 1; level <= 6; level++ {
+		size := 1.0 + (headingScale-1.0)*float64(6-level)/5
+		headingRules.WriteString(fmt.Sprintf(".section-content h%d { font-size: %sem; }\n", level, formatFloat(math.Round(size*100)/100)))
+	}
+	sectionTitleSize := formatFloat(math.Round(float64(preset.FontSize)*headingScale*2) / 2)
+
+	css := fmt.Sprintf(`/* md-converter — E-Ink Optimised Stylesheet */
 /* Device: %s (%dx%d) */
 
 body {
     margin: %dpx;
     padding: 0;
-    font-family: Georgia, "Times New Roman", serif;
+    font-family: %s;
     font-size: %dpt;
     color: #000000;
     background-color: #ffffff;
-    line-height: 1.6;
+    line-height: %s;
 }
 
 .page {
@@ -268,7 +1031,7 @@ body {
 }
 
 .section-title {
-    font-size: %dpt;
+    font-size: %spt;
     font-weight: bold;
     color: #000000;
     border-bottom: 2px solid #000000;
@@ -280,11 +1043,11 @@ body {
 .section-content {
     font-size: %dpt;
     max-width: 100%%;
-    line-height: 1.8;
+    line-height: %s;
 }
 
 .section-content p {
-    margin: 0.5em 0;
+    margin: %s 0;
 }
 
 .section-content h1, .section-content h2, .section-content h3, 
@@ -293,12 +1056,12 @@ body {
     font-weight: bold;
 }
 
-.section-content h1 { font-size: 1.5em; }
-.section-content h2 { font-size: 1.4em; }
-.section-content h3 { font-size: 1.3em; }
-.section-content h4 { font-size: 1.2em; }
-.section-content h5 { font-size: 1.1em; }
-.section-content h6 { font-size: 1.0em; }
+%s
+
+.section-content a {
+    color: #000000;
+    text-decoration: underline;
+}
 
 .section-content ul, .section-content ol {
     margin: 0.5em 0;
@@ -332,6 +1095,20 @@ body {
     font-style: italic;
 }
 
+/* Definition lists (DefinitionLists option) */
+.section-content dl {
+    margin: 0.5em 0;
+}
+
+.section-content dt {
+    font-weight: bold;
+    margin-top: 0.5em;
+}
+
+.section-content dd {
+    margin: 0 0 0.5em 1.5em;
+}
+
 .section-content table {
     border-collapse: collapse;
     width: 100%%;
@@ -348,12 +1125,135 @@ body {
     background-color: #f0f0f0;
     font-weight: bold;
 }
+
+/* Task-list boxes rendered as text symbols */
+.section-content li .task-box {
+    display: inline-block;
+    min-width: 1.4em;
+}
+
+.section-content li:has(> .task-box) {
+    list-style: none;
+    margin-left: -1.4em;
+}
+
+/* Back-to-TOC link appended per section (TOCBackLinks option) */
+.toc-link {
+    margin-top: 2em;
+    text-align: center;
+    font-size: 0.9em;
+}
+
+/* Running page chrome (RunningHeader/RunningFooter options) */
+.page-header {
+    width: 100%%;
+    font-size: 0.8em;
+    color: #555555;
+    border-bottom: 1px solid #ccc;
+    padding-bottom: 0.3em;
+    margin-bottom: 1em;
+}
+
+.page-footer {
+    width: 100%%;
+    font-size: 0.8em;
+    color: #555555;
+    border-top: 1px solid #ccc;
+    padding-top: 0.3em;
+    margin-top: 2em;
+    text-align: center;
+}
+
+/* Stacked table fallback for narrow screens (StackTables option) */
+.stacked-table .stacked-row {
+    border: 1px solid #ccc;
+    padding: 0.5em;
+    margin: 0.5em 0;
+}
+
+.stacked-table dt {
+    font-weight: bold;
+}
+
+.stacked-table dd {
+    margin: 0 0 0.5em 1em;
+}
+
+/* Grayscale syntax highlighting (chroma classes; inert unless the
+   highlighting option emitted classed spans). Color is unreliable on e-ink,
+   so tokens are distinguished with weight, slant and shade instead. */
+.chroma .k, .chroma .kc, .chroma .kd, .chroma .kn, .chroma .kp, .chroma .kr, .chroma .kt {
+    font-weight: bold;
+}
+
+.chroma .c, .chroma .c1, .chroma .cm, .chroma .ch, .chroma .cs {
+    font-style: italic;
+    color: #555555;
+}
+
+.chroma .s, .chroma .s1, .chroma .s2, .chroma .sb, .chroma .sx, .chroma .sc {
+    background-color: #e0e0e0;
+}
+
+.chroma .m, .chroma .mi, .chroma .mf, .chroma .mh, .chroma .mo, .chroma .mb {
+    color: #333333;
+}
+
+.chroma .nf, .chroma .fm {
+    font-weight: bold;
+}
+
+/* Bracketed caption fallback for images that are not embedded */
+.image-caption {
+    display: block;
+    font-style: italic;
+    color: #555555;
+    text-align: center;
+    margin: 0.5em 0;
+}
+
+/* Typographic cover page (Cover option) */
+.cover {
+    text-align: center;
+    margin-top: 40%%;
+}
 `,
 		preset.Name, preset.Width, preset.Height,
-		preset.Margin,
+		margin,
+		fontFamily,
 		preset.FontSize,
-		preset.Margin*2,
-		preset.FontSize+4,
+		bodyLineHeight,
+		margin*2,
+		sectionTitleSize,
 		preset.FontSize,
+		contentLineHeight,
+		paraSpacing,
+		strings.TrimSuffix(headingRules.String(), "\n"),
 	)
+
+	if verticalCJK {
+		// Vertical-rl flows columns right-to-left, so the reading margin moves
+		// from top/bottom to right/left and line spacing opens up for ruby.
+		css += fmt.Sprintf(`
+/* Vertical CJK writing mode */
+body {
+    writing-mode: vertical-rl;
+    -epub-writing-mode: vertical-rl;
+    margin: %dpx %dpx;
+    line-height: 1.9;
+}
+
+ruby {
+    ruby-position: over;
+    ruby-align: center;
+}
+
+rt {
+    font-size: 0.5em;
+    line-height: 1;
+}
+`, preset.Margin/2, preset.Margin)
+	}
+
+	return css
 }
@@ -3,6 +3,11 @@ package services
 import (
 	"archive/zip"
 	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -61,10 +66,10 @@ func TestGenerateEPUB_OnePagePerSection(t *testing.T) {
 		t.Fatalf("invalid zip: %v", err)
 	}
 
-	// Count section XHTML pages.
+	// Count section XHTML pages (everything except the nav document).
 	pageCount := 0
 	for _, f := range r.File {
-		if strings.Contains(f.Name, "section_") && strings.HasSuffix(f.Name, ".xhtml") {
+		if strings.HasSuffix(f.Name, ".xhtml") && f.Name != "OEBPS/nav.xhtml" {
 			pageCount++
 		}
 	}
@@ -102,7 +107,7 @@ func TestGenerateEPUB_EmptySections(t *testing.T) {
 
 	// Should still have structure files but no section pages.
 	for _, f := range r.File {
-		if strings.HasSuffix(f.Name, "_section.xhtml") || strings.Contains(f.Name, "section_") {
+		if strings.HasSuffix(f.Name, ".xhtml") && f.Name != "OEBPS/nav.xhtml" {
 			t.Errorf("unexpected section page in empty document: %s", f.Name)
 		}
 	}
@@ -141,6 +146,198 @@ func TestGenerateEPUB_MimetypeFirst(t *testing.T) {
 	}
 }
 
+func TestNavLabels_DuplicatesGetAncestor(t *testing.T) {
+	sections := []Section{
+		{Title: "Release 1.0", Level: 2},
+		{Title: "Fixed", Level: 3},
+		{Title: "Release 2.0", Level: 2},
+		{Title: "Fixed", Level: 3},
+	}
+
+	labels := navLabels(sections)
+
+	want := []string{
+		"Release 1.0",
+		"Fixed (Release 1.0)",
+		"Release 2.0",
+		"Fixed (Release 2.0)",
+	}
+	for i, label := range labels {
+		if label != want[i] {
+			t.Errorf("label %d: got %q, want %q", i, label, want[i])
+		}
+	}
+}
+
+func TestNavLabels_OrdinalFallback(t *testing.T) {
+	// Duplicates under the same ancestor still need unique labels.
+	sections := []Section{
+		{Title: "Release 1.0", Level: 2},
+		{Title: "Fixed", Level: 3},
+		{Title: "Fixed", Level: 3},
+		{Title: "Orphan", Level: 1},
+		{Title: "Orphan", Level: 1}, // no ancestor at all
+	}
+
+	labels := navLabels(sections)
+
+	want := []string{
+		"Release 1.0",
+		"Fixed (Release 1.0) (1)",
+		"Fixed (Release 1.0) (2)",
+		"Orphan (1)",
+		"Orphan (2)",
+	}
+	for i, label := range labels {
+		if label != want[i] {
+			t.Errorf("label %d: got %q, want %q", i, label, want[i])
+		}
+	}
+
+	// Deterministic across runs.
+	again := navLabels(sections)
+	for i := range labels {
+		if labels[i] != again[i] {
+			t.Errorf("label %d differs between runs: %q vs %q", i, labels[i], again[i])
+		}
+	}
+
+	// Page headings stay as authored.
+	if sections[1].Title != "Fixed" {
+		t.Errorf("section title was modified: %q", sections[1].Title)
+	}
+}
+
+func TestGenerateCSS_FontFamily(t *testing.T) {
+	preset := DevicePresets[0]
+	preset.FontFamily = FontFamilySans
+	css := generateCSS(ConvertOptions{Preset: preset})
+
+	if !strings.Contains(css, "font-family: "+FontFamilySans+";") {
+		t.Errorf("css missing selected font family:\n%s", css)
+	}
+	// Code blocks must stay monospace regardless of the body family.
+	if !strings.Contains(css, "font-family: monospace;") {
+		t.Errorf("css missing monospace for code blocks:\n%s", css)
+	}
+
+	// An empty family falls back to the serif stack.
+	preset.FontFamily = ""
+	css = generateCSS(ConvertOptions{Preset: preset})
+	if !strings.Contains(css, "font-family: "+FontFamilySerif+";") {
+		t.Errorf("css missing serif fallback:\n%s", css)
+	}
+}
+
+func TestGenerateSectionIDs_NoCollisions(t *testing.T) {
+	// 12,000 headings with heavily repeated titles must produce unique ids.
+	sections := make([]Section, 12000)
+	for i := range sections {
+		switch i % 4 {
+		case 0:
+			sections[i] = Section{ID: int64(i + 1), Title: "Overview"}
+		case 1:
+			sections[i] = Section{ID: int64(i + 1), Title: "Chapter Notes"}
+		case 2:
+			sections[i] = Section{ID: int64(i + 1), Title: ""} // untitled
+		default:
+			sections[i] = Section{ID: int64(i + 1), Title: "2024 Review"} // digit-leading slug
+		}
+	}
+
+	ids := generateSectionIDs(sections)
+	if len(ids) != len(sections) {
+		t.Fatalf("got %d ids, want %d", len(ids), len(sections))
+	}
+
+	seen := make(map[string]int)
+	for i, id := range ids {
+		if id == "" {
+			t.Fatalf("section %d has empty id", i)
+		}
+		if prev, dup := seen[id]; dup {
+			t.Fatalf("id %q collides between sections %d and %d", id, prev, i)
+		}
+		seen[id] = i
+		if id[0] < 'a' || id[0] > 'z' {
+			t.Errorf("id %q does not start with a letter", id)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Introduction", "introduction"},
+		{"Getting Started", "getting-started"},
+		{"  Spaces & Symbols!  ", "spaces-symbols"},
+		{"2024 Review", "sec-2024-review"},
+		{"---", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.title); got != tt.want {
+			t.Errorf("slugify(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateEPUBWithCompression_StoreKeepsMimetypeFirst(t *testing.T) {
+	for _, mode := range []CompressionMode{CompressionDefault, CompressionFast, CompressionStore} {
+		t.Run(mode.String(), func(t *testing.T) {
+			data, err := GenerateEPUBWithCompression(sampleSections(), DevicePresets[0], "Test", mode)
+			if err != nil {
+				t.Fatalf("GenerateEPUBWithCompression(%s): %v", mode, err)
+			}
+
+			r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("invalid zip: %v", err)
+			}
+			if r.File[0].Name != "mimetype" || r.File[0].Method != zip.Store {
+				t.Errorf("mimetype must be first and stored uncompressed")
+			}
+			if mode == CompressionStore {
+				for _, f := range r.File {
+					if f.Method != zip.Store {
+						t.Errorf("entry %s not stored in store mode", f.Name)
+					}
+				}
+			}
+		})
+	}
+}
+
+// benchmarkSections builds roughly 1 MB of section content.
+func benchmarkSections() []Section {
+	paragraph := strings.Repeat("<p>The quick brown fox jumps over the lazy dog.</p>\n", 100)
+	sections := make([]Section, 200)
+	for i := range sections {
+		sections[i] = Section{
+			ID:      int64(i + 1),
+			Title:   fmt.Sprintf("Section %d", i+1),
+			Content: paragraph,
+		}
+	}
+	return sections
+}
+
+func benchmarkGenerateEPUB(b *testing.B, mode CompressionMode) {
+	sections := benchmarkSections()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := GenerateEPUBWithCompression(sections, DevicePresets[0], "Benchmark", mode); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateEPUB_Default(b *testing.B) { benchmarkGenerateEPUB(b, CompressionDefault) }
+func BenchmarkGenerateEPUB_Fast(b *testing.B)    { benchmarkGenerateEPUB(b, CompressionFast) }
+func BenchmarkGenerateEPUB_Store(b *testing.B)   { benchmarkGenerateEPUB(b, CompressionStore) }
+
 func TestSanitizeHTML(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -171,3 +368,861 @@ func TestSanitizeHTML(t *testing.T) {
 		})
 	}
 }
+
+// readEPUBEntry returns the content of one named entry in an epub.
+func readEPUBEntry(t *testing.T, data []byte, name string) string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", name, err)
+		}
+		defer rc.Close()
+		var sb strings.Builder
+		if _, err := io.Copy(&sb, rc); err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		return sb.String()
+	}
+	t.Fatalf("entry %s not found", name)
+	return ""
+}
+
+func TestGenerateEPUBWithLayout_FixedLayoutOPF(t *testing.T) {
+	data, err := GenerateEPUBWithLayout(sampleSections(), DevicePresets[0], "Test", CompressionDefault, true)
+	if err != nil {
+		t.Fatalf("GenerateEPUBWithLayout: %v", err)
+	}
+
+	opf := readEPUBEntry(t, data, "OEBPS/content.opf")
+	for _, want := range []string{
+		`prefix="rendition: http://www.idpf.org/vocab/rendition/#"`,
+		`<meta property="rendition:layout">pre-paginated</meta>`,
+	} {
+		if !strings.Contains(opf, want) {
+			t.Errorf("fixed-layout OPF missing %s", want)
+		}
+	}
+}
+
+func TestGenerateEPUBWithLayout_ReflowableOPF(t *testing.T) {
+	data, err := GenerateEPUBWithLayout(sampleSections(), DevicePresets[0], "Test", CompressionDefault, false)
+	if err != nil {
+		t.Fatalf("GenerateEPUBWithLayout: %v", err)
+	}
+
+	opf := readEPUBEntry(t, data, "OEBPS/content.opf")
+	if strings.Contains(opf, "rendition") {
+		t.Error("reflowable OPF should not carry rendition properties")
+	}
+}
+
+func TestGenerateEPUBWithLayout_ViewportPerPage(t *testing.T) {
+	preset := DevicePresets[0] // Xtreink X4, 480x800
+	data, err := GenerateEPUBWithLayout(sampleSections(), preset, "Test", CompressionDefault, true)
+	if err != nil {
+		t.Fatalf("GenerateEPUBWithLayout: %v", err)
+	}
+
+	viewport := fmt.Sprintf(`<meta name="viewport" content="width=%d, height=%d"/>`, preset.Width, preset.Height)
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	pages := 0
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".xhtml") || f.Name == "OEBPS/nav.xhtml" {
+			continue
+		}
+		pages++
+		if page := readEPUBEntry(t, data, f.Name); !strings.Contains(page, viewport) {
+			t.Errorf("page %s missing viewport meta %s", f.Name, viewport)
+		}
+	}
+	if pages != len(sampleSections()) {
+		t.Fatalf("checked %d pages, want %d", pages, len(sampleSections()))
+	}
+
+	css := readEPUBEntry(t, data, "OEBPS/styles.css")
+	for _, want := range []string{
+		fmt.Sprintf("width: %dpx;", preset.Width),
+		fmt.Sprintf("height: %dpx;", preset.Height),
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("fixed-layout CSS missing %q", want)
+		}
+	}
+}
+
+func TestGenerateEPUBWithLayout_NoViewportWhenReflowable(t *testing.T) {
+	data, err := GenerateEPUBWithLayout(sampleSections(), DevicePresets[0], "Test", CompressionDefault, false)
+	if err != nil {
+		t.Fatalf("GenerateEPUBWithLayout: %v", err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".xhtml") {
+			continue
+		}
+		if page := readEPUBEntry(t, data, f.Name); strings.Contains(page, `name="viewport"`) {
+			t.Errorf("reflowable page %s has a viewport meta", f.Name)
+		}
+	}
+}
+
+func TestGenerateCSS_VerticalCJK(t *testing.T) {
+	css := generateCSS(ConvertOptions{Preset: DevicePresets[0], VerticalCJK: true})
+	for _, want := range []string{
+		"writing-mode: vertical-rl;",
+		"-epub-writing-mode: vertical-rl;",
+		"Noto Serif CJK JP",
+		"ruby-position: over;",
+		"rt {",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("vertical CJK CSS missing %q", want)
+		}
+	}
+}
+
+func TestGenerateEPUBWithVerticalText_Metadata(t *testing.T) {
+	data, err := GenerateEPUBWithVerticalText(sampleSections(), DevicePresets[0], "Test", CompressionDefault, false, true)
+	if err != nil {
+		t.Fatalf("GenerateEPUBWithVerticalText: %v", err)
+	}
+
+	opf := readEPUBEntry(t, data, "OEBPS/content.opf")
+	if !strings.Contains(opf, "<dc:language>ja</dc:language>") {
+		t.Error("vertical CJK OPF missing ja language metadata")
+	}
+
+	page := readEPUBEntry(t, data, "OEBPS/introduction.xhtml")
+	if !strings.Contains(page, `xml:lang="ja"`) {
+		t.Error("section page missing xml:lang=\"ja\"")
+	}
+}
+
+func TestGenerateEPUB_DefaultLanguageEnglish(t *testing.T) {
+	data, err := GenerateEPUB(sampleSections(), DevicePresets[0], "Test")
+	if err != nil {
+		t.Fatalf("GenerateEPUB: %v", err)
+	}
+	if opf := readEPUBEntry(t, data, "OEBPS/content.opf"); !strings.Contains(opf, "<dc:language>en</dc:language>") {
+		t.Error("default OPF language is not en")
+	}
+}
+
+func TestGenerateOPF_AccessibilityTextOnly(t *testing.T) {
+	sections := []Section{{ID: 1, Title: "Intro", Level: 1, Content: "<p>text only</p>"}}
+
+	data, err := GenerateEPUB(sections, DevicePresets[0], "Test")
+	if err != nil {
+		t.Fatalf("GenerateEPUB: %v", err)
+	}
+	opf := readEPUBEntry(t, data, "OEBPS/content.opf")
+
+	for _, want := range []string{
+		`<meta property="schema:accessMode">textual</meta>`,
+		`<meta property="schema:accessModeSufficient">textual</meta>`,
+		`<meta property="schema:accessibilityFeature">tableOfContents</meta>`,
+	} {
+		if !strings.Contains(opf, want) {
+			t.Errorf("opf missing %q:\n%s", want, opf)
+		}
+	}
+	if strings.Contains(opf, `>visual<`) {
+		t.Errorf("text-only opf claims visual access mode:\n%s", opf)
+	}
+}
+
+func TestGenerateOPF_AccessibilityWithAltImages(t *testing.T) {
+	sections := []Section{{ID: 1, Title: "Intro", Level: 1, Content: `<p><img src="a.png" alt="diagram"/></p>`}}
+
+	data, err := GenerateEPUB(sections, DevicePresets[0], "Test")
+	if err != nil {
+		t.Fatalf("GenerateEPUB: %v", err)
+	}
+	opf := readEPUBEntry(t, data, "OEBPS/content.opf")
+
+	if !strings.Contains(opf, `<meta property="schema:accessMode">visual</meta>`) {
+		t.Errorf("opf missing visual access mode:\n%s", opf)
+	}
+	if !strings.Contains(opf, `<meta property="schema:accessibilityFeature">alternativeText</meta>`) {
+		t.Errorf("opf missing alternativeText feature:\n%s", opf)
+	}
+}
+
+func TestGenerateOPF_AccessibilityImageWithoutAlt(t *testing.T) {
+	sections := []Section{{ID: 1, Title: "Intro", Level: 1, Content: `<p><img src="a.png"/> and <img src="b.png" alt="ok"/></p>`}}
+
+	data, err := GenerateEPUB(sections, DevicePresets[0], "Test")
+	if err != nil {
+		t.Fatalf("GenerateEPUB: %v", err)
+	}
+	opf := readEPUBEntry(t, data, "OEBPS/content.opf")
+
+	if !strings.Contains(opf, `<meta property="schema:accessMode">visual</meta>`) {
+		t.Errorf("opf missing visual access mode:\n%s", opf)
+	}
+	if strings.Contains(opf, "alternativeText") {
+		t.Errorf("opf claims alternativeText despite missing alt:\n%s", opf)
+	}
+}
+
+func TestConvert_SpecialCharacterTitlesYieldWellFormedXHTML(t *testing.T) {
+	md := "# AT&T history\n\nBody one.\n\n# The <x> element\n\nBody two.\n\n# Quoting \"properly\"\n\nBody three.\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: `Tricky & "Titles" <here>`})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(result.EPUBData), int64(len(result.EPUBData)))
+	if err != nil {
+		t.Fatalf("open epub: %v", err)
+	}
+	checked := 0
+	for _, f := range zr.File {
+		if !strings.HasSuffix(f.Name, ".xhtml") {
+			continue
+		}
+		page := readEPUBEntry(t, result.EPUBData, f.Name)
+		dec := xml.NewDecoder(strings.NewReader(page))
+		for {
+			_, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("%s is not well-formed XML: %v", f.Name, err)
+				break
+			}
+		}
+		checked++
+	}
+	if checked < 4 { // three sections plus the nav document
+		t.Fatalf("only %d xhtml entries checked", checked)
+	}
+}
+
+func TestGenerateCSS_SpacingDefaultsUnchanged(t *testing.T) {
+	css := generateCSS(ConvertOptions{Preset: DevicePresets[0]})
+	for _, want := range []string{
+		"margin: 16px;", "padding: 32px;",
+		"line-height: 1.6;", "line-height: 1.8;",
+		"margin: 0.5em 0;",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("default css missing %q:\n%s", want, css)
+		}
+	}
+}
+
+func TestGenerateCSS_SpacingOverrides(t *testing.T) {
+	css := generateCSS(ConvertOptions{
+		Preset:      DevicePresets[0],
+		Margin:      40,
+		LineHeight:  2.0,
+		ParaSpacing: 1.25,
+	})
+	for _, want := range []string{
+		"margin: 40px;", "padding: 80px;",
+		"line-height: 2;",
+		"margin: 1.25em 0;",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("css missing %q:\n%s", want, css)
+		}
+	}
+	if strings.Contains(css, "line-height: 1.8;") {
+		t.Errorf("default line-height survived the override:\n%s", css)
+	}
+}
+
+func TestConvert_SpacingOverridesClamped(t *testing.T) {
+	result, err := Convert([]byte("# T\n\nbody\n"), ConvertOptions{
+		Title:       "Test",
+		Margin:      9999,
+		LineHeight:  42,
+		ParaSpacing: -3,
+	})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	css := readEPUBEntry(t, result.EPUBData, "OEBPS/styles.css")
+	for _, want := range []string{"margin: 200px;", "line-height: 3;", "margin: 0.5em 0;"} {
+		if !strings.Contains(css, want) {
+			t.Errorf("clamped css missing %q:\n%s", want, css)
+		}
+	}
+}
+
+func TestConvert_LandmarksWithCover(t *testing.T) {
+	md := "# First Chapter\n\nContent.\n\n# Second Chapter\n\nMore.\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Book", Cover: true})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	nav := readEPUBEntry(t, result.EPUBData, "OEBPS/nav.xhtml")
+	if !strings.Contains(nav, `epub:type="landmarks"`) {
+		t.Fatalf("nav.xhtml has no landmarks block:\n%s", nav)
+	}
+	if !strings.Contains(nav, `<a epub:type="cover" href="cover.xhtml">Cover</a>`) {
+		t.Errorf("landmarks missing cover entry:\n%s", nav)
+	}
+	if !strings.Contains(nav, `<a epub:type="toc" href="nav.xhtml">Table of Contents</a>`) {
+		t.Errorf("landmarks missing toc entry:\n%s", nav)
+	}
+	if !strings.Contains(nav, `<a epub:type="bodymatter" href="first-chapter.xhtml">Begin Reading</a>`) {
+		t.Errorf("landmarks missing bodymatter entry:\n%s", nav)
+	}
+
+	cover := readEPUBEntry(t, result.EPUBData, "OEBPS/cover.xhtml")
+	if !strings.Contains(cover, "<h1>Book</h1>") {
+		t.Errorf("cover page missing title:\n%s", cover)
+	}
+	opf := readEPUBEntry(t, result.EPUBData, "OEBPS/content.opf")
+	if !strings.Contains(opf, `<itemref idref="cover"/>`) {
+		t.Errorf("cover missing from spine:\n%s", opf)
+	}
+}
+
+func TestConvert_LandmarksWithoutCover(t *testing.T) {
+	md := "# First Chapter\n\nContent.\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Book"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	nav := readEPUBEntry(t, result.EPUBData, "OEBPS/nav.xhtml")
+	if strings.Contains(nav, `epub:type="cover"`) {
+		t.Errorf("landmarks reference a cover that was not generated:\n%s", nav)
+	}
+	if !strings.Contains(nav, `<a epub:type="bodymatter" href="first-chapter.xhtml">Begin Reading</a>`) {
+		t.Errorf("landmarks missing bodymatter entry:\n%s", nav)
+	}
+}
+
+func TestConvert_ReproducibleOutputIsByteIdentical(t *testing.T) {
+	md := []byte("# Title\n\nSome text.\n\n## Second\n\nMore text.\n")
+	opts := ConvertOptions{
+		Title:        "Book",
+		Reproducible: true,
+		Cover:        true,
+		Colophon:     true,
+	}
+
+	first, err := Convert(md, opts)
+	if err != nil {
+		t.Fatalf("Convert (first): %v", err)
+	}
+	second, err := Convert(md, opts)
+	if err != nil {
+		t.Fatalf("Convert (second): %v", err)
+	}
+
+	if !bytes.Equal(first.EPUBData, second.EPUBData) {
+		t.Errorf("reproducible conversions differ: %d vs %d bytes", len(first.EPUBData), len(second.EPUBData))
+	}
+
+	opf := readEPUBEntry(t, first.EPUBData, "OEBPS/content.opf")
+	if !strings.Contains(opf, "1970-01-01T00:00:00Z") {
+		t.Errorf("reproducible output without a known date should use the fixed epoch, got:\n%s", opf)
+	}
+}
+
+func TestConvert_FrontMatterDateEmitsDCDate(t *testing.T) {
+	// dc:date carries the publication date; dcterms:modified stays the
+	// conversion time. Cover the accepted input formats.
+	for _, tc := range []struct {
+		name string
+		date string
+	}{
+		{"plain date", "2021-05-04"},
+		{"rfc3339", "2021-05-04T10:30:00Z"},
+		{"iso8601 no zone", "2021-05-04T10:30:00"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			md := []byte("---\ntitle: Post\ndate: " + tc.date + "\n---\n\n# Title\n\nText.\n")
+
+			result, err := Convert(md, ConvertOptions{Title: "Book"})
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+			if len(result.Warnings) != 0 {
+				t.Errorf("unexpected warnings: %v", result.Warnings)
+			}
+
+			opf := readEPUBEntry(t, result.EPUBData, "OEBPS/content.opf")
+			if !strings.Contains(opf, "<dc:date>2021-05-04</dc:date>") {
+				t.Errorf("dc:date should come from the front matter date, got:\n%s", opf)
+			}
+			if strings.Contains(opf, `<meta property="dcterms:modified">2021-05-04`) {
+				t.Errorf("dcterms:modified should not be the publication date:\n%s", opf)
+			}
+		})
+	}
+}
+
+func TestConvert_UnparseableFrontMatterDateWarns(t *testing.T) {
+	md := []byte("---\ndate: sometime in May\n---\n\n# Title\n\nText.\n")
+
+	result, err := Convert(md, ConvertOptions{Title: "Book"})
+	if err != nil {
+		t.Fatalf("Convert should not fail on a bad date: %v", err)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], `"sometime in May"`) {
+		t.Errorf("Warnings = %v, want one naming the bad date", result.Warnings)
+	}
+	if opf := readEPUBEntry(t, result.EPUBData, "OEBPS/content.opf"); strings.Contains(opf, "<dc:date>") {
+		t.Errorf("dc:date should be omitted for an unparseable date:\n%s", opf)
+	}
+}
+
+func TestConvert_IdentifierIsStableContentHash(t *testing.T) {
+	md := []byte("# Title\n\nText.\n")
+
+	first, err := Convert(md, ConvertOptions{Title: "Book"})
+	if err != nil {
+		t.Fatalf("Convert (first): %v", err)
+	}
+	second, err := Convert(md, ConvertOptions{Title: "Book"})
+	if err != nil {
+		t.Fatalf("Convert (second): %v", err)
+	}
+	changed, err := Convert(md, ConvertOptions{Title: "Book", NumberSections: true})
+	if err != nil {
+		t.Fatalf("Convert (changed settings): %v", err)
+	}
+
+	reID := regexp.MustCompile(`<dc:identifier id="uid">(md-converter-[0-9a-f]{16})</dc:identifier>`)
+	id := func(result ConversionResult) string {
+		m := reID.FindStringSubmatch(readEPUBEntry(t, result.EPUBData, "OEBPS/content.opf"))
+		if m == nil {
+			t.Fatalf("no content-hash identifier in OPF")
+		}
+		return m[1]
+	}
+
+	if id(first) != id(second) {
+		t.Errorf("identical conversions got different identifiers: %s vs %s", id(first), id(second))
+	}
+	if id(first) == id(changed) {
+		t.Errorf("changed settings should change the identifier, both are %s", id(first))
+	}
+}
+
+func TestReplaceImageFallbacks(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			"alt text becomes a caption",
+			`<p><img src="https://example.com/arch.png" alt="Architecture diagram"/></p>`,
+			`<p><span class="image-caption">[Image: Architecture diagram]</span></p>`,
+		},
+		{
+			"empty alt falls back to the src basename",
+			`<img src="images/diagram.png?v=2">`,
+			`<span class="image-caption">[Image: diagram.png]</span>`,
+		},
+		{
+			"no alt and no src",
+			`<img>`,
+			`<span class="image-caption">[Image]</span>`,
+		},
+		{
+			"embedded data URI is left untouched",
+			`<img src="data:image/png;base64,AAAA" alt="chart"/>`,
+			`<img src="data:image/png;base64,AAAA" alt="chart"/>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceImageFallbacks(tt.input); got != tt.want {
+				t.Errorf("replaceImageFallbacks(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert_ImageFallbackCaptionInPage(t *testing.T) {
+	md := []byte("# Title\n\n![Architecture diagram](https://example.com/arch.png)\n")
+
+	result, err := Convert(md, ConvertOptions{Title: "Book"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/title.xhtml")
+	if !strings.Contains(page, `<span class="image-caption">[Image: Architecture diagram]</span>`) {
+		t.Errorf("page should carry the caption fallback, got:\n%s", page)
+	}
+	if strings.Contains(page, "<img") {
+		t.Errorf("unembedded image tag should be replaced:\n%s", page)
+	}
+}
+
+func TestRenderSectionPages_MatchesSerial(t *testing.T) {
+	sections := make([]Section, 200)
+	for i := range sections {
+		sections[i] = Section{
+			ID:      int64(i + 1),
+			Title:   fmt.Sprintf("Section %d", i+1),
+			Content: fmt.Sprintf("<p>Body %d with <strong>markup</strong> and an <img src=\"pic%d.png\" alt=\"Figure %d\"> image.</p>", i+1, i+1, i+1),
+		}
+	}
+
+	pages, err := renderSectionPages(sections, "Book", "", "en", false, nil)
+	if err != nil {
+		t.Fatalf("renderSectionPages: %v", err)
+	}
+	if len(pages) != len(sections) {
+		t.Fatalf("got %d pages, want %d", len(pages), len(sections))
+	}
+
+	for i, section := range sections {
+		want, err := generateSectionPage(section, "Book", "", "en", false, pageChrome{})
+		if err != nil {
+			t.Fatalf("generateSectionPage(%d): %v", i, err)
+		}
+		if pages[i] != want {
+			t.Errorf("page %d differs from the serial render", i)
+		}
+	}
+}
+
+func TestRenderSectionPages_Empty(t *testing.T) {
+	pages, err := renderSectionPages(nil, "Book", "", "en", false, nil)
+	if err != nil {
+		t.Fatalf("renderSectionPages: %v", err)
+	}
+	if len(pages) != 0 {
+		t.Errorf("got %d pages, want 0", len(pages))
+	}
+}
+
+func BenchmarkGenerateEPUB_1000Sections(b *testing.B) {
+	sections := make([]Section, 1000)
+	for i := range sections {
+		sections[i] = Section{
+			ID:      int64(i + 1),
+			Title:   fmt.Sprintf("Section %d", i+1),
+			Content: strings.Repeat(fmt.Sprintf("<p>Paragraph %d of synthetic body text for benchmarking.</p>", i+1), 20),
+		}
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := GenerateEPUB(sections, DevicePresets[0], "Benchmark"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateCSS_HeadingScalePerPreset(t *testing.T) {
+	// Snapshot of the heading rules each preset produces: compact on the
+	// low-resolution Xtreink, the classic 1.5→1.0 scale everywhere else.
+	want := map[string][]string{
+		"Xtreink X4": {
+			".section-content h1 { font-size: 1.3em; }",
+			".section-content h2 { font-size: 1.24em; }",
+			".section-content h3 { font-size: 1.18em; }",
+			".section-content h4 { font-size: 1.12em; }",
+			".section-content h5 { font-size: 1.06em; }",
+			".section-content h6 { font-size: 1em; }",
+			".section-title {\n    font-size: 15.5pt;",
+		},
+		"Onyx Boox Page": {
+			".section-content h1 { font-size: 1.5em; }",
+			".section-content h6 { font-size: 1em; }",
+			".section-title {\n    font-size: 24pt;",
+		},
+		"Kindle": {
+			".section-content h1 { font-size: 1.5em; }",
+			".section-content h2 { font-size: 1.4em; }",
+			".section-title {\n    font-size: 24pt;",
+		},
+		"Kobo Clara Reader": {
+			".section-content h1 { font-size: 1.5em; }",
+			".section-content h6 { font-size: 1em; }",
+			".section-title {\n    font-size: 21pt;",
+		},
+	}
+
+	for _, preset := range DevicePresets {
+		t.Run(preset.Name, func(t *testing.T) {
+			css := generateCSS(ConvertOptions{Preset: preset})
+			for _, snippet := range want[preset.Name] {
+				if !strings.Contains(css, snippet) {
+					t.Errorf("css missing %q:\n%s", snippet, css)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateCSS_HeadingScaleOverride(t *testing.T) {
+	preset := DevicePresets[0]
+	preset.HeadingScale = HeadingScaleLarge
+	css := generateCSS(ConvertOptions{Preset: preset})
+
+	if !strings.Contains(css, ".section-content h1 { font-size: 1.7em; }") {
+		t.Errorf("css missing large h1 size:\n%s", css)
+	}
+
+	// A zero scale falls back to the normal one.
+	preset.HeadingScale = 0
+	css = generateCSS(ConvertOptions{Preset: preset})
+	if !strings.Contains(css, ".section-content h1 { font-size: 1.5em; }") {
+		t.Errorf("css missing normal fallback h1 size:\n%s", css)
+	}
+}
+
+func TestConvert_TOCBackLinks(t *testing.T) {
+	md := "# Guide\n\nintro\n\n## Setup\n\nsteps\n\n## Usage\n\nrun it\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Guide", TOCBackLinks: true})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	for _, file := range []string{"OEBPS/guide.xhtml", "OEBPS/setup.xhtml", "OEBPS/usage.xhtml"} {
+		page := readEPUBEntry(t, result.EPUBData, file)
+		if !strings.Contains(page, `<a href="nav.xhtml">↑ Contents</a>`) {
+			t.Errorf("%s is missing the back-to-TOC link:\n%s", file, page)
+		}
+	}
+}
+
+func TestConvert_TOCBackLinksOffByDefault(t *testing.T) {
+	md := "# Guide\n\nintro\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Guide"})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/guide.xhtml")
+	if strings.Contains(page, "toc-link") {
+		t.Errorf("back-to-TOC link should be off by default:\n%s", page)
+	}
+}
+
+func TestConvert_HeadingAnchorLinks(t *testing.T) {
+	md := "# Guide\n\nSee [setup](#setup), [tiny](#tiny-note) and [after](#after).\n\n" +
+		"## Setup\n\nEnough words here to stay clear of the tiny-section merge.\n\n" +
+		"## Tiny Note\n\nx\n\n" +
+		"## After\n\nEnough words here to stay clear of the tiny-section merge too.\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Guide", MinSectionChars: 20})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	// A link to a section's own heading resolves to its page, whose container
+	// carries the heading id.
+	guide := readEPUBEntry(t, result.EPUBData, "OEBPS/guide.xhtml")
+	if !strings.Contains(guide, `href="setup.xhtml#setup"`) {
+		t.Errorf("own-heading link not resolved:\n%s", guide)
+	}
+	setup := readEPUBEntry(t, result.EPUBData, "OEBPS/setup.xhtml")
+	if !strings.Contains(setup, `<div class="page" id="setup">`) {
+		t.Errorf("page container is missing the heading id:\n%s", setup)
+	}
+
+	// "After" was merged into the "Tiny Note" page, so its heading is a
+	// sub-heading there; both anchors resolve to that page.
+	if !strings.Contains(guide, `href="tiny-note.xhtml#tiny-note"`) {
+		t.Errorf("merged page's own-heading link not resolved:\n%s", guide)
+	}
+	if !strings.Contains(guide, `href="tiny-note.xhtml#after"`) {
+		t.Errorf("sub-heading link not resolved:\n%s", guide)
+	}
+	tiny := readEPUBEntry(t, result.EPUBData, "OEBPS/tiny-note.xhtml")
+	if !strings.Contains(tiny, `<h2 id="after">After</h2>`) {
+		t.Errorf("merged-away heading lost its anchor id:\n%s", tiny)
+	}
+}
+
+func TestConvert_HeadingAnchorDedupedAcrossSplit(t *testing.T) {
+	md := "# Guide\n\nintro\n\n## Long\n\n" + strings.Repeat("<p>filler paragraph text</p>\n\n", 20)
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Guide", MaxSectionChars: 200, UnsafeHTML: true})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if result.SectionCount < 3 {
+		t.Fatalf("expected the long section to split, got %d sections", result.SectionCount)
+	}
+
+	long := readEPUBEntry(t, result.EPUBData, "OEBPS/long.xhtml")
+	if !strings.Contains(long, `<div class="page" id="long">`) {
+		t.Errorf("first split page should keep the heading id:\n%s", long)
+	}
+	cont := readEPUBEntry(t, result.EPUBData, "OEBPS/long-cont.xhtml")
+	if strings.Contains(cont, `id="long"`) {
+		t.Errorf("continuation page duplicates the heading id:\n%s", cont)
+	}
+}
+
+func TestGenerateNav_TOCDepthSnapshots(t *testing.T) {
+	sections := []Section{
+		{Title: "Part One", Level: 1},
+		{Title: "Chapter", Level: 2},
+		{Title: "Topic", Level: 3},
+		{Title: "Detail", Level: 4},
+	}
+	ids := generateSectionIDs(sections)
+
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{
+			depth: 1,
+			want:  "      <li><a href=\"part-one.xhtml\">Part One</a></li>\n",
+		},
+		{
+			depth: 3,
+			want: "      <li><a href=\"part-one.xhtml\">Part One</a></li>\n" +
+				"      <li><a href=\"chapter.xhtml\">Chapter</a></li>\n" +
+				"      <li><a href=\"topic.xhtml\">Topic</a></li>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		nav := generateNav(sections, ids, "Book", ConvertOptions{TOCDepth: tt.depth}, "", "")
+		start := strings.Index(nav, "<ol>\n") + len("<ol>\n")
+		end := strings.Index(nav, "    </ol>")
+		if got := nav[start:end]; got != tt.want {
+			t.Errorf("depth %d toc entries:\ngot:\n%swant:\n%s", tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestConvert_TOCDepthDefault(t *testing.T) {
+	md := "# Book\n\nintro\n\n## Chapter\n\nbody\n\n### Topic\n\nbody\n\n#### Detail\n\ndeep body\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Book"})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	nav := readEPUBEntry(t, result.EPUBData, "OEBPS/nav.xhtml")
+	if !strings.Contains(nav, `href="topic.xhtml"`) {
+		t.Errorf("level-3 section missing from nav at default depth:\n%s", nav)
+	}
+	if strings.Contains(nav, `href="detail.xhtml"`) {
+		t.Errorf("level-4 section listed at default depth 3:\n%s", nav)
+	}
+	// The page itself still exists and is in the spine.
+	opf := readEPUBEntry(t, result.EPUBData, "OEBPS/content.opf")
+	if !strings.Contains(opf, `<itemref idref="detail"/>`) {
+		t.Errorf("unlisted section missing from spine:\n%s", opf)
+	}
+	readEPUBEntry(t, result.EPUBData, "OEBPS/detail.xhtml")
+}
+
+func TestGenerateCSS_MatchesEmbedded(t *testing.T) {
+	opts := ConvertOptions{
+		Preset:      DevicePresets[1],
+		Title:       "Doc",
+		Margin:      30,
+		LineHeight:  2.0,
+		ParaSpacing: 1.0,
+		FixedLayout: true,
+	}
+	result, err := Convert([]byte("# One\n\nbody\n"), opts)
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	embedded := readEPUBEntry(t, result.EPUBData, "OEBPS/styles.css")
+	if standalone := GenerateCSS(opts); standalone != embedded {
+		t.Errorf("standalone CSS differs from the EPUB-embedded copy:\n--- standalone ---\n%s\n--- embedded ---\n%s", standalone, embedded)
+	}
+}
+
+// BenchmarkGenerateEPUBTo_Memory streams a large document to io.Discard with
+// allocation reporting: rendered pages are released batch by batch, so the
+// reported bytes per operation should track the largest sections, not the
+// whole document's rendered size.
+func BenchmarkGenerateEPUBTo_Memory(b *testing.B) {
+	sections := benchmarkSections()
+	opts := ConvertOptions{Preset: DevicePresets[0], Title: "Benchmark", Compression: CompressionStore}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := GenerateEPUBTo(io.Discard, sections, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateEPUBTo_BatchSizeDoesNotChangeOutput(t *testing.T) {
+	sections := benchmarkSections()
+	opts := ConvertOptions{Preset: DevicePresets[0], Title: "Doc", Reproducible: true}
+
+	generate := func(procs int) []byte {
+		old := runtime.GOMAXPROCS(procs)
+		defer runtime.GOMAXPROCS(old)
+		var buf bytes.Buffer
+		if err := GenerateEPUBTo(&buf, sections, opts); err != nil {
+			t.Fatalf("GenerateEPUBTo with GOMAXPROCS=%d: %v", procs, err)
+		}
+		return buf.Bytes()
+	}
+
+	serial := generate(1)
+	parallel := generate(8)
+	if !bytes.Equal(serial, parallel) {
+		t.Error("output differs between batch sizes")
+	}
+}
+
+func TestConvert_RunningHeaderAndFooter(t *testing.T) {
+	md := "# First\n\none\n\n# Second\n\ntwo\n\n# Third\n\nthree\n"
+	result, err := Convert([]byte(md), ConvertOptions{
+		Title:          "Ref Book",
+		RunningHeader:  true,
+		RunningFooter:  true,
+		ShowWordCounts: true,
+	})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/second.xhtml")
+	if !strings.Contains(page, `<div class="page-header">Ref Book · Second</div>`) {
+		t.Errorf("running header missing or wrong:\n%s", page)
+	}
+	if !strings.Contains(page, `<div class="page-footer">Section 2 of 3</div>`) {
+		t.Errorf("running footer missing or wrong:\n%s", page)
+	}
+
+	// Chrome is injected at render time only; word counts see the content.
+	for _, stat := range result.SectionStats {
+		if stat.Title == "Second" && stat.Words != 1 {
+			t.Errorf("Words = %d, want 1 (chrome must not be counted)", stat.Words)
+		}
+	}
+}
+
+func TestConvert_RunningChromeOffByDefault(t *testing.T) {
+	result, err := Convert([]byte("# Only\n\nbody\n"), ConvertOptions{Title: "Doc"})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/only.xhtml")
+	if strings.Contains(page, "page-header") || strings.Contains(page, "page-footer") {
+		t.Errorf("running chrome rendered without the options:\n%s", page)
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// IsEPUBPath reports whether a file name looks like an EPUB, for routing
+// uploads to ConvertEPUB.
+func IsEPUBPath(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".epub")
+}
+
+// EPUBMetadata holds the package metadata carried over from an imported EPUB.
+type EPUBMetadata struct {
+	Title    string // dc:title, or "" when absent
+	Language string // dc:language, or "" when absent
+}
+
+var (
+	reDCTitle       = regexp.MustCompile(`(?s)<dc:title[^>]*>(.*?)</dc:title>`)
+	reDCLanguage    = regexp.MustCompile(`(?s)<dc:language[^>]*>(.*?)</dc:language>`)
+	rePropertiesAtt = regexp.MustCompile(`\bproperties="([^"]*)"`)
+	reBodyInner     = regexp.MustCompile(`(?s)<body[^>]*>(.*)</body>`)
+	rePageH1        = regexp.MustCompile(`(?s)<h1[^>]*>(.*?)</h1>`)
+	rePageTitleTag  = regexp.MustCompile(`(?s)<title[^>]*>(.*?)</title>`)
+	reSectionTitle  = regexp.MustCompile(`(?s)<div class="section-title">(.*?)</div>`)
+	reSectionInner  = regexp.MustCompile(`(?s)<div class="section-content">(.*)</div>`)
+	reTOCLinkDiv    = regexp.MustCompile(`(?s)\s*<div class="toc-link">.*?</div>`)
+)
+
+// ConvertEPUB re-targets an existing EPUB — generated earlier or by another
+// tool — to the selected preset and stylesheet without the original Markdown:
+// the spine-ordered pages are extracted into sections and run back through
+// the normal EPUB generation. The imported title and language are used unless
+// the options already set them.
+func ConvertEPUB(epubData []byte, opts ConvertOptions) (ConversionResult, error) {
+	opts = opts.withDefaults()
+	sections, meta, err := ParseEPUB(epubData)
+	if err != nil {
+		return ConversionResult{}, err
+	}
+	if opts.Title == "" {
+		opts.Title = meta.Title
+	}
+	if opts.Language == "" {
+		opts.Language = meta.Language
+	}
+	return convertSections(sections, opts)
+}
+
+// ParseEPUB extracts the spine-ordered XHTML pages of an EPUB into sections,
+// along with the package metadata. The navigation document is skipped — it is
+// regenerated from the sections — and each page's title comes from its
+// section-title div, first h1 or <title> tag, in that order. Malformed input
+// fails with a descriptive error rather than a zip panic.
+func ParseEPUB(data []byte) ([]Section, EPUBMetadata, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, EPUBMetadata{}, fmt.Errorf("epub import: not a valid zip archive: %v", err)
+	}
+
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+
+	container, ok := entries["META-INF/container.xml"]
+	if !ok {
+		return nil, EPUBMetadata{}, fmt.Errorf("epub import: missing META-INF/container.xml")
+	}
+	containerXML, err := readZipEntry(container)
+	if err != nil {
+		return nil, EPUBMetadata{}, fmt.Errorf("epub import: read container.xml: %w", err)
+	}
+	m := reFullPath.FindSubmatch(containerXML)
+	if m == nil {
+		return nil, EPUBMetadata{}, fmt.Errorf("epub import: container.xml has no rootfile full-path")
+	}
+	opfPath := string(m[1])
+	opfFile, ok := entries[opfPath]
+	if !ok {
+		return nil, EPUBMetadata{}, fmt.Errorf("epub import: package document %q is missing", opfPath)
+	}
+	opf, err := readZipEntry(opfFile)
+	if err != nil {
+		return nil, EPUBMetadata{}, fmt.Errorf("epub import: read %s: %w", opfPath, err)
+	}
+	opfStr := string(opf)
+
+	meta := EPUBMetadata{}
+	if m := reDCTitle.FindStringSubmatch(opfStr); m != nil {
+		meta.Title = strings.TrimSpace(html.UnescapeString(m[1]))
+	}
+	if m := reDCLanguage.FindStringSubmatch(opfStr); m != nil {
+		meta.Language = strings.TrimSpace(m[1])
+	}
+
+	// Manifest: id → href, noting which items are the navigation document.
+	hrefs := make(map[string]string)
+	isNav := make(map[string]bool)
+	for _, tag := range reItemTag.FindAllString(opfStr, -1) {
+		id := reIDAttr.FindStringSubmatch(tag)
+		href := reHrefAttr.FindStringSubmatch(tag)
+		if id == nil || href == nil {
+			continue
+		}
+		hrefs[id[1]] = href[1]
+		if p := rePropertiesAtt.FindStringSubmatch(tag); p != nil && strings.Contains(p[1], "nav") {
+			isNav[id[1]] = true
+		}
+	}
+
+	opfDir := path.Dir(opfPath)
+	var sections []Section
+	for _, m := range reItemref.FindAllStringSubmatch(opfStr, -1) {
+		idref := m[1]
+		if isNav[idref] {
+			continue
+		}
+		href, ok := hrefs[idref]
+		if !ok {
+			return nil, EPUBMetadata{}, fmt.Errorf("epub import: spine itemref %q has no manifest item", idref)
+		}
+		file, ok := entries[path.Join(opfDir, href)]
+		if !ok {
+			return nil, EPUBMetadata{}, fmt.Errorf("epub import: spine page %q is missing from the archive", href)
+		}
+		page, err := readZipEntry(file)
+		if err != nil {
+			return nil, EPUBMetadata{}, fmt.Errorf("epub import: read %s: %w", href, err)
+		}
+
+		title, content := extractPageSection(string(page))
+		sections = append(sections, Section{
+			ID:      int64(len(sections) + 1),
+			Title:   title,
+			Level:   1,
+			Content: content,
+		})
+	}
+
+	if len(sections) == 0 {
+		return nil, EPUBMetadata{}, fmt.Errorf("epub import: spine has no content pages")
+	}
+	return sections, meta, nil
+}
+
+// extractPageSection pulls a section title and body content out of one XHTML
+// page. Pages generated by this converter round-trip through their
+// section-title/section-content divs; foreign pages fall back to the first h1
+// (removed from the content, since the page template renders the title
+// itself) and then the <title> tag.
+func extractPageSection(page string) (title, content string) {
+	content = page
+	if m := reBodyInner.FindStringSubmatch(page); m != nil {
+		content = m[1]
+	}
+	// The back-to-TOC link is chrome, not content; it is regenerated when the
+	// TOCBackLinks option is on.
+	content = reTOCLinkDiv.ReplaceAllString(content, "")
+
+	if m := reSectionTitle.FindStringSubmatch(content); m != nil {
+		title = stripTags(m[1])
+		if inner := reSectionInner.FindStringSubmatch(content); inner != nil {
+			content = inner[1]
+		}
+	} else if m := rePageH1.FindStringSubmatch(content); m != nil {
+		title = stripTags(m[1])
+		content = strings.Replace(content, m[0], "", 1)
+	} else if m := rePageTitleTag.FindStringSubmatch(page); m != nil {
+		title = stripTags(m[1])
+	}
+	return strings.TrimSpace(title), strings.TrimSpace(content)
+}
+
+// stripTags removes markup from an extracted title fragment.
+func stripTags(s string) string {
+	return strings.TrimSpace(html.UnescapeString(reTag.ReplaceAllString(s, "")))
+}
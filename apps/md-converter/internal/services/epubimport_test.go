@@ -0,0 +1,173 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestIsEPUBPath(t *testing.T) {
+	if !IsEPUBPath("book.epub") || !IsEPUBPath("BOOK.EPUB") {
+		t.Error("epub paths not recognized")
+	}
+	if IsEPUBPath("book.md") || IsEPUBPath("book.html") {
+		t.Error("non-epub path recognized")
+	}
+}
+
+func TestParseEPUB_RoundTrip(t *testing.T) {
+	md := "# My Book\n\nintro text\n\n## Chapter One\n\nfirst chapter\n\n## Chapter Two\n\nsecond chapter\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "My Book"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	sections, meta, err := ParseEPUB(result.EPUBData)
+	if err != nil {
+		t.Fatalf("ParseEPUB: %v", err)
+	}
+
+	if meta.Title != "My Book" {
+		t.Errorf("title: got %q, want %q", meta.Title, "My Book")
+	}
+	if meta.Language != "en" {
+		t.Errorf("language: got %q, want %q", meta.Language, "en")
+	}
+
+	wantTitles := []string{"My Book", "Chapter One", "Chapter Two"}
+	if len(sections) != len(wantTitles) {
+		t.Fatalf("got %d sections, want %d", len(sections), len(wantTitles))
+	}
+	for i, want := range wantTitles {
+		if sections[i].Title != want {
+			t.Errorf("section %d title: got %q, want %q", i, sections[i].Title, want)
+		}
+	}
+	if !strings.Contains(sections[1].Content, "first chapter") {
+		t.Errorf("section 1 content lost: %q", sections[1].Content)
+	}
+}
+
+func TestConvertEPUB_RetargetsPreset(t *testing.T) {
+	md := "# Guide\n\nbody\n\n## Setup\n\nsteps\n"
+	original, err := Convert([]byte(md), ConvertOptions{Title: "Guide", Preset: DevicePresets[1]})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	retargeted, err := ConvertEPUB(original.EPUBData, ConvertOptions{Preset: DevicePresets[0]})
+	if err != nil {
+		t.Fatalf("ConvertEPUB: %v", err)
+	}
+
+	if retargeted.SectionCount != original.SectionCount {
+		t.Errorf("sections: got %d, want %d", retargeted.SectionCount, original.SectionCount)
+	}
+
+	css := readEPUBEntry(t, retargeted.EPUBData, "OEBPS/styles.css")
+	if !strings.Contains(css, DevicePresets[0].Name) {
+		t.Errorf("stylesheet not re-targeted to %s:\n%s", DevicePresets[0].Name, css)
+	}
+
+	// Title and language carry over from the imported package metadata.
+	opf := readEPUBEntry(t, retargeted.EPUBData, "OEBPS/content.opf")
+	if !strings.Contains(opf, "<dc:title>Guide</dc:title>") {
+		t.Errorf("imported title not carried over:\n%s", opf)
+	}
+	if !strings.Contains(opf, "<dc:language>en</dc:language>") {
+		t.Errorf("imported language not carried over:\n%s", opf)
+	}
+}
+
+func TestConvertEPUB_ForeignPagesUseH1(t *testing.T) {
+	// A minimal EPUB from another tool: no section-title divs, h1 headings.
+	sections, meta, err := ParseEPUB(buildForeignEPUB(t))
+	if err != nil {
+		t.Fatalf("ParseEPUB: %v", err)
+	}
+	if meta.Title != "Foreign Book" || meta.Language != "fr" {
+		t.Errorf("metadata: got %+v", meta)
+	}
+	if len(sections) != 1 {
+		t.Fatalf("got %d sections, want 1", len(sections))
+	}
+	if sections[0].Title != "Premier chapitre" {
+		t.Errorf("title: got %q", sections[0].Title)
+	}
+	if strings.Contains(sections[0].Content, "<h1") {
+		t.Errorf("h1 should be removed from content: %q", sections[0].Content)
+	}
+	if !strings.Contains(sections[0].Content, "Le corps du texte.") {
+		t.Errorf("content lost: %q", sections[0].Content)
+	}
+}
+
+func TestParseEPUB_Malformed(t *testing.T) {
+	if _, _, err := ParseEPUB([]byte("not a zip at all")); err == nil {
+		t.Error("expected error for non-zip input")
+	} else if !strings.Contains(err.Error(), "not a valid zip archive") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Valid zip, but not an EPUB.
+	if _, _, err := ParseEPUB(buildZip(t, map[string]string{"readme.txt": "hi"})); err == nil {
+		t.Error("expected error for zip without container.xml")
+	}
+}
+
+// buildForeignEPUB assembles a minimal EPUB the converter didn't generate.
+func buildForeignEPUB(t *testing.T) []byte {
+	t.Helper()
+	return buildZip(t, map[string]string{
+		"mimetype": "application/epub+zip",
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container><rootfiles><rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`,
+		"content.opf": `<?xml version="1.0"?>
+<package xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <metadata>
+    <dc:title>Foreign Book</dc:title>
+    <dc:language>fr</dc:language>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" properties="nav"/>
+    <item id="ch1" href="ch1.xhtml"/>
+  </manifest>
+  <spine>
+    <itemref idref="nav"/>
+    <itemref idref="ch1"/>
+  </spine>
+</package>`,
+		"nav.xhtml": `<html><body><nav><ol><li><a href="ch1.xhtml">Premier chapitre</a></li></ol></nav></body></html>`,
+		"ch1.xhtml": `<html><head><title>ignored</title></head><body><h1>Premier chapitre</h1><p>Le corps du texte.</p></body></html>`,
+	})
+}
+
+// buildZip packs the given entries into an in-memory zip archive.
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	// mimetype first when present, matching real EPUB layout.
+	if content, ok := files["mimetype"]; ok {
+		if err := addUncompressed(w, "mimetype", content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		if name == "mimetype" {
+			continue
+		}
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// XTCMaxSectionPages is the per-section page budget for XTC rasterization.
+// The JS generator renders each section as a run of fixed-size images and
+// degrades (or fails with an opaque error) well before a section reaches
+// triple-digit page counts, so warn once an estimate crosses this line.
+const XTCMaxSectionPages = 50
+
+// SectionPageEstimate is one section's estimated size when rendered at a
+// device preset's resolution.
+type SectionPageEstimate struct {
+	Title string
+	Lines int // estimated rendered text lines
+	Pages int // estimated device pages (at least 1)
+}
+
+// EstimatePages estimates how many device pages each section occupies at the
+// preset's resolution, from stripped text length against the usable glyph
+// grid. It is a deliberately rough paperback-style estimate — enough to spot
+// outliers — and is shared by the XTC capacity warnings and page-count
+// displays.
+func EstimatePages(sections []Section, preset DevicePreset) []SectionPageEstimate {
+	// pt → px at the usual 96/72 ratio; 1.8 matches the stylesheet's content
+	// line-height and 0.55em approximates the average glyph advance.
+	fontPx := float64(preset.FontSize) * 96 / 72
+	lineHeightPx := fontPx * 1.8
+	charsPerLine := math.Max(float64(preset.Width-2*preset.Margin)/(fontPx*0.55), 1)
+	linesPerPage := math.Max(float64(preset.Height-2*preset.Margin)/lineHeightPx, 1)
+
+	estimates := make([]SectionPageEstimate, len(sections))
+	for i, section := range sections {
+		text := html.UnescapeString(reTag.ReplaceAllString(section.Content, " "))
+		lines := int(math.Ceil(float64(len(strings.TrimSpace(text))) / charsPerLine))
+		if lines < 1 {
+			lines = 1
+		}
+		estimates[i] = SectionPageEstimate{
+			Title: section.Title,
+			Lines: lines,
+			Pages: int(math.Ceil(float64(lines) / linesPerPage)),
+		}
+	}
+	return estimates
+}
+
+// XTCCapacityWarnings returns one warning per section whose estimated page
+// count exceeds the XTC per-section budget, so oversized sections can be
+// flagged before the JS generator fails on them. Empty when everything fits.
+func XTCCapacityWarnings(sections []Section, preset DevicePreset) []string {
+	var warnings []string
+	for _, est := range EstimatePages(sections, preset) {
+		if est.Pages <= XTCMaxSectionPages {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"%q is ~%d pages at %s resolution (limit %d) — enable section splitting to break it up",
+			est.Title, est.Pages, preset.Name, XTCMaxSectionPages))
+	}
+	return warnings
+}
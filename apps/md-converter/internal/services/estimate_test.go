@@ -0,0 +1,53 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// estimateTestPreset gives the estimates round numbers: 16px glyphs on a
+// 560x760 usable area.
+var estimateTestPreset = DevicePreset{Name: "Test", Width: 600, Height: 800, FontSize: 12, Margin: 20}
+
+func TestEstimatePages_TinySectionIsOnePage(t *testing.T) {
+	sections := []Section{{Title: "Short", Content: "<p>hello</p>"}}
+	estimates := EstimatePages(sections, estimateTestPreset)
+	if len(estimates) != 1 {
+		t.Fatalf("len(estimates) = %d, want 1", len(estimates))
+	}
+	if estimates[0].Pages != 1 {
+		t.Errorf("Pages = %d, want 1", estimates[0].Pages)
+	}
+}
+
+func TestXTCCapacityWarnings_LongSectionFlagged(t *testing.T) {
+	long := Section{Title: "Huge", Content: "<p>" + strings.Repeat("word ", 30000) + "</p>"}
+	short := Section{Title: "Fine", Content: "<p>hello</p>"}
+
+	warnings := XTCCapacityWarnings([]Section{short, long}, estimateTestPreset)
+	if len(warnings) != 1 {
+		t.Fatalf("len(warnings) = %d, want 1: %v", len(warnings), warnings)
+	}
+	if !strings.Contains(warnings[0], `"Huge"`) {
+		t.Errorf("warning does not name the section: %q", warnings[0])
+	}
+	if !strings.Contains(warnings[0], "section splitting") {
+		t.Errorf("warning does not suggest splitting: %q", warnings[0])
+	}
+}
+
+func TestXTCCapacityWarnings_ThresholdPinned(t *testing.T) {
+	// ~63.6 chars per line and ~26.4 lines per page on the test preset gives
+	// ~1,683 chars per device page. 60k chars lands near 36 pages — inside
+	// the budget of 50 — while 100k chars is ~60 pages and must warn. This
+	// pins the threshold so estimate tweaks that move it show up here.
+	within := Section{Title: "Within", Content: "<p>" + strings.Repeat("x", 60000) + "</p>"}
+	over := Section{Title: "Over", Content: "<p>" + strings.Repeat("x", 100000) + "</p>"}
+
+	if warnings := XTCCapacityWarnings([]Section{within}, estimateTestPreset); len(warnings) != 0 {
+		t.Errorf("60k chars should be within the budget, got %v", warnings)
+	}
+	if warnings := XTCCapacityWarnings([]Section{over}, estimateTestPreset); len(warnings) != 1 {
+		t.Errorf("100k chars should exceed the budget, got %v", warnings)
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ExcludeSections drops sections whose titles match any of the given
+// patterns and reports how many were removed. Patterns are a comma-separated
+// list of globs (path.Match syntax: * ? [...]), matched case-insensitively
+// against the whole title — "license" only drops "License", while "chang*"
+// drops "Changelog" and "Changes". An empty pattern list is a no-op.
+func ExcludeSections(sections []Section, patterns string) ([]Section, int, error) {
+	globs := splitPatterns(patterns)
+	if len(globs) == 0 {
+		return sections, 0, nil
+	}
+
+	var kept []Section
+	for _, section := range sections {
+		matched, err := matchesAny(globs, section.Title)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !matched {
+			kept = append(kept, section)
+		}
+	}
+	return kept, len(sections) - len(kept), nil
+}
+
+// splitPatterns splits a comma-separated pattern list, dropping empty entries.
+func splitPatterns(patterns string) []string {
+	var globs []string
+	for _, p := range strings.Split(patterns, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			globs = append(globs, strings.ToLower(p))
+		}
+	}
+	return globs
+}
+
+// matchesAny reports whether the title matches any glob, case-insensitively.
+func matchesAny(globs []string, title string) (bool, error) {
+	lower := strings.ToLower(title)
+	for _, glob := range globs {
+		matched, err := path.Match(glob, lower)
+		if err != nil {
+			return false, fmt.Errorf("bad exclusion pattern %q: %w", glob, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
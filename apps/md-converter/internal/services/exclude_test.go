@@ -0,0 +1,82 @@
+package services
+
+import "testing"
+
+func docSections() []Section {
+	return []Section{
+		{ID: 1, Title: "Introduction", Level: 1, Content: "<p>intro</p>"},
+		{ID: 2, Title: "License", Level: 1, Content: "<p>MIT</p>"},
+		{ID: 3, Title: "Changelog", Level: 1, Content: "<p>history</p>"},
+		{ID: 4, Title: "Changes in v2", Level: 1, Content: "<p>v2</p>"},
+		{ID: 5, Title: "Contributing", Level: 1, Content: "<p>PRs welcome</p>"},
+	}
+}
+
+func TestExcludeSections_MultipleMatches(t *testing.T) {
+	kept, excluded, err := ExcludeSections(docSections(), "LICENSE, chang*, contributing")
+	if err != nil {
+		t.Fatalf("ExcludeSections: %v", err)
+	}
+	if excluded != 4 {
+		t.Errorf("excluded: got %d, want 4", excluded)
+	}
+	if len(kept) != 1 || kept[0].Title != "Introduction" {
+		t.Errorf("kept sections wrong: %v", kept)
+	}
+}
+
+func TestExcludeSections_NoMatches(t *testing.T) {
+	kept, excluded, err := ExcludeSections(docSections(), "appendix,glossary")
+	if err != nil {
+		t.Fatalf("ExcludeSections: %v", err)
+	}
+	if excluded != 0 {
+		t.Errorf("excluded: got %d, want 0", excluded)
+	}
+	if len(kept) != len(docSections()) {
+		t.Errorf("got %d sections, want %d", len(kept), len(docSections()))
+	}
+}
+
+func TestExcludeSections_EmptyPatterns(t *testing.T) {
+	sections := docSections()
+	kept, excluded, err := ExcludeSections(sections, "  ,  ")
+	if err != nil {
+		t.Fatalf("ExcludeSections: %v", err)
+	}
+	if excluded != 0 || len(kept) != len(sections) {
+		t.Errorf("empty pattern list dropped sections: %d excluded", excluded)
+	}
+}
+
+func TestExcludeSections_GlobIsWholeTitle(t *testing.T) {
+	// Without a wildcard, a pattern must match the whole title.
+	kept, excluded, err := ExcludeSections(docSections(), "chang")
+	if err != nil {
+		t.Fatalf("ExcludeSections: %v", err)
+	}
+	if excluded != 0 || len(kept) != len(docSections()) {
+		t.Errorf("partial pattern matched: %d excluded", excluded)
+	}
+}
+
+func TestExcludeSections_BadPattern(t *testing.T) {
+	if _, _, err := ExcludeSections(docSections(), "[unclosed"); err == nil {
+		t.Error("expected error for malformed glob")
+	}
+}
+
+func TestConvert_ExclusionsCountDropped(t *testing.T) {
+	md := "# Guide\n\nbody\n\n# License\n\nMIT\n\n# Changelog\n\nhistory\n"
+
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Test", ExcludePatterns: "license,changelog"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if result.Excluded != 2 {
+		t.Errorf("Excluded: got %d, want 2", result.Excluded)
+	}
+	if result.SectionCount != 1 || result.EPUBSections != 1 {
+		t.Errorf("counts: got %d/%d, want 1/1", result.SectionCount, result.EPUBSections)
+	}
+}
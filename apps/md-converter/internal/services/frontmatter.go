@@ -0,0 +1,77 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// recognizedFrontMatterKeys are the metadata keys that mark a leading fenced
+// block as front matter. A block without any of them — a poem or quote that
+// happens to sit between --- lines — is content, not metadata.
+var recognizedFrontMatterKeys = map[string]bool{
+	"title":  true,
+	"author": true,
+	"date":   true,
+	"lang":   true,
+	"tags":   true,
+}
+
+// reFrontMatterKey matches one flat key/value line; YAML uses "key: value"
+// and TOML "key = value".
+var reFrontMatterKey = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_-]*)\s*[:=]\s*(.*)$`)
+
+// extractFrontMatter returns the document without its leading front matter
+// block, plus the block's key/value pairs with lowercase keys. A --- (YAML)
+// or +++ (TOML) fence is only treated as metadata when every line of its body
+// is a key/value pair, comment or continuation AND at least one key is
+// recognized; anything else — including an unclosed fence — leaves the
+// document untouched with a nil map.
+func extractFrontMatter(mdData []byte) ([]byte, map[string]string) {
+	lines := strings.Split(string(mdData), "\n")
+	if len(lines) == 0 {
+		return mdData, nil
+	}
+	fence := strings.TrimSpace(lines[0])
+	if fence != "---" && fence != "+++" {
+		return mdData, nil
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == fence || (fence == "---" && trimmed == "...") {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return mdData, nil // unclosed fence: not front matter
+	}
+
+	meta := make(map[string]string)
+	recognized := false
+	for _, line := range lines[1:end] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		// Indented lines continue the previous key (YAML lists like tags
+		// entries); their values aren't needed here.
+		if line != trimmed {
+			continue
+		}
+		m := reFrontMatterKey.FindStringSubmatch(trimmed)
+		if m == nil {
+			return mdData, nil // prose line: this block is content
+		}
+		key := strings.ToLower(m[1])
+		meta[key] = strings.Trim(strings.TrimSpace(m[2]), `"'`)
+		if recognizedFrontMatterKeys[key] {
+			recognized = true
+		}
+	}
+	if !recognized {
+		return mdData, nil
+	}
+	return []byte(strings.Join(lines[end+1:], "\n")), meta
+}
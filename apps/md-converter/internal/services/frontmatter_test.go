@@ -0,0 +1,123 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractFrontMatter_YAMLMetadata(t *testing.T) {
+	md := "---\ntitle: My Post\nauthor: Jo\ndate: 2024-05-01\ntags:\n  - go\n  - epub\n---\n\n# Heading\n\nbody\n"
+	rest, meta := extractFrontMatter([]byte(md))
+
+	if meta == nil {
+		t.Fatal("metadata block not recognized")
+	}
+	if meta["title"] != "My Post" || meta["author"] != "Jo" || meta["date"] != "2024-05-01" {
+		t.Errorf("metadata = %v", meta)
+	}
+	if strings.Contains(string(rest), "My Post") {
+		t.Errorf("front matter not stripped: %q", rest)
+	}
+	if !strings.Contains(string(rest), "# Heading") {
+		t.Errorf("document body lost: %q", rest)
+	}
+}
+
+func TestExtractFrontMatter_TOMLMetadata(t *testing.T) {
+	md := "+++\ntitle = \"My Post\"\ndate = 2024-05-01\n+++\n\nbody\n"
+	rest, meta := extractFrontMatter([]byte(md))
+
+	if meta == nil {
+		t.Fatal("TOML metadata block not recognized")
+	}
+	if meta["title"] != "My Post" || meta["date"] != "2024-05-01" {
+		t.Errorf("metadata = %v", meta)
+	}
+	if strings.Contains(string(rest), "+++") {
+		t.Errorf("fence not stripped: %q", rest)
+	}
+}
+
+func TestExtractFrontMatter_PoemIsNotMetadata(t *testing.T) {
+	md := "---\nRoses are red,\nviolets are blue.\n---\n\nbody\n"
+	rest, meta := extractFrontMatter([]byte(md))
+
+	if meta != nil {
+		t.Errorf("poem treated as metadata: %v", meta)
+	}
+	if string(rest) != md {
+		t.Errorf("document was modified: %q", rest)
+	}
+}
+
+func TestExtractFrontMatter_UnrecognizedKeysStay(t *testing.T) {
+	md := "---\ncustom: value\nother: thing\n---\n\nbody\n"
+	rest, meta := extractFrontMatter([]byte(md))
+
+	if meta != nil {
+		t.Errorf("block without recognized keys treated as metadata: %v", meta)
+	}
+	if string(rest) != md {
+		t.Errorf("document was modified: %q", rest)
+	}
+}
+
+func TestExtractFrontMatter_MalformedFences(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+	}{
+		{"unclosed fence", "---\ntitle: Post\n\n# Heading\n\nbody\n"},
+		{"mismatched fence", "+++\ntitle = \"Post\"\n---\n\nbody\n"},
+		{"no fence at all", "# Heading\n\nbody\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, meta := extractFrontMatter([]byte(tt.md))
+			if meta != nil {
+				t.Errorf("metadata = %v, want nil", meta)
+			}
+			if string(rest) != tt.md {
+				t.Errorf("document was modified: %q", rest)
+			}
+		})
+	}
+}
+
+func TestConvert_FrontMatterStripped(t *testing.T) {
+	md := "---\ntitle: Post\ndate: 2024-05-01\n---\n\n# Heading\n\nbody text\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Post"})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/heading.xhtml")
+	if strings.Contains(page, "date: 2024-05-01") || strings.Contains(page, "title: Post") {
+		t.Errorf("front matter leaked into the page:\n%s", page)
+	}
+	if !strings.Contains(page, "body text") {
+		t.Errorf("body lost:\n%s", page)
+	}
+}
+
+func TestConvert_PoemBlockSurvives(t *testing.T) {
+	md := "---\nRoses are red\n---\n\n# Heading\n\nbody\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Poems"})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if result.SectionCount == 0 {
+		t.Fatal("no sections")
+	}
+	found := false
+	for _, stat := range result.SectionStats {
+		page := readEPUBEntry(t, result.EPUBData, "OEBPS/"+stat.ID+".xhtml")
+		if strings.Contains(page, "Roses are red") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("poem content was stripped as front matter")
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	reBody    = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+	reChrome  = regexp.MustCompile(`(?is)<(nav|header|footer|aside)[^>]*>.*?</(?:nav|header|footer|aside)>`)
+	reHeading = regexp.MustCompile(`(?is)<h([1-6])[^>]*>(.*?)</h[1-6]>`)
+)
+
+// IsHTMLPath reports whether a file name looks like raw HTML input
+// (saved article, pandoc output) rather than Markdown.
+func IsHTMLPath(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".html") || strings.HasSuffix(lower, ".htm")
+}
+
+// ParseHTML parses a raw HTML document into sections, skipping goldmark
+// entirely. The <head>, scripts, styles, comments and nav/header/footer
+// chrome are dropped; the remaining body is split on h1–h6 headings exactly
+// like rendered Markdown, so the rest of the EPUB pipeline is reused as-is.
+func ParseHTML(data []byte) ([]Section, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("html data is empty")
+	}
+
+	content := string(data)
+	if m := reBody.FindStringSubmatch(content); m != nil {
+		content = m[1]
+	}
+
+	content = stripComments(content)
+	content = reScript.ReplaceAllString(content, "")
+	content = reStyle.ReplaceAllString(content, "")
+	content = reChrome.ReplaceAllString(content, "")
+
+	// splitIntoSections is line-based; put every heading on its own line so
+	// inline HTML (single-line exports are common) still splits correctly.
+	content = reHeading.ReplaceAllString(content, "\n<h$1>$2</h$1>\n")
+
+	return splitIntoSections(strings.TrimSpace(content)), nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// exportedArticle is a typical saved-article HTML file: head chrome, nav,
+// scripts and a body with headings.
+const exportedArticle = `<!DOCTYPE html>
+<html>
+<head>
+<title>Saved Article</title>
+<style>body { color: red; }</style>
+<script>trackPageView();</script>
+</head>
+<body>
+<nav><a href="/">Home</a><a href="/about">About</a></nav>
+<header><h1>Site Banner</h1></header>
+<h1>Getting Started</h1>
+<p>Intro paragraph.</p>
+<h2>Installation</h2><p>Run the installer.</p>
+<h2>Configuration</h2>
+<p>Edit the config file.</p>
+<footer><p>© 2026 Example</p></footer>
+</body>
+</html>`
+
+func TestParseHTML_ExportedArticle(t *testing.T) {
+	sections, err := ParseHTML([]byte(exportedArticle))
+	if err != nil {
+		t.Fatalf("ParseHTML: %v", err)
+	}
+
+	wantTitles := []string{"Getting Started", "Installation", "Configuration"}
+	if len(sections) != len(wantTitles) {
+		var got []string
+		for _, s := range sections {
+			got = append(got, s.Title)
+		}
+		t.Fatalf("got sections %v, want %v", got, wantTitles)
+	}
+	for i, want := range wantTitles {
+		if sections[i].Title != want {
+			t.Errorf("section %d title: got %q, want %q", i, sections[i].Title, want)
+		}
+	}
+
+	if sections[1].Level != 2 {
+		t.Errorf("Installation level: got %d, want 2", sections[1].Level)
+	}
+	if !strings.Contains(sections[1].Content, "Run the installer.") {
+		t.Errorf("Installation content missing: %s", sections[1].Content)
+	}
+
+	all := ""
+	for _, s := range sections {
+		all += s.Content
+	}
+	for _, dropped := range []string{"trackPageView", "color: red", "Site Banner", "© 2026", "About"} {
+		if strings.Contains(all, dropped) {
+			t.Errorf("chrome content %q survived into sections", dropped)
+		}
+	}
+}
+
+func TestParseHTML_Empty(t *testing.T) {
+	if _, err := ParseHTML(nil); err == nil {
+		t.Error("expected error for empty html")
+	}
+}
+
+func TestIsHTMLPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"article.html", true},
+		{"Article.HTM", true},
+		{"notes.md", false},
+		{"html-notes.md", false},
+	}
+	for _, tt := range tests {
+		if got := IsHTMLPath(tt.name); got != tt.want {
+			t.Errorf("IsHTMLPath(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConvertHTML_Pipeline(t *testing.T) {
+	result, err := ConvertHTML([]byte(exportedArticle), ConvertOptions{Title: "Saved Article"})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+	if result.SectionCount != 3 {
+		t.Errorf("SectionCount: got %d, want 3", result.SectionCount)
+	}
+	if len(result.EPUBData) == 0 {
+		t.Error("empty epub data")
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+
+	_ "image/gif"  // register decoder
+	_ "image/jpeg" // register decoder
+)
+
+// DefaultGrayLevels is the grayscale level count used for e-ink EPUB output.
+const DefaultGrayLevels = 8
+
+// GrayLevelsForFormat returns the grayscale level count appropriate for an
+// output format: 2 for 1-bit XTC, 4 for 2-bit XTCH, and DefaultGrayLevels
+// for everything else.
+func GrayLevelsForFormat(format string) int {
+	switch format {
+	case "xtc":
+		return 2
+	case "xtch":
+		return 4
+	default:
+		return DefaultGrayLevels
+	}
+}
+
+// ProcessImageForEInk converts an embedded image (PNG, JPEG or GIF bytes) to
+// the given number of grayscale levels, optionally applying Floyd–Steinberg
+// dithering, and re-encodes it as PNG. E-ink panels only show grayscale, so
+// this shrinks images and avoids colour banding.
+func ProcessImageForEInk(data []byte, levels int, dither bool) ([]byte, error) {
+	if levels < 2 {
+		return nil, fmt.Errorf("grayscale levels must be at least 2, got %d", levels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// Working buffer of gray values so dithering error can accumulate.
+	gray := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray)
+			gray[y*w+x] = float64(c.Y)
+		}
+	}
+
+	out := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			old := gray[y*w+x]
+			quantized := quantizeGray(old, levels)
+			out.SetGray(x, y, color.Gray{Y: quantized})
+
+			if !dither {
+				continue
+			}
+			// Floyd–Steinberg error diffusion.
+			diff := old - float64(quantized)
+			if x+1 < w {
+				gray[y*w+x+1] += diff * 7 / 16
+			}
+			if y+1 < h {
+				if x > 0 {
+					gray[(y+1)*w+x-1] += diff * 3 / 16
+				}
+				gray[(y+1)*w+x] += diff * 5 / 16
+				if x+1 < w {
+					gray[(y+1)*w+x+1] += diff * 1 / 16
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, out); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// quantizeGray snaps a gray value to the nearest of the given level count.
+func quantizeGray(v float64, levels int) uint8 {
+	step := 255.0 / float64(levels-1)
+	q := math.Round(v/step) * step
+	if q < 0 {
+		q = 0
+	}
+	if q > 255 {
+		q = 255
+	}
+	return uint8(q)
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// gradientPNG returns a 4x4 horizontal gradient test image.
+func gradientPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 64)})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode test image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// decodeGrayPixels decodes PNG bytes into a row-major gray pixel slice.
+func decodeGrayPixels(t *testing.T, data []byte) []uint8 {
+	t.Helper()
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	bounds := img.Bounds()
+	pixels := make([]uint8, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixels = append(pixels, color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+	}
+	return pixels
+}
+
+func TestProcessImageForEInk_DitherGolden(t *testing.T) {
+	out, err := ProcessImageForEInk(gradientPNG(t), 2, true)
+	if err != nil {
+		t.Fatalf("ProcessImageForEInk: %v", err)
+	}
+
+	// Golden output of 1-bit Floyd–Steinberg on the 4x4 gradient.
+	golden := []uint8{
+		0, 0, 255, 255,
+		0, 0, 0, 255,
+		0, 0, 255, 255,
+		0, 0, 255, 0,
+	}
+	pixels := decodeGrayPixels(t, out)
+	if len(pixels) != len(golden) {
+		t.Fatalf("got %d pixels, want %d", len(pixels), len(golden))
+	}
+	for i, p := range pixels {
+		if p != golden[i] {
+			t.Errorf("pixel %d: got %d, want %d", i, p, golden[i])
+		}
+	}
+}
+
+func TestProcessImageForEInk_QuantizeNoDither(t *testing.T) {
+	out, err := ProcessImageForEInk(gradientPNG(t), 4, false)
+	if err != nil {
+		t.Fatalf("ProcessImageForEInk: %v", err)
+	}
+
+	// Every row quantizes identically without error diffusion.
+	want := []uint8{0, 85, 170, 170}
+	pixels := decodeGrayPixels(t, out)
+	for i, p := range pixels {
+		if p != want[i%4] {
+			t.Errorf("pixel %d: got %d, want %d", i, p, want[i%4])
+		}
+	}
+}
+
+func TestProcessImageForEInk_Invalid(t *testing.T) {
+	if _, err := ProcessImageForEInk([]byte("not an image"), 8, true); err == nil {
+		t.Error("expected error for undecodable data")
+	}
+	if _, err := ProcessImageForEInk(gradientPNG(t), 1, true); err == nil {
+		t.Error("expected error for fewer than 2 levels")
+	}
+}
+
+func TestGrayLevelsForFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		want   int
+	}{
+		{"xtc", 2},
+		{"xtch", 4},
+		{"epub", DefaultGrayLevels},
+		{"", DefaultGrayLevels},
+	}
+	for _, tt := range tests {
+		if got := GrayLevelsForFormat(tt.format); got != tt.want {
+			t.Errorf("GrayLevelsForFormat(%q) = %d, want %d", tt.format, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// reAnchor matches an <a> element with an href attribute, capturing the URL
+// and the link text.
+var reAnchor = regexp.MustCompile(`(?is)<a\s[^>]*href="([^"]*)"[^>]*>(.*?)</a>`)
+
+// RewriteLinksAsFootnotes rewrites external hyperlinks in every section into
+// footnote-style references: `<a href="http...">text</a>` becomes `text[n]`
+// and a numbered list of URLs is appended to the section, so the URLs are
+// readable on non-touch e-ink devices. Anchor-only and intra-document links
+// are left alone. Returns new sections; the input is not modified.
+func RewriteLinksAsFootnotes(sections []Section) []Section {
+	result := make([]Section, len(sections))
+	for i, section := range sections {
+		section.Content = rewriteSectionLinks(section.Content)
+		result[i] = section
+	}
+	return result
+}
+
+// rewriteSectionLinks rewrites the external links of a single section.
+// Numbering is per section, in order of first appearance; duplicate URLs
+// share a number.
+func rewriteSectionLinks(content string) string {
+	var urls []string
+	index := make(map[string]int)
+
+	out := reAnchor.ReplaceAllStringFunc(content, func(match string) string {
+		groups := reAnchor.FindStringSubmatch(match)
+		href, text := groups[1], groups[2]
+		if !isExternalLink(href) {
+			return match
+		}
+		n, seen := index[href]
+		if !seen {
+			urls = append(urls, href)
+			n = len(urls)
+			index[href] = n
+		}
+		return fmt.Sprintf("%s[%d]", text, n)
+	})
+
+	if len(urls) == 0 {
+		return content
+	}
+
+	var sb strings.Builder
+	sb.WriteString(out)
+	sb.WriteString("\n<div class=\"section-links\">\n<p>Links</p>\n<ol>\n")
+	for _, u := range urls {
+		sb.WriteString("<li>" + html.EscapeString(u) + "</li>\n")
+	}
+	sb.WriteString("</ol>\n</div>")
+	return sb.String()
+}
+
+// isExternalLink reports whether the href points outside the document.
+// Fragment links and relative intra-document links stay as-is.
+func isExternalLink(href string) bool {
+	h := strings.ToLower(href)
+	return strings.HasPrefix(h, "http://") || strings.HasPrefix(h, "https://")
+}
+
+// reBareURL matches bare domains with a path (example.com/docs), which GFM's
+// Linkify leaves as plain text because they carry no scheme or www prefix.
+var reBareURL = regexp.MustCompile(`\b(?:https?://)?(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?\.)+[a-zA-Z]{2,}/[^\s<>"']*`)
+
+// autolinkBareURLs wraps bare URLs in rendered HTML with anchors so they are
+// tappable and styled like every other link. Text inside existing anchors,
+// code and pre elements is left untouched, and hrefs get an https:// scheme so
+// the footnote-links pass treats them as external.
+func autolinkBareURLs(s string) string {
+	var sb strings.Builder
+	skipDepth := 0 // nested inside <a>, <code> or <pre>
+	for i := 0; i < len(s); {
+		if s[i] == '<' {
+			end := strings.IndexByte(s[i:], '>')
+			if end < 0 {
+				sb.WriteString(s[i:])
+				break
+			}
+			tag := s[i : i+end+1]
+			switch tagName(tag) {
+			case "a", "code", "pre":
+				skipDepth++
+			case "/a", "/code", "/pre":
+				if skipDepth > 0 {
+					skipDepth--
+				}
+			}
+			sb.WriteString(tag)
+			i += end + 1
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '<')
+		if end < 0 {
+			end = len(s) - i
+		}
+		text := s[i : i+end]
+		if skipDepth == 0 {
+			text = linkifyText(text)
+		}
+		sb.WriteString(text)
+		i += end
+	}
+	return sb.String()
+}
+
+// tagName returns the lowercased element name of a raw tag, with a leading
+// slash kept for closing tags ("</A foo>" → "/a").
+func tagName(tag string) string {
+	name := strings.TrimPrefix(strings.TrimSuffix(tag, ">"), "<")
+	for i := 0; i < len(name); i++ {
+		if name[i] == ' ' || name[i] == '\t' || name[i] == '\n' {
+			name = name[:i]
+			break
+		}
+	}
+	return strings.ToLower(name)
+}
+
+// linkifyText wraps bare URL matches in a text node with anchors, leaving
+// trailing sentence punctuation and unbalanced closing parens outside the
+// link.
+func linkifyText(text string) string {
+	return reBareURL.ReplaceAllStringFunc(text, func(m string) string {
+		url := m
+		for {
+			trimmed := strings.TrimRight(url, ".,;:!?")
+			if strings.HasSuffix(trimmed, ")") && strings.Count(trimmed, ")") > strings.Count(trimmed, "(") {
+				trimmed = trimmed[:len(trimmed)-1]
+			}
+			if trimmed == url {
+				break
+			}
+			url = trimmed
+		}
+		rest := m[len(url):]
+
+		href := url
+		if !strings.HasPrefix(strings.ToLower(href), "http://") && !strings.HasPrefix(strings.ToLower(href), "https://") {
+			href = "https://" + href
+		}
+		return fmt.Sprintf(`<a href=%q>%s</a>%s`, href, url, rest)
+	})
+}
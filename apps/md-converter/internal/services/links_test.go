@@ -0,0 +1,136 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteLinksAsFootnotes_Numbering(t *testing.T) {
+	sections := []Section{{
+		ID:    1,
+		Title: "Links",
+		Content: `<p>See <a href="https://example.com/a">first</a> and ` +
+			`<a href="https://example.com/b">second</a> and ` +
+			`<a href="https://example.com/a">first again</a>.</p>`,
+	}}
+
+	out := RewriteLinksAsFootnotes(sections)
+	content := out[0].Content
+
+	for _, want := range []string{"first[1]", "second[2]", "first again[1]"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content missing %q:\n%s", want, content)
+		}
+	}
+
+	// The duplicate URL must be listed only once.
+	if strings.Count(content, "https://example.com/a") != 1 {
+		t.Errorf("duplicate URL should appear once in the link list:\n%s", content)
+	}
+	if !strings.Contains(content, `<div class="section-links">`) {
+		t.Errorf("content missing links list:\n%s", content)
+	}
+}
+
+func TestRewriteLinksAsFootnotes_InternalLinksUntouched(t *testing.T) {
+	sections := []Section{{
+		ID:    1,
+		Title: "Internal",
+		Content: `<p><a href="#heading">anchor</a> and ` +
+			`<a href="getting-started.xhtml">other section</a>.</p>`,
+	}}
+
+	out := RewriteLinksAsFootnotes(sections)
+	content := out[0].Content
+
+	if !strings.Contains(content, `<a href="#heading">anchor</a>`) {
+		t.Errorf("fragment link was rewritten:\n%s", content)
+	}
+	if !strings.Contains(content, `<a href="getting-started.xhtml">other section</a>`) {
+		t.Errorf("intra-document link was rewritten:\n%s", content)
+	}
+	if strings.Contains(content, "section-links") {
+		t.Errorf("links list added with no external links:\n%s", content)
+	}
+}
+
+func TestRewriteLinksAsFootnotes_NumberingStablePerSection(t *testing.T) {
+	sections := []Section{
+		{ID: 1, Title: "A", Content: `<a href="https://one.example">x</a>`},
+		{ID: 2, Title: "B", Content: `<a href="https://two.example">y</a>`},
+	}
+
+	out := RewriteLinksAsFootnotes(sections)
+
+	// Numbering restarts in each section.
+	if !strings.Contains(out[0].Content, "x[1]") {
+		t.Errorf("section A: %s", out[0].Content)
+	}
+	if !strings.Contains(out[1].Content, "y[1]") {
+		t.Errorf("section B numbering should restart at 1: %s", out[1].Content)
+	}
+}
+
+func TestAutolinkBareURLs_Punctuation(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{
+			"see example.com/docs.",
+			`see <a href="https://example.com/docs">example.com/docs</a>.`,
+		},
+		{
+			"(example.com/a/b),",
+			`(<a href="https://example.com/a/b">example.com/a/b</a>),`,
+		},
+		{
+			"wiki style example.com/page_(topic) works",
+			`wiki style <a href="https://example.com/page_(topic)">example.com/page_(topic)</a> works`,
+		},
+		{
+			"no path example.com here",
+			"no path example.com here",
+		},
+	}
+	for _, tt := range tests {
+		if got := autolinkBareURLs(tt.in); got != tt.want {
+			t.Errorf("autolinkBareURLs(%q):\n got  %s\n want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAutolinkBareURLs_SkipsAnchorsAndCode(t *testing.T) {
+	in := `<a href="https://example.com/x">example.com/x</a> and <code>example.com/y</code>`
+	if got := autolinkBareURLs(in); got != in {
+		t.Errorf("existing anchor or code mangled:\n got  %s\n want %s", got, in)
+	}
+}
+
+func TestParseMD_AutolinksBareURLs(t *testing.T) {
+	sections, err := ParseMD([]byte("# One\n\nvisit example.com/docs, then go.\n"))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if len(sections) != 1 {
+		t.Fatalf("got %d sections, want 1", len(sections))
+	}
+	if !strings.Contains(sections[0].Content, `<a href="https://example.com/docs">example.com/docs</a>,`) {
+		t.Errorf("bare URL not autolinked: %s", sections[0].Content)
+	}
+}
+
+func TestAutolinkedURLs_FeedFootnotes(t *testing.T) {
+	sections, err := ParseMD([]byte("# One\n\nvisit example.com/docs for more.\n"))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+
+	rewritten := RewriteLinksAsFootnotes(sections)
+	if !strings.Contains(rewritten[0].Content, "example.com/docs[1]") {
+		t.Errorf("autolinked URL not footnoted: %s", rewritten[0].Content)
+	}
+	if !strings.Contains(rewritten[0].Content, "<li>https://example.com/docs</li>") {
+		t.Errorf("footnote list missing autolinked URL: %s", rewritten[0].Content)
+	}
+}
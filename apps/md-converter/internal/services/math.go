@@ -0,0 +1,323 @@
+package services
+
+import (
+	"fmt"
+	stdhtml "html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Math support: $...$ (inline) and $$...$$ (display) spans are lifted out of
+// the Markdown before goldmark runs — emphasis parsing would otherwise chew
+// subscripts like x_i — and replaced with plain-text sentinels. After
+// rendering, restoreMath swaps each sentinel for MathML, which EPUB 3 reading
+// systems render natively. The LaTeX subset covered is the one academic notes
+// actually use: identifiers, numbers, operators, ^/_ scripts, \frac, \sqrt
+// and the common symbol commands; anything unrecognized degrades to its
+// literal text inside the formula instead of failing the conversion.
+
+// mathSentinel is the inert text stand-in for extracted math span n. Plain
+// alphanumerics and hyphens pass through goldmark untouched.
+const mathSentinel = "mdconverter-math-%d-sentinel"
+
+var reMathSentinel = regexp.MustCompile(`mdconverter-math-(\d+)-sentinel`)
+
+// extractMath replaces math spans in the Markdown with sentinels and returns
+// the MathML for each. Fenced code blocks and inline code spans are skipped,
+// and unmatched delimiters are left as literal text: a closing $ must follow
+// a non-space character and must not be followed by a digit, so "$5 and $6"
+// stays prose.
+func extractMath(data []byte) ([]byte, []string) {
+	var blocks []string
+	var out []string
+	inFence := false
+
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			out = append(out, line)
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		// A lone $$ opens a display block running to the closing $$ line.
+		if trimmed == "$$" {
+			var body []string
+			j := i + 1
+			for ; j < len(lines) && strings.TrimSpace(lines[j]) != "$$"; j++ {
+				body = append(body, lines[j])
+			}
+			if j < len(lines) {
+				blocks = append(blocks, latexToMathML(strings.Join(body, " "), true))
+				out = append(out, fmt.Sprintf(mathSentinel, len(blocks)-1))
+				i = j
+				continue
+			}
+			// No closing $$: leave the lines as they are.
+		}
+
+		out = append(out, extractInlineMath(line, &blocks))
+	}
+	return []byte(strings.Join(out, "\n")), blocks
+}
+
+// extractInlineMath handles $$...$$ and $...$ spans within a single line.
+func extractInlineMath(line string, blocks *[]string) string {
+	var sb strings.Builder
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '`':
+			// Copy inline code spans verbatim.
+			end := indexRune(runes, i+1, '`')
+			if end < 0 {
+				sb.WriteString(string(runes[i:]))
+				return sb.String()
+			}
+			sb.WriteString(string(runes[i : end+1]))
+			i = end
+		case '$':
+			display := i+1 < len(runes) && runes[i+1] == '$'
+			start := i + 1
+			if display {
+				start++
+			}
+			end := closingDollar(runes, start, display)
+			if end < 0 {
+				sb.WriteRune('$')
+				continue
+			}
+			*blocks = append(*blocks, latexToMathML(string(runes[start:end]), display))
+			sb.WriteString(fmt.Sprintf(mathSentinel, len(*blocks)-1))
+			i = end
+			if display {
+				i++
+			}
+		default:
+			sb.WriteRune(runes[i])
+		}
+	}
+	return sb.String()
+}
+
+// closingDollar finds the index of the delimiter that ends a math span
+// starting at start, or -1. Inline spans additionally require a non-space
+// before the closing $ and no digit after it, the usual heuristic for telling
+// formulas from dollar amounts.
+func closingDollar(runes []rune, start int, display bool) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] != '$' {
+			continue
+		}
+		if display {
+			if i+1 < len(runes) && runes[i+1] == '$' {
+				return i
+			}
+			continue
+		}
+		if i == start || unicode.IsSpace(runes[i-1]) {
+			continue
+		}
+		if i+1 < len(runes) && unicode.IsDigit(runes[i+1]) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+func indexRune(runes []rune, start int, r rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// restoreMath swaps the sentinels in rendered sections back for their MathML.
+// A no-op when no math was extracted.
+func restoreMath(sections []Section, blocks []string) []Section {
+	if len(blocks) == 0 {
+		return sections
+	}
+	for i := range sections {
+		sections[i].Content = reMathSentinel.ReplaceAllStringFunc(sections[i].Content, func(sentinel string) string {
+			var n int
+			fmt.Sscanf(sentinel, mathSentinel, &n)
+			if n < 0 || n >= len(blocks) {
+				return sentinel
+			}
+			return blocks[n]
+		})
+	}
+	return sections
+}
+
+// latexSymbols maps the common symbol commands to their characters. Letters
+// render as <mi>, everything else as <mo>.
+var latexSymbols = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "lambda": "λ", "mu": "μ",
+	"nu": "ν", "xi": "ξ", "pi": "π", "rho": "ρ", "sigma": "σ",
+	"tau": "τ", "phi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Sigma": "Σ",
+	"Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	"times": "×", "cdot": "⋅", "pm": "±", "div": "÷",
+	"leq": "≤", "geq": "≥", "neq": "≠", "approx": "≈", "equiv": "≡",
+	"infty": "∞", "partial": "∂", "nabla": "∇",
+	"sum": "∑", "prod": "∏", "int": "∫",
+	"rightarrow": "→", "leftarrow": "←", "to": "→",
+	"in": "∈", "subset": "⊂", "cup": "∪", "cap": "∩",
+	"forall": "∀", "exists": "∃", "ldots": "…", "cdots": "⋯",
+}
+
+// latexToMathML translates one math span into a <math> element. Unrecognized
+// commands render as their own name, so the formula stays readable.
+func latexToMathML(src string, display bool) string {
+	p := &mathParser{src: []rune(strings.TrimSpace(src))}
+	body := p.parseSequence(0)
+	mode := "inline"
+	if display {
+		mode = "block"
+	}
+	return fmt.Sprintf(`<math xmlns="http://www.w3.org/1998/Math/MathML" display=%q><mrow>%s</mrow></math>`, mode, body)
+}
+
+type mathParser struct {
+	src []rune
+	pos int
+}
+
+// parseSequence parses atoms until the stop rune (0 for end of input),
+// combining ^ and _ scripts with the preceding atom.
+func (p *mathParser) parseSequence(stop rune) string {
+	var atoms []string
+	for p.pos < len(p.src) && p.src[p.pos] != stop {
+		r := p.src[p.pos]
+		if r == '^' || r == '_' {
+			p.pos++
+			script := p.parseAtom()
+			base := "<mrow></mrow>"
+			if len(atoms) > 0 {
+				base = "<mrow>" + atoms[len(atoms)-1] + "</mrow>"
+				atoms = atoms[:len(atoms)-1]
+			}
+			tag := "msup"
+			if r == '_' {
+				tag = "msub"
+			}
+			atoms = append(atoms, fmt.Sprintf("<%s>%s<mrow>%s</mrow></%s>", tag, base, script, tag))
+			continue
+		}
+		if atom := p.parseAtom(); atom != "" {
+			atoms = append(atoms, atom)
+		}
+	}
+	return strings.Join(atoms, "")
+}
+
+// parseAtom consumes one unit: a group, a command, a number, a letter or an
+// operator character.
+func (p *mathParser) parseAtom() string {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		return ""
+	}
+
+	switch r := p.src[p.pos]; {
+	case r == '{':
+		p.pos++
+		inner := p.parseSequence('}')
+		if p.pos < len(p.src) {
+			p.pos++ // consume }
+		}
+		return inner
+	case r == '}':
+		p.pos++ // stray closing brace: drop it
+		return ""
+	case r == '\\':
+		return p.parseCommand()
+	case unicode.IsDigit(r):
+		start := p.pos
+		for p.pos < len(p.src) && (unicode.IsDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		return "<mn>" + string(p.src[start:p.pos]) + "</mn>"
+	case unicode.IsLetter(r):
+		p.pos++
+		return "<mi>" + string(r) + "</mi>"
+	default:
+		p.pos++
+		return "<mo>" + stdhtml.EscapeString(string(r)) + "</mo>"
+	}
+}
+
+// parseCommand handles a backslash command at the cursor.
+func (p *mathParser) parseCommand() string {
+	p.pos++ // consume the backslash
+	start := p.pos
+	for p.pos < len(p.src) && unicode.IsLetter(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		// An escaped single character like \{ or \$.
+		if p.pos < len(p.src) {
+			p.pos++
+			return "<mo>" + stdhtml.EscapeString(string(p.src[p.pos-1])) + "</mo>"
+		}
+		return ""
+	}
+	name := string(p.src[start:p.pos])
+
+	switch name {
+	case "frac":
+		num := p.parseAtom()
+		den := p.parseAtom()
+		return fmt.Sprintf("<mfrac><mrow>%s</mrow><mrow>%s</mrow></mfrac>", num, den)
+	case "sqrt":
+		return fmt.Sprintf("<msqrt><mrow>%s</mrow></msqrt>", p.parseAtom())
+	case "left", "right":
+		return "" // sizing hints; the delimiter itself follows as its own atom
+	case "text", "mathrm":
+		return "<mtext>" + stdhtml.EscapeString(p.parseTextGroup()) + "</mtext>"
+	}
+
+	if sym, ok := latexSymbols[name]; ok {
+		if unicode.IsLetter([]rune(sym)[0]) {
+			return "<mi>" + sym + "</mi>"
+		}
+		return "<mo>" + sym + "</mo>"
+	}
+	// Unknown command: keep its name visible rather than dropping it.
+	return "<mi>" + stdhtml.EscapeString(name) + "</mi>"
+}
+
+// parseTextGroup consumes a {...} group as raw text for \text{...}.
+func (p *mathParser) parseTextGroup() string {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.pos >= len(p.src) || p.src[p.pos] != '{' {
+		return ""
+	}
+	p.pos++
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != '}' {
+		p.pos++
+	}
+	text := string(p.src[start:p.pos])
+	if p.pos < len(p.src) {
+		p.pos++
+	}
+	return text
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLatexToMathML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "superscript",
+			src:  "E = mc^2",
+			want: []string{"<mi>E</mi>", "<mo>=</mo>", "<msup><mrow><mi>c</mi></mrow><mrow><mn>2</mn></mrow></msup>"},
+		},
+		{
+			name: "fraction",
+			src:  `\frac{a}{b}`,
+			want: []string{"<mfrac><mrow><mi>a</mi></mrow><mrow><mi>b</mi></mrow></mfrac>"},
+		},
+		{
+			name: "subscript and symbol",
+			src:  `x_i \leq \pi`,
+			want: []string{"<msub><mrow><mi>x</mi></mrow><mrow><mi>i</mi></mrow></msub>", "<mo>≤</mo>", "<mi>π</mi>"},
+		},
+		{
+			name: "sqrt",
+			src:  `\sqrt{2}`,
+			want: []string{"<msqrt><mrow><mn>2</mn></mrow></msqrt>"},
+		},
+		{
+			name: "unknown command stays visible",
+			src:  `\mystery`,
+			want: []string{"<mi>mystery</mi>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := latexToMathML(tt.src, false)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("missing %q in:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractMath_UnmatchedDelimitersStayText(t *testing.T) {
+	in := "This costs $5 and that costs $6.\n"
+	out, blocks := extractMath([]byte(in))
+	if len(blocks) != 0 {
+		t.Fatalf("dollar amounts treated as math: %v", blocks)
+	}
+	if string(out) != in {
+		t.Errorf("text was modified: %q", out)
+	}
+}
+
+func TestExtractMath_SkipsCode(t *testing.T) {
+	in := "```\n$a + b$\n```\n\nAnd `$x$` inline.\n"
+	_, blocks := extractMath([]byte(in))
+	if len(blocks) != 0 {
+		t.Errorf("math extracted from code: %v", blocks)
+	}
+}
+
+func TestConvert_MathML(t *testing.T) {
+	md := "# Physics\n\nMass-energy: $E = mc^2$ in short.\n\n$$\n\\frac{a}{b} = \\sqrt{2}\n$$\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Physics", MathML: true})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/physics.xhtml")
+	if !strings.Contains(page, `<math xmlns="http://www.w3.org/1998/Math/MathML" display="inline">`) {
+		t.Errorf("inline math missing:\n%s", page)
+	}
+	if !strings.Contains(page, "<msup><mrow><mi>c</mi></mrow><mrow><mn>2</mn></mrow></msup>") {
+		t.Errorf("superscript markup missing:\n%s", page)
+	}
+	if !strings.Contains(page, `display="block"`) || !strings.Contains(page, "<mfrac>") {
+		t.Errorf("display math missing:\n%s", page)
+	}
+	if strings.Contains(page, "math-0-sentinel") {
+		t.Errorf("sentinel leaked into output:\n%s", page)
+	}
+}
+
+func TestConvert_MathMLOffByDefault(t *testing.T) {
+	md := "# Physics\n\n$E = mc^2$\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Physics"})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/physics.xhtml")
+	if strings.Contains(page, "<math") {
+		t.Errorf("math should stay literal without the option:\n%s", page)
+	}
+}
@@ -3,11 +3,16 @@ package services
 
 import (
 	"fmt"
+	stdhtml "html"
+	"regexp"
 	"strings"
 
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
 	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/renderer/html"
 )
 
@@ -15,7 +20,17 @@ import (
 type Section struct {
 	ID      int64
 	Title   string // Section title (from heading)
+	Level   int    // Heading level (1-6); 1 for the implicit intro section
 	Content string // HTML content of the section
+	// Appendix keeps the section out of the main reading flow: linear="no"
+	// in the spine and an "Appendices" branch in the navigation. Set by
+	// MarkAppendices.
+	Appendix bool
+	// HeadingID is the auto-generated anchor id of the heading this section
+	// came from, carried onto the section's page container so deep links to
+	// the heading keep working after the heading becomes the page title.
+	// Empty for the implicit preamble and generated pages.
+	HeadingID string
 }
 
 // ParseMD parses a Markdown .md file (provided as raw bytes) and returns
@@ -24,21 +39,67 @@ type Section struct {
 // The Markdown is parsed using GitHub Flavored Markdown (GFM) specifications,
 // which includes support for tables, task lists, strikethrough, and other GFM features.
 func ParseMD(data []byte) ([]Section, error) {
+	return ParseMDWithHighlighting(data, false)
+}
+
+// ParseMDWithHighlighting is ParseMD with optional syntax highlighting for
+// fenced code blocks. When enabled, chroma tokenizes the code into
+// span-classed elements (no inline colors) so generateCSS can style them with
+// a grayscale e-ink theme.
+func ParseMDWithHighlighting(data []byte, highlightCode bool) ([]Section, error) {
+	return ParseMDWithUnsafeHTML(data, highlightCode, false)
+}
+
+// ParseMDWithUnsafeHTML is ParseMDWithHighlighting with an explicit choice
+// about raw HTML embedded in the Markdown. By default (unsafeHTML false) raw
+// HTML blocks and inline tags are dropped by goldmark, so author-supplied
+// markup — including event handlers and scripts — can never reach the EPUB.
+// With unsafeHTML true, goldmark passes raw HTML through and the output relies
+// on sanitizeHTML to strip scripts, styles and event-handler attributes;
+// enable it only for trusted documents that genuinely need embedded HTML.
+func ParseMDWithUnsafeHTML(data []byte, highlightCode, unsafeHTML bool) ([]Section, error) {
+	return ParseMDWithDefinitionLists(data, highlightCode, unsafeHTML, false)
+}
+
+// ParseMDWithDefinitionLists is ParseMDWithUnsafeHTML with opt-in support for
+// the definition-list syntax ("Term" followed by ": definition" lines), which
+// GFM doesn't cover and goldmark drops by default. Off unless asked for, since
+// a stray leading colon would otherwise silently change a document's markup.
+func ParseMDWithDefinitionLists(data []byte, highlightCode, unsafeHTML, definitionLists bool) ([]Section, error) {
 	if len(data) == 0 {
 		return nil, fmt.Errorf("markdown data is empty")
 	}
 
+	// Rewrite page-break marker lines before goldmark sees them; the rendered
+	// sentinel becomes a section boundary in splitIntoSections. Convert may
+	// have already applied a custom marker — reapplying the default is a no-op
+	// on rewritten lines.
+	data = applyPageBreakMarkers(data, defaultPageBreakMarker)
+
+	extenders := []goldmark.Extender{extension.GFM, extension.Footnote}
+	if definitionLists {
+		extenders = append(extenders, extension.DefinitionList)
+	}
+	if highlightCode {
+		extenders = append(extenders, highlighting.NewHighlighting(
+			highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+		))
+	}
+
+	rendererOptions := []renderer.Option{
+		html.WithHardWraps(),
+		html.WithXHTML(),
+	}
+	if unsafeHTML {
+		rendererOptions = append(rendererOptions, html.WithUnsafe())
+	}
+
 	md := goldmark.New(
-		goldmark.WithExtensions(
-			extension.GFM,
-		),
+		goldmark.WithExtensions(extenders...),
 		goldmark.WithParserOptions(
 			parser.WithAutoHeadingID(),
 		),
-		goldmark.WithRendererOptions(
-			html.WithHardWraps(),
-			html.WithXHTML(),
-		),
+		goldmark.WithRendererOptions(rendererOptions...),
 	)
 
 	var buf strings.Builder
@@ -46,66 +107,371 @@ func ParseMD(data []byte) ([]Section, error) {
 		return nil, fmt.Errorf("failed to parse markdown: %w", err)
 	}
 
-	htmlContent := buf.String()
-	return splitIntoSections(htmlContent), nil
+	// Strip HTML comments before splitting: a comment can span what would
+	// become a section boundary, and half a comment renders literally on some
+	// readers. Comment-like text inside code blocks is already entity-escaped
+	// by goldmark, so it is unaffected.
+	htmlContent := stripComments(buf.String())
+
+	// GFM's Linkify only triggers on schemes and www. prefixes; pick up bare
+	// domains with paths (example.com/docs) as well.
+	htmlContent = autolinkBareURLs(htmlContent)
+
+	// Footnote definitions render as one block at the document end, which the
+	// splitter would otherwise bury in whatever section happens to be last.
+	// Pull them out into a dedicated "Notes" section; the EPUB generator
+	// resolves the reference links across section files.
+	htmlContent, notes := extractFootnotes(htmlContent)
+
+	sections := splitIntoSections(htmlContent)
+	if notes != "" {
+		sections = append(sections, Section{
+			ID:      int64(len(sections) + 1),
+			Title:   "Notes",
+			Level:   1,
+			Content: notes,
+		})
+	}
+	return sections, nil
+}
+
+// reFootnotesDiv matches goldmark's footnote definition block.
+var reFootnotesDiv = regexp.MustCompile(`(?s)<div class="footnotes"[^>]*>.*?</div>`)
+
+// extractFootnotes removes the footnote definition block from the rendered
+// document and returns it separately; the block is empty when the document
+// has no footnotes.
+func extractFootnotes(html string) (rest, notes string) {
+	notes = reFootnotesDiv.FindString(html)
+	if notes == "" {
+		return html, ""
+	}
+	return strings.Replace(html, notes, "", 1), strings.TrimSpace(notes)
+}
+
+// PreviewSections parses the Markdown like ParseMD and applies the same
+// sanitization used for EPUB section pages, so the UI preview shows exactly
+// what would end up in the generated book.
+func PreviewSections(data []byte) ([]Section, error) {
+	sections, err := ParseMD(data)
+	if err != nil {
+		return nil, err
+	}
+	return sanitizeSections(sections), nil
+}
+
+// PreviewSectionsHTML is PreviewSections for raw HTML input.
+func PreviewSectionsHTML(data []byte) ([]Section, error) {
+	sections, err := ParseHTML(data)
+	if err != nil {
+		return nil, err
+	}
+	return sanitizeSections(sections), nil
+}
+
+// sanitizeSections applies the EPUB page sanitization to every section.
+func sanitizeSections(sections []Section) []Section {
+	for i := range sections {
+		sections[i].Content = sanitizeHTML(sections[i].Content)
+	}
+	return sections
+}
+
+// reHeadingID extracts the id attribute from a heading's opening tag.
+var reHeadingID = regexp.MustCompile(`^<h[1-6][^>]*\bid="([^"]+)"`)
+
+// headingLevel extracts the level from a heading tag line like "<h2 ...>".
+// Lines without a digit after "<h" default to level 1.
+func headingLevel(line string) int {
+	if len(line) >= 3 && line[2] >= '1' && line[2] <= '6' {
+		return int(line[2] - '0')
+	}
+	return 1
+}
+
+// NumberSections returns sections whose titles are prefixed with hierarchical
+// numbers (1, 1.1, 1.2, 2, …) derived from their heading levels. Because the
+// prefix lives on the title, the numbering shows up consistently in nav, the
+// TOC page and each section page. Skipped levels (h1 → h3) get an implicit 1
+// for the missing level, so numbers stay sane and unique. The input is not
+// modified.
+func NumberSections(sections []Section) []Section {
+	result := make([]Section, len(sections))
+
+	// counters[l] is the current number at heading level l (1-6).
+	var counters [7]int
+
+	for i, section := range sections {
+		level := section.Level
+		if level < 1 {
+			level = 1
+		}
+		if level > 6 {
+			level = 6
+		}
+
+		counters[level]++
+		for l := level + 1; l <= 6; l++ {
+			counters[l] = 0
+		}
+
+		// Numbering starts at the shallowest level seen so far; skipped
+		// levels in between get an implicit 1.
+		start := 1
+		for start < level && counters[start] == 0 {
+			start++
+		}
+		for l := start; l < level; l++ {
+			if counters[l] == 0 {
+				counters[l] = 1
+			}
+		}
+
+		parts := make([]string, 0, level-start+1)
+		for l := start; l <= level; l++ {
+			parts = append(parts, fmt.Sprintf("%d", counters[l]))
+		}
+
+		section.Title = fmt.Sprintf("%s %s", strings.Join(parts, "."), section.Title)
+		result[i] = section
+	}
+
+	return result
+}
+
+// MergeTinySections coalesces any section whose content is smaller than
+// minChars into the following section, so deep heading nesting doesn't
+// produce a run of near-empty pages that cause page-flip fatigue on e-ink.
+// The merged page keeps the shallower heading as its title — a tiny chapter
+// heading absorbs its first subsection rather than vanishing from the nav —
+// and the other heading is rendered inline at its original position. Merging
+// repeats while the combined content is still under the threshold. IDs are
+// reassigned in document order. A minChars of zero or less returns the input
+// unchanged.
+func MergeTinySections(sections []Section, minChars int) []Section {
+	if minChars <= 0 {
+		return sections
+	}
+
+	var result []Section
+	for i := 0; i < len(sections); i++ {
+		section := sections[i]
+		for len(section.Content) < minChars && i+1 < len(sections) {
+			i++
+			section = mergeSections(section, sections[i])
+		}
+		result = append(result, section)
+	}
+
+	for i := range result {
+		result[i].ID = int64(i + 1)
+	}
+	return result
+}
+
+// mergeSections combines two adjacent sections into one page. The shallower
+// heading wins the page title (and its anchor id); ties go to the earlier
+// section. The merged-away heading keeps its id inline, so links to it still
+// land on the right page.
+func mergeSections(a, b Section) Section {
+	merged := Section{Title: a.Title, Level: a.Level, HeadingID: a.HeadingID}
+	var parts []string
+	if b.Level < a.Level {
+		merged.Title, merged.Level, merged.HeadingID = b.Title, b.Level, b.HeadingID
+		parts = append(parts, inlineHeading(a))
+	}
+	if a.Content != "" {
+		parts = append(parts, a.Content)
+	}
+	if b.Level >= a.Level {
+		parts = append(parts, inlineHeading(b))
+	}
+	if b.Content != "" {
+		parts = append(parts, b.Content)
+	}
+	merged.Content = strings.Join(parts, "\n")
+	return merged
+}
+
+// inlineHeading renders a merged-away section's heading back into content,
+// keeping its anchor id when it has one.
+func inlineHeading(s Section) string {
+	level := s.Level
+	if level < 1 {
+		level = 1
+	}
+	if level > 6 {
+		level = 6
+	}
+	idAttr := ""
+	if s.HeadingID != "" {
+		idAttr = fmt.Sprintf(" id=%q", s.HeadingID)
+	}
+	return fmt.Sprintf("<h%d%s>%s</h%d>", level, idAttr, stdhtml.EscapeString(s.Title), level)
+}
+
+// SplitLargeSections divides any section whose rendered content exceeds
+// maxChars into continuation pages titled "Title (cont.)", so a single giant
+// section doesn't become one enormous EPUB page that crawls on e-ink
+// hardware. Splits happen at paragraph (block) boundaries only; a single
+// block larger than the limit stays whole. IDs are reassigned in document
+// order. A maxChars of zero or less returns the input unchanged.
+func SplitLargeSections(sections []Section, maxChars int) []Section {
+	if maxChars <= 0 {
+		return sections
+	}
+
+	var result []Section
+	for _, section := range sections {
+		if len(section.Content) <= maxChars {
+			result = append(result, section)
+			continue
+		}
+
+		blocks := splitBlocks(section.Content)
+		var chunk strings.Builder
+		first := true
+		flush := func() {
+			if chunk.Len() == 0 {
+				return
+			}
+			page := section
+			page.Content = chunk.String()
+			if !first {
+				page.Title = section.Title + " (cont.)"
+			}
+			result = append(result, page)
+			first = false
+			chunk.Reset()
+		}
+
+		for _, block := range blocks {
+			if chunk.Len() > 0 && chunk.Len()+len(block)+1 > maxChars {
+				flush()
+			}
+			if chunk.Len() > 0 {
+				chunk.WriteByte('\n')
+			}
+			chunk.WriteString(block)
+		}
+		flush()
+	}
+
+	for i := range result {
+		result[i].ID = int64(i + 1)
+	}
+	return result
+}
+
+// blockClosers are line suffixes that end a paragraph-level block, i.e. safe
+// points to break a section into continuation pages.
+var blockClosers = []string{"</p>", "</ul>", "</ol>", "</pre>", "</blockquote>", "</table>", "</div>", "<hr/>", "<hr>"}
+
+// splitBlocks groups a section's content lines into paragraph-level blocks.
+func splitBlocks(content string) []string {
+	var blocks []string
+	var current strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		if current.Len() > 0 {
+			current.WriteByte('\n')
+		}
+		current.WriteString(line)
+
+		trimmed := strings.TrimSpace(line)
+		for _, closer := range blockClosers {
+			if strings.HasSuffix(trimmed, closer) {
+				blocks = append(blocks, current.String())
+				current.Reset()
+				break
+			}
+		}
+	}
+	if current.Len() > 0 {
+		blocks = append(blocks, current.String())
+	}
+	return blocks
 }
 
 // splitIntoSections splits HTML content into sections based on headings.
-// Each section starts with a heading and includes all content until the next heading.
+// Each section starts with a heading and includes all content until the next
+// heading.
+//
+// Preamble rule: content before the first heading becomes an implicit
+// "Introduction" section at level 1, emitted only when it has non-empty
+// content — an all-preamble document yields just that one section, and a
+// document starting with a heading gets no preamble at all. IDs are assigned
+// sequentially from 1 after all sections are collected, so they are always
+// contiguous and in document order regardless of whether a preamble exists.
 func splitIntoSections(html string) []Section {
 	var sections []Section
-	var currentSection *Section
-	var idCounter int64 = 1
+	var current *Section
+	preamble := false
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Content = strings.TrimSpace(current.Content)
+		// An empty implicit preamble would just clutter the nav; heading
+		// sections are kept even without content.
+		if !preamble || current.Content != "" {
+			sections = append(sections, *current)
+		}
+		current = nil
+	}
 
-	lines := strings.Split(html, "\n")
-	for _, line := range lines {
+	for _, line := range strings.Split(html, "\n") {
 		line = strings.TrimSpace(line)
 
-		// Check if line contains a heading tag (h1-h6)
-		if strings.HasPrefix(line, "<h") && strings.Contains(line, ">") {
-			// If we were building a section, add it to the list
-			if currentSection != nil {
-				currentSection.Content = strings.TrimSpace(currentSection.Content)
-				sections = append(sections, *currentSection)
+		// A page-break sentinel forces a new page that continues the current
+		// section's title; the sentinel itself never reaches the output. One
+		// before any content at all has nothing to break and is dropped.
+		if line == pageBreakSentinelHTML {
+			if current == nil {
+				continue
 			}
+			title, level := current.Title, current.Level
+			flush()
+			preamble = false
+			current = &Section{Title: title, Level: level}
+			continue
+		}
 
-			// Start new section
-			currentSection = &Section{
-				ID: idCounter,
+		// Check if line contains a heading tag (h1-h6)
+		if strings.HasPrefix(line, "<h") && strings.Contains(line, ">") {
+			flush()
+			preamble = false
+			current = &Section{Level: headingLevel(line)}
+			if m := reHeadingID.FindStringSubmatch(line); m != nil {
+				current.HeadingID = m[1]
 			}
-			idCounter++
 
 			// Extract title from heading tag
 			startIdx := strings.Index(line, ">") + 1
 			endIdx := strings.LastIndex(line, "<")
 			if startIdx > 0 && endIdx > startIdx {
-				currentSection.Title = strings.TrimSpace(line[startIdx:endIdx])
+				current.Title = strings.TrimSpace(line[startIdx:endIdx])
 			} else {
-				currentSection.Title = fmt.Sprintf("Section %d", idCounter-1)
-			}
-		} else if currentSection != nil {
-			// Add content to current section
-			if currentSection.Content == "" {
-				currentSection.Content = line
-			} else {
-				currentSection.Content = fmt.Sprintf("%s\n%s", currentSection.Content, line)
+				current.Title = fmt.Sprintf("Section %d", len(sections)+1)
 			}
+			continue
+		}
+
+		if current == nil {
+			// Content before the first heading: start the implicit preamble.
+			preamble = true
+			current = &Section{Title: "Introduction", Level: 1}
+		}
+		if current.Content == "" {
+			current.Content = line
 		} else {
-			// Content before first heading - create a default section
-			currentSection = &Section{
-				ID:    idCounter,
-				Title: "Introduction",
-			}
-			idCounter++
-			currentSection.Content = line
+			current.Content = fmt.Sprintf("%s\n%s", current.Content, line)
 		}
 	}
+	flush()
 
-	// Add the last section if it exists
-	if currentSection != nil && strings.TrimSpace(currentSection.Content) != "" {
-		currentSection.Content = strings.TrimSpace(currentSection.Content)
-		sections = append(sections, *currentSection)
+	for i := range sections {
+		sections[i].ID = int64(i + 1)
 	}
-
 	return sections
 }
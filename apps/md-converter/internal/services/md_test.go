@@ -1,6 +1,7 @@
 package services
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -114,6 +115,78 @@ func TestParseMD_GFMFeatures(t *testing.T) {
 	// This test is just to ensure GFM parsing doesn't fail
 }
 
+func TestParseMD_HeadingLevels(t *testing.T) {
+	md := "# One\ntext\n\n## Two\ntext\n\n### Three\ntext\n"
+
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if len(sections) != 3 {
+		t.Fatalf("got %d sections, want 3", len(sections))
+	}
+
+	wantLevels := []int{1, 2, 3}
+	for i, section := range sections {
+		if section.Level != wantLevels[i] {
+			t.Errorf("section %d level: got %d, want %d", i, section.Level, wantLevels[i])
+		}
+	}
+}
+
+func TestNumberSections_Hierarchy(t *testing.T) {
+	sections := []Section{
+		{Title: "Intro", Level: 1},
+		{Title: "Setup", Level: 2},
+		{Title: "Config", Level: 2},
+		{Title: "Details", Level: 3},
+		{Title: "Usage", Level: 1},
+	}
+
+	numbered := NumberSections(sections)
+
+	want := []string{
+		"1 Intro",
+		"1.1 Setup",
+		"1.2 Config",
+		"1.2.1 Details",
+		"2 Usage",
+	}
+	for i, section := range numbered {
+		if section.Title != want[i] {
+			t.Errorf("section %d: got %q, want %q", i, section.Title, want[i])
+		}
+	}
+
+	// Input must be untouched.
+	if sections[0].Title != "Intro" {
+		t.Errorf("input was modified: %q", sections[0].Title)
+	}
+}
+
+func TestNumberSections_SkippedLevels(t *testing.T) {
+	sections := []Section{
+		{Title: "Top", Level: 1},
+		{Title: "Deep", Level: 3}, // h1 → h3 with no h2
+		{Title: "Deeper Sibling", Level: 3},
+		{Title: "Back Up", Level: 2},
+	}
+
+	numbered := NumberSections(sections)
+
+	want := []string{
+		"1 Top",
+		"1.1.1 Deep", // the missing h2 gets an implicit 1
+		"1.1.2 Deeper Sibling",
+		"1.2 Back Up",
+	}
+	for i, section := range numbered {
+		if section.Title != want[i] {
+			t.Errorf("section %d: got %q, want %q", i, section.Title, want[i])
+		}
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (len(substr) == 0 || indexOf(s, substr) != -1)
 }
@@ -126,3 +199,435 @@ func indexOf(s, substr string) int {
 	}
 	return -1
 }
+
+func TestParseMD_StripsHTMLComments(t *testing.T) {
+	md := "# One\n\nvisible text\n\n<!-- TOC -->\n\nmore text\n"
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	for _, s := range sections {
+		if strings.Contains(s.Content, "TOC") {
+			t.Errorf("comment survived into section %q: %s", s.Title, s.Content)
+		}
+	}
+}
+
+func TestParseMD_CommentSpanningSectionBoundary(t *testing.T) {
+	// The comment wraps raw HTML containing a heading tag; without stripping
+	// before the section split, the half-open comment would leak into two
+	// sections and the commented-out heading would become a section.
+	md := "# One\n\ntext\n\n<div>\n<!-- hidden block\n<h1>Not a real section</h1>\nstill hidden -->\n</div>\n\n# Two\n\nmore\n"
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+
+	var titles []string
+	for _, s := range sections {
+		titles = append(titles, s.Title)
+		if strings.Contains(s.Content, "hidden") || strings.Contains(s.Content, "<!--") {
+			t.Errorf("comment content leaked into section %q: %s", s.Title, s.Content)
+		}
+	}
+	want := []string{"One", "Two"}
+	if len(titles) != len(want) {
+		t.Fatalf("got sections %v, want %v", titles, want)
+	}
+	for i := range want {
+		if titles[i] != want[i] {
+			t.Errorf("section %d: got %q, want %q", i, titles[i], want[i])
+		}
+	}
+}
+
+func TestParseMD_CommentInCodeBlockKept(t *testing.T) {
+	md := "# One\n\n```\n<!-- not a comment -->\n```\n"
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if len(sections) != 1 {
+		t.Fatalf("got %d sections, want 1", len(sections))
+	}
+	// goldmark escapes code block content, so the text must survive intact.
+	if !strings.Contains(sections[0].Content, "&lt;!-- not a comment --&gt;") {
+		t.Errorf("escaped comment text missing from code block: %s", sections[0].Content)
+	}
+}
+
+func TestSplitIntoSections_PreambleOnly(t *testing.T) {
+	sections, err := ParseMD([]byte("Just some notes.\n\nNo headings at all.\n"))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if len(sections) != 1 {
+		t.Fatalf("got %d sections, want 1", len(sections))
+	}
+	if sections[0].Title != "Introduction" || sections[0].ID != 1 {
+		t.Errorf("preamble: got title %q id %d, want Introduction / 1", sections[0].Title, sections[0].ID)
+	}
+}
+
+func TestSplitIntoSections_HeadingOnly(t *testing.T) {
+	sections, err := ParseMD([]byte("# One\n\n# Two\n"))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("got %d sections, want 2", len(sections))
+	}
+	for i, want := range []string{"One", "Two"} {
+		if sections[i].Title != want {
+			t.Errorf("section %d: got %q, want %q", i, sections[i].Title, want)
+		}
+		if sections[i].ID != int64(i+1) {
+			t.Errorf("section %d id: got %d, want %d", i, sections[i].ID, i+1)
+		}
+	}
+}
+
+func TestSplitIntoSections_MixedIDsContiguous(t *testing.T) {
+	sections, err := ParseMD([]byte("preamble text\n\n# One\n\nbody\n\n# Two\n\nmore\n"))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	wantTitles := []string{"Introduction", "One", "Two"}
+	if len(sections) != len(wantTitles) {
+		t.Fatalf("got %d sections, want %d", len(sections), len(wantTitles))
+	}
+	for i, want := range wantTitles {
+		if sections[i].Title != want {
+			t.Errorf("section %d: got %q, want %q", i, sections[i].Title, want)
+		}
+		if sections[i].ID != int64(i+1) {
+			t.Errorf("section %d id: got %d, want %d", i, sections[i].ID, i+1)
+		}
+	}
+}
+
+func TestSplitLargeSections_ContinuationPages(t *testing.T) {
+	// Ten ~100-char paragraphs under one heading; a 350-char limit should
+	// yield pages of three paragraphs each: 1 original + 3 continuations.
+	para := "<p>" + strings.Repeat("x", 93) + "</p>" // 100 chars with tags
+	content := para
+	for i := 1; i < 10; i++ {
+		content += "\n" + para
+	}
+	sections := []Section{{ID: 1, Title: "Huge", Level: 1, Content: content}}
+
+	split := SplitLargeSections(sections, 350)
+
+	if len(split) != 4 {
+		t.Fatalf("got %d pages, want 4", len(split))
+	}
+	if split[0].Title != "Huge" {
+		t.Errorf("first page title: got %q, want %q", split[0].Title, "Huge")
+	}
+	for i := 1; i < len(split); i++ {
+		if split[i].Title != "Huge (cont.)" {
+			t.Errorf("page %d title: got %q, want %q", i, split[i].Title, "Huge (cont.)")
+		}
+		if split[i].Level != 1 {
+			t.Errorf("page %d level: got %d, want 1", i, split[i].Level)
+		}
+		if split[i].ID != int64(i+1) {
+			t.Errorf("page %d id: got %d, want %d", i, split[i].ID, i+1)
+		}
+	}
+
+	// No content may be lost across the split.
+	total := 0
+	for _, s := range split {
+		total += strings.Count(s.Content, "<p>")
+	}
+	if total != 10 {
+		t.Errorf("got %d paragraphs across pages, want 10", total)
+	}
+}
+
+func TestSplitLargeSections_SmallSectionsUntouched(t *testing.T) {
+	sections := []Section{
+		{ID: 1, Title: "One", Level: 1, Content: "<p>short</p>"},
+		{ID: 2, Title: "Two", Level: 1, Content: "<p>also short</p>"},
+	}
+	split := SplitLargeSections(sections, 1000)
+	if len(split) != 2 {
+		t.Fatalf("got %d sections, want 2", len(split))
+	}
+	if split[0].Content != sections[0].Content || split[1].Content != sections[1].Content {
+		t.Error("small sections were modified")
+	}
+}
+
+func TestSplitLargeSections_OversizedBlockStaysWhole(t *testing.T) {
+	big := "<p>" + strings.Repeat("y", 500) + "</p>"
+	sections := []Section{{ID: 1, Title: "Block", Level: 1, Content: big + "\n<p>tail</p>"}}
+
+	split := SplitLargeSections(sections, 100)
+	if len(split) != 2 {
+		t.Fatalf("got %d pages, want 2", len(split))
+	}
+	if split[0].Content != big {
+		t.Errorf("oversized block was broken up: %d chars", len(split[0].Content))
+	}
+}
+
+func TestConvert_MaxSectionCharsCountsPages(t *testing.T) {
+	md := "# Huge\n\n"
+	for i := 0; i < 10; i++ {
+		md += strings.Repeat("word ", 30) + "\n\n"
+	}
+
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Test", MaxSectionChars: 400})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if result.EPUBSections < 2 {
+		t.Errorf("oversized section not paginated: %d pages", result.EPUBSections)
+	}
+}
+
+func TestParseMDWithHighlighting_ClassedSpans(t *testing.T) {
+	md := "# Code\n\n```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n"
+
+	sections, err := ParseMDWithHighlighting([]byte(md), true)
+	if err != nil {
+		t.Fatalf("ParseMDWithHighlighting: %v", err)
+	}
+	if len(sections) != 1 {
+		t.Fatalf("got %d sections, want 1", len(sections))
+	}
+
+	content := sections[0].Content
+	if !strings.Contains(content, "chroma") {
+		t.Errorf("highlighted block missing chroma wrapper: %s", content)
+	}
+	if !strings.Contains(content, `<span class=`) {
+		t.Errorf("highlighted block has no classed spans: %s", content)
+	}
+	if strings.Contains(content, "style=\"color") {
+		t.Errorf("highlighting emitted inline colors instead of classes: %s", content)
+	}
+
+	// Off by default: plain escaped code, no spans.
+	plain, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if strings.Contains(plain[0].Content, `<span class=`) {
+		t.Errorf("default parse produced highlight spans: %s", plain[0].Content)
+	}
+}
+
+func TestParseMD_RawHTMLDroppedByDefault(t *testing.T) {
+	md := "# Doc\n\nbefore\n\n<div onclick=\"evil()\">clickable</div>\n\nafter\n"
+
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if len(sections) != 1 {
+		t.Fatalf("got %d sections, want 1", len(sections))
+	}
+	if strings.Contains(sections[0].Content, "onclick") || strings.Contains(sections[0].Content, "<div") {
+		t.Errorf("raw HTML survived default parse:\n%s", sections[0].Content)
+	}
+}
+
+func TestConvert_UnsafeHTMLSanitized(t *testing.T) {
+	md := "# Doc\n\n<div class=\"note\" onclick=\"evil()\">embedded markup</div>\n"
+
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Test", UnsafeHTML: true})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/doc.xhtml")
+	if !strings.Contains(page, `<div class="note"`) {
+		t.Errorf("raw HTML missing with UnsafeHTML on:\n%s", page)
+	}
+	if strings.Contains(page, "onclick") {
+		t.Errorf("event handler survived sanitization:\n%s", page)
+	}
+}
+
+func TestSanitizeHTML_EventAttributes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`<div onclick="evil()">x</div>`, `<div>x</div>`},
+		{`<img src="a.png" onerror='evil()'/>`, `<img src="a.png"/>`},
+		{`<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		// Escaped code samples mentioning handlers are content, not markup.
+		{`<code>&lt;div onclick=&quot;f()&quot;&gt;</code>`, `<code>&lt;div onclick=&quot;f()&quot;&gt;</code>`},
+	}
+	for _, tt := range tests {
+		if got := sanitizeHTML(tt.in); got != tt.want {
+			t.Errorf("sanitizeHTML(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseMD_FootnotesCollectedIntoNotes(t *testing.T) {
+	md := "# Alpha\n\nFirst claim.[^1] Second claim.[^2]\n\n[^1]: First source.\n[^2]: Second source.\n"
+
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("got %d sections, want 2 (Alpha + Notes)", len(sections))
+	}
+
+	notes := sections[1]
+	if notes.Title != "Notes" {
+		t.Errorf("notes title: got %q, want Notes", notes.Title)
+	}
+	for _, want := range []string{`id="fn:1"`, `id="fn:2"`, "First source.", "Second source.", "footnote-backref"} {
+		if !strings.Contains(notes.Content, want) {
+			t.Errorf("Notes section missing %q:\n%s", want, notes.Content)
+		}
+	}
+	if strings.Contains(sections[0].Content, "First source.") {
+		t.Errorf("footnote definitions left in body section:\n%s", sections[0].Content)
+	}
+	if !strings.Contains(sections[0].Content, `id="fnref:1"`) {
+		t.Errorf("body section missing footnote reference:\n%s", sections[0].Content)
+	}
+}
+
+func TestParseMD_NoFootnotesNoNotesSection(t *testing.T) {
+	sections, err := ParseMD([]byte("# Alpha\n\nplain body\n"))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	for _, s := range sections {
+		if s.Title == "Notes" {
+			t.Errorf("unexpected Notes section: %v", sections)
+		}
+	}
+}
+
+func TestConvert_FootnoteLinksResolveAcrossFiles(t *testing.T) {
+	md := "# Alpha\n\nA claim.[^1]\n\n[^1]: The source.\n"
+
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Test"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	body := readEPUBEntry(t, result.EPUBData, "OEBPS/alpha.xhtml")
+	if !strings.Contains(body, `href="notes.xhtml#fn:1"`) {
+		t.Errorf("reference not resolved to notes file:\n%s", body)
+	}
+
+	notes := readEPUBEntry(t, result.EPUBData, "OEBPS/notes.xhtml")
+	if !strings.Contains(notes, `href="alpha.xhtml#fnref:1"`) {
+		t.Errorf("back-link not resolved to body file:\n%s", notes)
+	}
+	if !strings.Contains(notes, "The source.") {
+		t.Errorf("definition missing from notes page:\n%s", notes)
+	}
+}
+
+func TestMergeTinySections_CoalescesRun(t *testing.T) {
+	long := "<p>" + strings.Repeat("body ", 50) + "</p>"
+	sections := []Section{
+		{ID: 1, Title: "Chapter", Level: 1, Content: "<p>lead-in line</p>"},
+		{ID: 2, Title: "Sub A", Level: 2, Content: "<p>one line</p>"},
+		{ID: 3, Title: "Sub B", Level: 2, Content: long},
+	}
+
+	merged := MergeTinySections(sections, 60)
+	if len(merged) != 1 {
+		t.Fatalf("got %d sections, want 1: %+v", len(merged), merged)
+	}
+	page := merged[0]
+	if page.Title != "Chapter" || page.Level != 1 {
+		t.Errorf("merged page identity: got %q/%d, want Chapter/1", page.Title, page.Level)
+	}
+	for _, want := range []string{"lead-in line", "<h2>Sub A</h2>", "one line", "<h2>Sub B</h2>", "body"} {
+		if !strings.Contains(page.Content, want) {
+			t.Errorf("merged content missing %q:\n%s", want, page.Content)
+		}
+	}
+	if page.ID != 1 {
+		t.Errorf("merged ID: got %d, want 1", page.ID)
+	}
+}
+
+func TestMergeTinySections_ShallowerFollowingHeadingWins(t *testing.T) {
+	long := "<p>" + strings.Repeat("body ", 50) + "</p>"
+	sections := []Section{
+		{ID: 1, Title: "Orphan Sub", Level: 2, Content: "<p>stub</p>"},
+		{ID: 2, Title: "Chapter", Level: 1, Content: long},
+	}
+
+	merged := MergeTinySections(sections, 60)
+	if len(merged) != 1 {
+		t.Fatalf("got %d sections, want 1", len(merged))
+	}
+	if merged[0].Title != "Chapter" || merged[0].Level != 1 {
+		t.Errorf("merged identity: got %q/%d, want Chapter/1", merged[0].Title, merged[0].Level)
+	}
+	if !strings.Contains(merged[0].Content, "<h2>Orphan Sub</h2>") {
+		t.Errorf("orphan heading not inlined:\n%s", merged[0].Content)
+	}
+	if idx := strings.Index(merged[0].Content, "<h2>Orphan Sub</h2>"); idx != 0 {
+		t.Errorf("orphan heading not at start of merged content:\n%s", merged[0].Content)
+	}
+}
+
+func TestMergeTinySections_Disabled(t *testing.T) {
+	sections := []Section{
+		{ID: 1, Title: "A", Level: 1, Content: "<p>x</p>"},
+		{ID: 2, Title: "B", Level: 1, Content: "<p>y</p>"},
+	}
+	merged := MergeTinySections(sections, 0)
+	if len(merged) != 2 {
+		t.Errorf("got %d sections, want 2", len(merged))
+	}
+}
+
+func TestConvert_MinSectionCharsMergesPages(t *testing.T) {
+	md := "# Guide\n\n" + strings.Repeat("intro ", 50) + "\n\n## Tip A\n\nshort\n\n## Tip B\n\nalso short\n\n# Reference\n\n" + strings.Repeat("detail ", 50) + "\n"
+
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Test", MinSectionChars: 40})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	// The two one-line tips cannot stand alone at this threshold.
+	if result.SectionCount >= 4 {
+		t.Errorf("SectionCount = %d, want < 4 after merging", result.SectionCount)
+	}
+}
+
+func TestParseMDWithDefinitionLists(t *testing.T) {
+	md := "# Glossary\n\nEPUB\n: An e-book file format.\n"
+
+	sections, err := ParseMDWithDefinitionLists([]byte(md), false, false, true)
+	if err != nil {
+		t.Fatalf("ParseMDWithDefinitionLists: %v", err)
+	}
+	if len(sections) != 1 {
+		t.Fatalf("got %d sections, want 1", len(sections))
+	}
+	content := sections[0].Content
+	if !strings.Contains(content, "<dl>") || !strings.Contains(content, "<dt>EPUB</dt>") {
+		t.Errorf("definition list not rendered:\n%s", content)
+	}
+	if !strings.Contains(content, "An e-book file format.") {
+		t.Errorf("definition body lost:\n%s", content)
+	}
+
+	// Off by default: the same input stays plain paragraphs.
+	sections, err = ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if strings.Contains(sections[0].Content, "<dl>") {
+		t.Errorf("definition list rendered without the option:\n%s", sections[0].Content)
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// rePreBlock matches whole <pre> blocks, whose content is whitespace-
+	// sensitive and must pass through minification untouched.
+	rePreBlock = regexp.MustCompile(`(?s)<pre[^>]*>.*?</pre>`)
+
+	// reTagNewline matches inter-tag whitespace containing a newline — the
+	// indentation goldmark and the HTML parser leave behind — which can be
+	// removed entirely. Runs of plain spaces between tags may separate inline
+	// elements, so reTagSpaces only collapses them to a single space.
+	reTagNewline = regexp.MustCompile(`>\s*\n\s*<`)
+	reTagSpaces  = regexp.MustCompile(`>[ \t]{2,}<`)
+
+	// reEmptyPara matches paragraphs holding nothing but whitespace and line
+	// breaks; hard-wrap rendering occasionally emits these and they show up
+	// as blank vertical space on small screens.
+	reEmptyPara = regexp.MustCompile(`<p>(?:\s|<br\s*/?>)*</p>`)
+
+	// reBrRun matches three or more consecutive <br/> tags, which read as an
+	// accidental page gap; two are kept as an intentional blank line.
+	reBrRun = regexp.MustCompile(`(?:<br\s*/?>\s*){3,}`)
+)
+
+// MinifySections minifies the HTML content of every section for output:
+// inter-tag whitespace is collapsed, empty paragraphs are dropped, and runs
+// of three or more <br/> are merged down to two. <pre> blocks are preserved
+// byte for byte. Returns the minified sections and the total content size in
+// bytes before and after.
+func MinifySections(sections []Section) (result []Section, before, after int) {
+	result = make([]Section, len(sections))
+	for i, section := range sections {
+		before += len(section.Content)
+		section.Content = minifyHTML(section.Content)
+		after += len(section.Content)
+		result[i] = section
+	}
+	return result, before, after
+}
+
+// minifyHTML minifies one section's HTML, splitting around <pre> blocks so
+// their whitespace-sensitive content is never rewritten.
+func minifyHTML(content string) string {
+	var b strings.Builder
+	b.Grow(len(content))
+	last := 0
+	for _, loc := range rePreBlock.FindAllStringIndex(content, -1) {
+		b.WriteString(minifyFragment(content[last:loc[0]]))
+		b.WriteString(content[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(minifyFragment(content[last:]))
+	return b.String()
+}
+
+// minifyFragment applies the minification rules to a pre-free HTML fragment.
+func minifyFragment(fragment string) string {
+	fragment = reTagNewline.ReplaceAllString(fragment, "><")
+	fragment = reTagSpaces.ReplaceAllString(fragment, "> <")
+	fragment = reEmptyPara.ReplaceAllString(fragment, "")
+	fragment = reBrRun.ReplaceAllString(fragment, "<br/><br/>")
+	return fragment
+}
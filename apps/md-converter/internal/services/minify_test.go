@@ -0,0 +1,76 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMinifyHTML_CollapsesInterTagWhitespace(t *testing.T) {
+	in := "<ul>\n  <li>one</li>\n  <li>two</li>\n</ul>"
+	got := minifyHTML(in)
+	want := "<ul><li>one</li><li>two</li></ul>"
+	if got != want {
+		t.Errorf("minifyHTML(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestMinifyHTML_KeepsInlineSeparatingSpace(t *testing.T) {
+	in := "<em>one</em>   <strong>two</strong>"
+	got := minifyHTML(in)
+	want := "<em>one</em> <strong>two</strong>"
+	if got != want {
+		t.Errorf("minifyHTML(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestMinifyHTML_DropsEmptyParagraphs(t *testing.T) {
+	in := "<p>text</p>\n<p></p>\n<p> \n <br/> </p>\n<p>more</p>"
+	got := minifyHTML(in)
+	if strings.Contains(got, "<p></p>") || strings.Contains(got, "<p> ") {
+		t.Errorf("empty paragraphs survived: %q", got)
+	}
+	if !strings.Contains(got, "<p>text</p>") || !strings.Contains(got, "<p>more</p>") {
+		t.Errorf("non-empty paragraphs damaged: %q", got)
+	}
+}
+
+func TestMinifyHTML_MergesBrRuns(t *testing.T) {
+	in := "<p>one<br/><br/><br/><br/>two</p>"
+	got := minifyHTML(in)
+	want := "<p>one<br/><br/>two</p>"
+	if got != want {
+		t.Errorf("minifyHTML(%q) = %q, want %q", in, got, want)
+	}
+
+	// Two breaks are an intentional blank line and stay.
+	in = "<p>one<br/><br/>two</p>"
+	if got := minifyHTML(in); got != in {
+		t.Errorf("minifyHTML(%q) = %q, want unchanged", in, got)
+	}
+}
+
+func TestMinifyHTML_PreContentByteIdentical(t *testing.T) {
+	pre := "<pre><code>line one\n    indented\n\n\n<br/><br/><br/>\n  trailing  spaces  </code></pre>"
+	in := "<p>before</p>\n\n" + pre + "\n\n<p></p><p>after</p>"
+	got := minifyHTML(in)
+	if !strings.Contains(got, pre) {
+		t.Errorf("<pre> block was rewritten:\ngot  %q\nwant to contain %q", got, pre)
+	}
+	if strings.Contains(got, "<p></p>") {
+		t.Errorf("empty paragraph outside <pre> survived: %q", got)
+	}
+}
+
+func TestConvert_ReportsMinificationSizes(t *testing.T) {
+	md := "# Title\n\nSome text.\n\n- one\n- two\n- three\n"
+	result, err := Convert([]byte(md), ConvertOptions{})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if result.ContentBytes == 0 {
+		t.Errorf("ContentBytes = 0, want > 0")
+	}
+	if result.MinifiedBytes == 0 || result.MinifiedBytes > result.ContentBytes {
+		t.Errorf("MinifiedBytes = %d, want in (0, %d]", result.MinifiedBytes, result.ContentBytes)
+	}
+}
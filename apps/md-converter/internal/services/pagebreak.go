@@ -0,0 +1,49 @@
+package services
+
+import "strings"
+
+// defaultPageBreakMarker is the line authors write to force a new page. The
+// HTML-comment spelling "<!-- pagebreak -->" is always recognised alongside
+// it, since some Markdown tooling hides unknown backslash commands.
+const defaultPageBreakMarker = `\pagebreak`
+
+// pageBreakCommentMarker is the alternate HTML-comment spelling. It has to be
+// rewritten before goldmark runs: in safe mode raw HTML is dropped entirely,
+// so the comment would never reach the splitter.
+const pageBreakCommentMarker = "<!-- pagebreak -->"
+
+// pageBreakSentinel is the token marker lines are rewritten to. goldmark
+// renders it as a paragraph of plain text, which splitIntoSections recognises
+// and removes.
+const pageBreakSentinel = "mdconverter-pagebreak-8f2e"
+
+// pageBreakSentinelHTML is the sentinel as rendered by goldmark.
+const pageBreakSentinelHTML = "<p>" + pageBreakSentinel + "</p>"
+
+// applyPageBreakMarkers rewrites page-break marker lines to the sentinel the
+// section splitter understands. Only a top-level line containing exactly the
+// marker counts: markers inside list items carry a bullet prefix or
+// indentation, and lines inside fenced code blocks are skipped, so neither is
+// rewritten. Blank lines are added around the sentinel so an adjacent text
+// line can't absorb it into the same paragraph under hard-wrap rendering.
+func applyPageBreakMarkers(data []byte, marker string) []byte {
+	if marker == "" {
+		marker = defaultPageBreakMarker
+	}
+
+	lines := strings.Split(string(data), "\n")
+	out := make([]string, 0, len(lines))
+	inFence := false
+	for _, line := range lines {
+		trimmed := strings.TrimRight(line, "\r")
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence && (trimmed == marker || trimmed == pageBreakCommentMarker) {
+			out = append(out, "", pageBreakSentinel, "")
+			continue
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n"))
+}
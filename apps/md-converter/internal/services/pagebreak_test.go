@@ -0,0 +1,75 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMD_PageBreakMarkerSplitsSection(t *testing.T) {
+	md := "# Chapter\n\nFirst page.\n\n\\pagebreak\n\nSecond page.\n"
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("len(sections) = %d, want 2", len(sections))
+	}
+	if sections[0].Title != "Chapter" || sections[1].Title != "Chapter" {
+		t.Errorf("titles = %q, %q, want both \"Chapter\"", sections[0].Title, sections[1].Title)
+	}
+	if !strings.Contains(sections[0].Content, "First page") || !strings.Contains(sections[1].Content, "Second page") {
+		t.Errorf("content split wrong:\n[0] %q\n[1] %q", sections[0].Content, sections[1].Content)
+	}
+	for i, s := range sections {
+		if strings.Contains(s.Content, "pagebreak") {
+			t.Errorf("sections[%d] still contains the marker: %q", i, s.Content)
+		}
+	}
+}
+
+func TestParseMD_PageBreakCommentForm(t *testing.T) {
+	md := "# Chapter\n\nFirst page.\n\n<!-- pagebreak -->\n\nSecond page.\n"
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("len(sections) = %d, want 2", len(sections))
+	}
+}
+
+func TestParseMD_PageBreakInsideListIgnored(t *testing.T) {
+	md := "# Chapter\n\n- one\n- \\pagebreak\n- two\n"
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if len(sections) != 1 {
+		t.Fatalf("len(sections) = %d, want 1 (list marker must not split)", len(sections))
+	}
+}
+
+func TestParseMD_PageBreakInsideFenceIgnored(t *testing.T) {
+	md := "# Chapter\n\n```\n\\pagebreak\n```\n"
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+	if len(sections) != 1 {
+		t.Fatalf("len(sections) = %d, want 1 (fenced marker must not split)", len(sections))
+	}
+	if !strings.Contains(sections[0].Content, `\pagebreak`) {
+		t.Errorf("fenced marker text should survive verbatim: %q", sections[0].Content)
+	}
+}
+
+func TestParseSections_CustomPageBreakMarker(t *testing.T) {
+	md := "# Chapter\n\nFirst page.\n\n<<<break>>>\n\nSecond page.\n"
+	sections, err := ParseSections([]byte(md), ConvertOptions{PageBreakMarker: "<<<break>>>"})
+	if err != nil {
+		t.Fatalf("ParseSections: %v", err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("len(sections) = %d, want 2", len(sections))
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// reAnyTag matches any element tag, capturing the optional closing slash and
+// the tag name. Comments and doctypes don't match (no letter after "<").
+var reAnyTag = regexp.MustCompile(`(?s)<(/?)([a-zA-Z][a-zA-Z0-9]*)[^>]*>`)
+
+// voidElements never take a closing tag and stay off the open-tag stack.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// repairHTML rebalances the tags in a content fragment. splitIntoSections
+// slices rendered HTML at line boundaries, so an element opened before a
+// heading and closed after it leaves one section with an unclosed tag and the
+// next with a stray closer — both of which make strict EPUB readers reject
+// the XHTML. Open tags are tracked on a stack: a closer with no matching
+// opener is dropped, a closer that skips nested elements closes them first,
+// and anything still open at the end is closed in reverse order.
+func repairHTML(content string) string {
+	matches := reAnyTag.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return content
+	}
+
+	var sb strings.Builder
+	var stack []string
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(content[last:m[0]])
+		last = m[1]
+
+		tag := content[m[0]:m[1]]
+		name := strings.ToLower(content[m[4]:m[5]])
+		if m[3] == m[2] { // opening tag
+			sb.WriteString(tag)
+			if !voidElements[name] && !strings.HasSuffix(tag, "/>") {
+				stack = append(stack, name)
+			}
+			continue
+		}
+
+		open := -1
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i] == name {
+				open = i
+				break
+			}
+		}
+		if open < 0 {
+			continue // stray closer, drop it
+		}
+		for i := len(stack) - 1; i > open; i-- {
+			sb.WriteString("</" + stack[i] + ">")
+		}
+		sb.WriteString(tag)
+		stack = stack[:open]
+	}
+	sb.WriteString(content[last:])
+	for i := len(stack) - 1; i >= 0; i-- {
+		sb.WriteString("</" + stack[i] + ">")
+	}
+	return sb.String()
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRepairHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"balanced untouched", "<p>hello <em>world</em></p>", "<p>hello <em>world</em></p>"},
+		{"unclosed tags closed", "<ul>\n<li>item", "<ul>\n<li>item</li></ul>"},
+		{"stray closer dropped", "<li>after</li>\n</ul>", "<li>after</li>\n"},
+		{"interleaved closers reordered", "<b><i>text</b></i>", "<b><i>text</i></b>"},
+		{"void elements ignored", "<p>line<br/>img <img src=\"x.png\"/></p>", "<p>line<br/>img <img src=\"x.png\"/></p>"},
+		{"no tags", "plain text", "plain text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repairHTML(tt.in); got != tt.want {
+				t.Errorf("repairHTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// assertWellFormedXML fails when the page is not parseable XML.
+func assertWellFormedXML(t *testing.T, name, page string) {
+	t.Helper()
+	dec := xml.NewDecoder(strings.NewReader(page))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Errorf("%s is not well-formed XML: %v\n%s", name, err, page)
+			return
+		}
+	}
+}
+
+func TestConvertHTML_ElementsSpanningSectionsRepaired(t *testing.T) {
+	// The list and blockquote each open before a heading and close after it,
+	// so the splitter leaves unbalanced tags on both sides of the boundary.
+	input := "<h1>One</h1>\n<ul>\n<li>before</li>\n<h2>Two</h2>\n<li>after</li>\n</ul>\n<blockquote>\n<p>quoted</p>\n<h2>Three</h2>\n<p>still quoted</p>\n</blockquote>\n"
+	result, err := ConvertHTML([]byte(input), ConvertOptions{Title: "Test"})
+	if err != nil {
+		t.Fatalf("ConvertHTML: %v", err)
+	}
+
+	for _, stat := range result.SectionStats {
+		name := "OEBPS/" + stat.ID + ".xhtml"
+		assertWellFormedXML(t, name, readEPUBEntry(t, result.EPUBData, name))
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// maxReportedEntries caps how many of the largest entries a SizeWarning lists.
+const maxReportedEntries = 5
+
+// EntrySize names an EPUB zip entry and its compressed size in bytes.
+type EntrySize struct {
+	Name string
+	Size int
+}
+
+// SizeWarning reports that a generated EPUB exceeded the caller's size
+// budget, listing the largest entries so the user can see what to trim.
+// Automatic image downscaling will hook in here once image embedding lands.
+type SizeWarning struct {
+	Budget         int // the configured budget in bytes
+	Size           int // the actual EPUB size in bytes
+	LargestEntries []EntrySize
+}
+
+// Error-style message for display in the UI.
+func (w *SizeWarning) Message() string {
+	return fmt.Sprintf("EPUB is %d bytes, over the %d byte budget", w.Size, w.Budget)
+}
+
+// CheckSizeBudget inspects a generated EPUB against a size budget in bytes.
+// It returns nil when the EPUB fits (or budget is zero, meaning no budget),
+// and a SizeWarning listing the largest entries when it doesn't.
+func CheckSizeBudget(epubData []byte, budget int) (*SizeWarning, error) {
+	if budget <= 0 || len(epubData) <= budget {
+		return nil, nil
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(epubData), int64(len(epubData)))
+	if err != nil {
+		return nil, fmt.Errorf("inspect epub: %w", err)
+	}
+
+	entries := make([]EntrySize, 0, len(r.File))
+	for _, f := range r.File {
+		entries = append(entries, EntrySize{Name: f.Name, Size: int(f.CompressedSize64)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Name < entries[j].Name // stable order for equal sizes
+	})
+	if len(entries) > maxReportedEntries {
+		entries = entries[:maxReportedEntries]
+	}
+
+	return &SizeWarning{
+		Budget:         budget,
+		Size:           len(epubData),
+		LargestEntries: entries,
+	}, nil
+}
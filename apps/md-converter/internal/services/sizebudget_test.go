@@ -0,0 +1,66 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// largeSections returns sections big enough to blow a small budget.
+func largeSections() []Section {
+	content := strings.Repeat("<p>padding padding padding</p>\n", 500)
+	return []Section{
+		{ID: 1, Title: "Big One", Content: content},
+		{ID: 2, Title: "Big Two", Content: content + content},
+	}
+}
+
+func TestCheckSizeBudget_OverBudget(t *testing.T) {
+	data, err := GenerateEPUB(largeSections(), DevicePresets[0], "Big")
+	if err != nil {
+		t.Fatalf("GenerateEPUB: %v", err)
+	}
+
+	warning, err := CheckSizeBudget(data, 100) // absurdly small budget
+	if err != nil {
+		t.Fatalf("CheckSizeBudget: %v", err)
+	}
+	if warning == nil {
+		t.Fatal("expected a size warning, got nil")
+	}
+	if warning.Size != len(data) {
+		t.Errorf("warning size: got %d, want %d", warning.Size, len(data))
+	}
+	if len(warning.LargestEntries) == 0 {
+		t.Fatal("warning lists no entries")
+	}
+	for i := 1; i < len(warning.LargestEntries); i++ {
+		if warning.LargestEntries[i].Size > warning.LargestEntries[i-1].Size {
+			t.Errorf("entries not sorted by size: %v", warning.LargestEntries)
+		}
+	}
+}
+
+func TestCheckSizeBudget_WithinBudget(t *testing.T) {
+	data, err := GenerateEPUB(sampleSections(), DevicePresets[0], "Small")
+	if err != nil {
+		t.Fatalf("GenerateEPUB: %v", err)
+	}
+
+	warning, err := CheckSizeBudget(data, 10*1024*1024)
+	if err != nil {
+		t.Fatalf("CheckSizeBudget: %v", err)
+	}
+	if warning != nil {
+		t.Errorf("unexpected warning: %+v", warning)
+	}
+}
+
+func TestCheckSizeBudget_Disabled(t *testing.T) {
+	warning, err := CheckSizeBudget([]byte("tiny"), 0)
+	if err != nil {
+		t.Fatalf("CheckSizeBudget: %v", err)
+	}
+	if warning != nil {
+		t.Errorf("budget 0 should disable the check, got %+v", warning)
+	}
+}
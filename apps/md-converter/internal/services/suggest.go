@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Suggestion is one content-derived settings hint for the UI. ID is stable
+// per rule so dismissals can be remembered; Message is the human-readable
+// hint. Suggestions are advisory only — nothing acts on them automatically.
+type Suggestion struct {
+	ID      string
+	Message string
+}
+
+// Thresholds for SuggestSettings. Word counts are of stripped text; density
+// is per section, so short table- or code-heavy documents trigger too.
+const (
+	suggestLongWords    = 100000 // ≈ a 400-page paperback
+	suggestTableDensity = 0.5    // tables per section
+	suggestCodeDensity  = 0.5    // fenced code blocks per section
+	suggestImageCount   = 20
+)
+
+// SuggestSettings analyzes parsed sections and returns settings hints: a very
+// long document suggests section splitting, table- or code-heavy content
+// suggests a wider preset, and image-heavy content suggests a volume budget.
+// The result is empty for unremarkable documents.
+func SuggestSettings(sections []Section) []Suggestion {
+	if len(sections) == 0 {
+		return nil
+	}
+
+	words := 0
+	tables := 0
+	codeBlocks := 0
+	images := 0
+	for _, section := range sections {
+		words += CountWords(section.Content)
+		tables += strings.Count(section.Content, "<table")
+		codeBlocks += strings.Count(section.Content, "<pre")
+		images += strings.Count(section.Content, "<img")
+	}
+
+	var suggestions []Suggestion
+	if words >= suggestLongWords {
+		suggestions = append(suggestions, Suggestion{
+			ID: "long-document",
+			Message: fmt.Sprintf("Very long document (~%s words): consider enabling section splitting",
+				formatThousands(words)),
+		})
+	}
+	if float64(tables)/float64(len(sections)) >= suggestTableDensity {
+		suggestions = append(suggestions, Suggestion{
+			ID:      "table-heavy",
+			Message: "Heavy on tables: consider the Onyx Boox Page preset for its wider screen",
+		})
+	}
+	if float64(codeBlocks)/float64(len(sections)) >= suggestCodeDensity {
+		suggestions = append(suggestions, Suggestion{
+			ID:      "code-heavy",
+			Message: "Lots of code blocks: consider a large-screen preset so lines wrap less",
+		})
+	}
+	if images >= suggestImageCount {
+		suggestions = append(suggestions, Suggestion{
+			ID:      "image-heavy",
+			Message: fmt.Sprintf("%d images: consider setting a volume size budget to stay under device limits", images),
+		})
+	}
+	return suggestions
+}
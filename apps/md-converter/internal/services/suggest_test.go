@@ -0,0 +1,85 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// suggestionIDs returns just the rule IDs, for order-insensitive assertions.
+func suggestionIDs(suggestions []Suggestion) map[string]bool {
+	ids := make(map[string]bool, len(suggestions))
+	for _, s := range suggestions {
+		ids[s.ID] = true
+	}
+	return ids
+}
+
+func TestSuggestSettings_Unremarkable(t *testing.T) {
+	sections := []Section{
+		{Title: "Intro", Content: "<p>A short document.</p>"},
+		{Title: "Body", Content: "<p>Nothing unusual here.</p>"},
+	}
+	if got := SuggestSettings(sections); got != nil {
+		t.Errorf("SuggestSettings = %v, want nil", got)
+	}
+	if got := SuggestSettings(nil); got != nil {
+		t.Errorf("SuggestSettings(nil) = %v, want nil", got)
+	}
+}
+
+func TestSuggestSettings_LongDocument(t *testing.T) {
+	content := "<p>" + strings.Repeat("word ", 60000) + "</p>"
+	sections := []Section{
+		{Title: "A", Content: content},
+		{Title: "B", Content: content},
+	}
+	got := SuggestSettings(sections)
+	if !suggestionIDs(got)["long-document"] {
+		t.Fatalf("no long-document suggestion in %v", got)
+	}
+	for _, s := range got {
+		if s.ID == "long-document" && !strings.Contains(s.Message, "section splitting") {
+			t.Errorf("message %q does not mention section splitting", s.Message)
+		}
+	}
+}
+
+func TestSuggestSettings_TableHeavy(t *testing.T) {
+	sections := []Section{
+		{Title: "Specs", Content: "<table><tr><td>x</td></tr></table>"},
+		{Title: "More", Content: "<table><tr><td>y</td></tr></table>"},
+	}
+	got := SuggestSettings(sections)
+	if !suggestionIDs(got)["table-heavy"] {
+		t.Fatalf("no table-heavy suggestion in %v", got)
+	}
+	for _, s := range got {
+		if s.ID == "table-heavy" && !strings.Contains(s.Message, "Onyx Boox Page") {
+			t.Errorf("message %q does not name a preset", s.Message)
+		}
+	}
+}
+
+func TestSuggestSettings_CodeHeavy(t *testing.T) {
+	sections := []Section{
+		{Title: "Listing", Content: "<pre><code>x := 1</code></pre>"},
+	}
+	if got := SuggestSettings(sections); !suggestionIDs(got)["code-heavy"] {
+		t.Errorf("no code-heavy suggestion in %v", got)
+	}
+}
+
+func TestSuggestSettings_ImageHeavy(t *testing.T) {
+	imgs := strings.Repeat(`<img src="a.png" alt=""/>`, suggestImageCount)
+	sections := []Section{{Title: "Gallery", Content: imgs}}
+	got := SuggestSettings(sections)
+	if !suggestionIDs(got)["image-heavy"] {
+		t.Fatalf("no image-heavy suggestion in %v", got)
+	}
+
+	// One image fewer stays quiet.
+	few := []Section{{Title: "G", Content: strings.Repeat(`<img src="a.png"/>`, suggestImageCount-1)}}
+	if got := SuggestSettings(few); suggestionIDs(got)["image-heavy"] {
+		t.Errorf("unexpected image-heavy suggestion: %v", got)
+	}
+}
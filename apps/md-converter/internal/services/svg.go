@@ -0,0 +1,57 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Inline SVG policy: simple SVGs (badges, small diagrams) are kept — e-ink
+// panels render their colors as grays on their own — after stripping the
+// parts that are unsafe or unrenderable. <script> content goes with the rest
+// of the scripts in sanitizeHTML; <foreignObject> embeds arbitrary HTML that
+// most reading systems refuse to draw, so it is removed here. Readers with no
+// SVG support at all can opt into ReplaceSVGCaptions instead.
+var (
+	reSVGBlock      = regexp.MustCompile(`(?is)<svg\b[^>]*>.*?</svg>`)
+	reForeignObject = regexp.MustCompile(`(?is)<foreignObject\b[^>]*>.*?</foreignObject>`)
+	reSVGTitleTag   = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	reAriaLabel     = regexp.MustCompile(`(?i)\baria-label\s*=\s*("([^"]*)"|'([^']*)')`)
+)
+
+// sanitizeSVGs removes the SVG constructs the shared sanitizer doesn't cover.
+// It runs on every page from sanitizeHTML; documents without SVG pass through
+// untouched.
+func sanitizeSVGs(s string) string {
+	if !strings.Contains(strings.ToLower(s), "<svg") {
+		return s
+	}
+	return reForeignObject.ReplaceAllString(s, "")
+}
+
+// ReplaceSVGCaptions rewrites every inline SVG as a bracketed text caption,
+// mirroring what replaceImageFallbacks does for unembedded images: the
+// caption comes from the SVG's <title> child, then its aria-label, then plain
+// "[Image]". The input slice is not modified.
+func ReplaceSVGCaptions(sections []Section) []Section {
+	result := make([]Section, len(sections))
+	for i, section := range sections {
+		section.Content = reSVGBlock.ReplaceAllStringFunc(section.Content, svgCaption)
+		result[i] = section
+	}
+	return result
+}
+
+// svgCaption derives the replacement caption for one inline SVG.
+func svgCaption(svg string) string {
+	if m := reSVGTitleTag.FindStringSubmatch(svg); m != nil {
+		if title := stripTags(m[1]); title != "" {
+			return "[Image: " + title + "]"
+		}
+	}
+	if m := reAriaLabel.FindStringSubmatch(svg); m != nil {
+		if label := strings.TrimSpace(m[2] + m[3]); label != "" {
+			return "[Image: " + label + "]"
+		}
+	}
+	return "[Image]"
+}
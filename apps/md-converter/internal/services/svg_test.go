@@ -0,0 +1,94 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// badgeSVG is a shields.io-style status badge: nested rects, a gradient and
+// text, but nothing a sanitizer needs to touch.
+const badgeSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="90" height="20" aria-label="build: passing">
+<title>build: passing</title>
+<linearGradient id="b" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<rect rx="3" width="90" height="20" fill="#555"/>
+<rect rx="3" x="37" width="53" height="20" fill="#4c1"/>
+<g fill="#fff" text-anchor="middle" font-family="Verdana" font-size="11">
+<text x="19" y="14">build</text>
+<text x="62" y="14">passing</text>
+</g>
+</svg>`
+
+func TestSanitizeHTML_KeepsBadgeSVG(t *testing.T) {
+	got := sanitizeHTML("<p>status: " + badgeSVG + "</p>")
+	if !strings.Contains(got, "<svg") || !strings.Contains(got, "passing</text>") {
+		t.Errorf("simple badge SVG should survive sanitization:\n%s", got)
+	}
+}
+
+func TestSanitizeHTML_StripsSVGScriptAndForeignObject(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg">
+<script>alert("xss")</script>
+<foreignObject width="100" height="50"><div onclick="alert(1)">html island</div></foreignObject>
+<rect width="10" height="10" onload="alert(2)"/>
+<a xlink:href="javascript:alert(3)"><text>link</text></a>
+</svg>`
+	got := sanitizeHTML(svg)
+
+	if !strings.Contains(got, "<svg") || !strings.Contains(got, "<rect") {
+		t.Fatalf("SVG shell should survive sanitization:\n%s", got)
+	}
+	for _, banned := range []string{"<script", "foreignObject", "html island", "onload", "onclick", "javascript:"} {
+		if strings.Contains(got, banned) {
+			t.Errorf("sanitized SVG still contains %q:\n%s", banned, got)
+		}
+	}
+}
+
+func TestReplaceSVGCaptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "title child",
+			content: "<p>status: " + badgeSVG + "</p>",
+			want:    "<p>status: [Image: build: passing]</p>",
+		},
+		{
+			name:    "aria-label fallback",
+			content: `<svg aria-label="coverage chart"><rect width="5" height="5"/></svg>`,
+			want:    "[Image: coverage chart]",
+		},
+		{
+			name:    "no label at all",
+			content: `<svg><circle r="4"/></svg>`,
+			want:    "[Image]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections := ReplaceSVGCaptions([]Section{{Content: tt.content}})
+			if got := sections[0].Content; got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert_SVGCaptionsOption(t *testing.T) {
+	md := "# Status\n\nBuild health:\n\n" + badgeSVG + "\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Status", UnsafeHTML: true, SVGCaptions: true})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/status.xhtml")
+	if strings.Contains(page, "<svg") {
+		t.Errorf("SVG should be replaced when SVGCaptions is on:\n%s", page)
+	}
+	if !strings.Contains(page, "[Image: build: passing]") {
+		t.Errorf("caption missing from page:\n%s", page)
+	}
+}
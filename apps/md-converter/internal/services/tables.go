@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// stackTablesMaxWidth is the preset width in pixels below which tables are
+// rewritten when the StackTables option is on; wider screens can render real
+// tables legibly.
+const stackTablesMaxWidth = 600
+
+var (
+	reTableBlock = regexp.MustCompile(`(?s)<table[^>]*>.*?</table>`)
+	reTableRow   = regexp.MustCompile(`(?s)<tr[^>]*>(.*?)</tr>`)
+	reTableCell  = regexp.MustCompile(`(?s)<t[hd][^>]*>(.*?)</t[hd]>`)
+)
+
+// StackTables rewrites every table into stacked "header: value" definition
+// lists, one per row, so wide GFM tables stay readable on narrow e-ink
+// screens where columns would overflow. Presets at or above
+// stackTablesMaxWidth are returned unchanged — the fallback only kicks in
+// where it is needed. The input slice is not modified.
+func StackTables(sections []Section, preset DevicePreset) []Section {
+	if preset.Width >= stackTablesMaxWidth {
+		return sections
+	}
+
+	result := make([]Section, len(sections))
+	for i, section := range sections {
+		section.Content = reTableBlock.ReplaceAllStringFunc(section.Content, stackTable)
+		result[i] = section
+	}
+	return result
+}
+
+// stackTable converts one rendered table into a stacked definition list.
+// Header labels come from the first row; rows with more cells than headers
+// fall back to positional "Column N" labels. Tables without a data row are
+// left alone.
+func stackTable(table string) string {
+	rows := reTableRow.FindAllStringSubmatch(table, -1)
+	if len(rows) < 2 {
+		return table
+	}
+
+	var headers []string
+	for _, cell := range reTableCell.FindAllStringSubmatch(rows[0][1], -1) {
+		headers = append(headers, strings.TrimSpace(cell[1]))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<div class=\"stacked-table\">\n")
+	for _, row := range rows[1:] {
+		cells := reTableCell.FindAllStringSubmatch(row[1], -1)
+		if len(cells) == 0 {
+			continue
+		}
+		sb.WriteString("<dl class=\"stacked-row\">\n")
+		for j, cell := range cells {
+			label := fmt.Sprintf("Column %d", j+1)
+			if j < len(headers) && headers[j] != "" {
+				label = headers[j]
+			}
+			sb.WriteString(fmt.Sprintf("<dt>%s</dt><dd>%s</dd>\n", label, strings.TrimSpace(cell[1])))
+		}
+		sb.WriteString("</dl>\n")
+	}
+	sb.WriteString("</div>")
+	return sb.String()
+}
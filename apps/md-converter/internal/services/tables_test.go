@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// tableMD is a 3-column GFM table that overflows a 480px screen.
+const tableMD = "# Specs\n\n| Device | Width | Height |\n|---|---|---|\n| Xtreink X4 | 480 | 800 |\n| Kindle | 1264 | 1680 |\n"
+
+func TestConvert_StackTablesOnNarrowPreset(t *testing.T) {
+	result, err := Convert([]byte(tableMD), ConvertOptions{
+		Preset:      DevicePresets[0], // Xtreink X4, 480px
+		Title:       "Test",
+		StackTables: true,
+	})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/specs.xhtml")
+	for _, want := range []string{`<div class="stacked-table">`, "<dt>Device</dt>", "<dd>Xtreink X4</dd>", "<dt>Width</dt>", "<dd>1264</dd>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<table>") {
+		t.Errorf("table markup survived stacking:\n%s", page)
+	}
+}
+
+func TestConvert_StackTablesWidePresetUnchanged(t *testing.T) {
+	result, err := Convert([]byte(tableMD), ConvertOptions{
+		Preset:      DevicePresets[2], // Kindle, 1264px
+		Title:       "Test",
+		StackTables: true,
+	})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/specs.xhtml")
+	if !strings.Contains(page, "<table>") || strings.Contains(page, "stacked-table") {
+		t.Errorf("wide preset should keep the real table:\n%s", page)
+	}
+}
+
+func TestStackTable_ColumnFallbackLabels(t *testing.T) {
+	content := "<table>\n<tr><th>Name</th></tr>\n<tr><td>a</td><td>extra</td></tr>\n</table>"
+	sections := StackTables([]Section{{ID: 1, Title: "T", Level: 1, Content: content}}, DevicePresets[0])
+
+	got := sections[0].Content
+	if !strings.Contains(got, "<dt>Name</dt><dd>a</dd>") {
+		t.Errorf("header label missing:\n%s", got)
+	}
+	if !strings.Contains(got, "<dt>Column 2</dt><dd>extra</dd>") {
+		t.Errorf("positional fallback missing:\n%s", got)
+	}
+}
+
+func TestStackTable_HeaderOnlyTableUntouched(t *testing.T) {
+	content := "<table>\n<tr><th>Only Headers</th></tr>\n</table>"
+	sections := StackTables([]Section{{ID: 1, Title: "T", Level: 1, Content: content}}, DevicePresets[0])
+	if sections[0].Content != content {
+		t.Errorf("header-only table was rewritten:\n%s", sections[0].Content)
+	}
+}
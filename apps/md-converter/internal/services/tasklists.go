@@ -0,0 +1,38 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// reTaskItem matches a task-list item's leading checkbox input as goldmark's
+// GFM extension renders it. Literal checkbox HTML in code blocks is
+// entity-escaped and can never match; requiring the enclosing <li> keeps raw
+// inputs elsewhere in the document untouched.
+var reTaskItem = regexp.MustCompile(`(?i)<li([^>]*)>\s*<input[^>]*type="checkbox"[^>]*>`)
+
+// ReplaceTaskCheckboxes rewrites GFM task-list checkboxes as printable text
+// symbols: disabled <input> elements are dropped outright by many e-readers,
+// losing the done/undone distinction. Unchecked items become ☐ and checked
+// items ☑; with ascii set, [ ] and [x] are used instead for fonts that lack
+// the glyphs. The input slice is not modified.
+func ReplaceTaskCheckboxes(sections []Section, ascii bool) []Section {
+	unchecked, checked := "☐", "☑"
+	if ascii {
+		unchecked, checked = "[ ]", "[x]"
+	}
+
+	result := make([]Section, len(sections))
+	for i, section := range sections {
+		section.Content = reTaskItem.ReplaceAllStringFunc(section.Content, func(item string) string {
+			box := unchecked
+			if strings.Contains(strings.ToLower(item), "checked") {
+				box = checked
+			}
+			liEnd := strings.Index(item, ">")
+			return item[:liEnd+1] + `<span class="task-box">` + box + `</span>`
+		})
+		result[i] = section
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// taskListMD nests a checked sub-item under an unchecked parent.
+const taskListMD = "# Todo\n\n- [ ] outer task\n  - [x] nested done\n  - [ ] nested open\n- [x] finished task\n"
+
+func TestConvert_TaskListGlyphs(t *testing.T) {
+	result, err := Convert([]byte(taskListMD), ConvertOptions{Title: "Test"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/todo.xhtml")
+	if got := strings.Count(page, `<span class="task-box">☐</span>`); got != 2 {
+		t.Errorf("want 2 unchecked boxes, got %d:\n%s", got, page)
+	}
+	if got := strings.Count(page, `<span class="task-box">☑</span>`); got != 2 {
+		t.Errorf("want 2 checked boxes, got %d:\n%s", got, page)
+	}
+	if strings.Contains(page, "<input") {
+		t.Errorf("checkbox input survived replacement:\n%s", page)
+	}
+}
+
+func TestConvert_TaskListASCIIBoxes(t *testing.T) {
+	result, err := Convert([]byte(taskListMD), ConvertOptions{Title: "Test", ASCIICheckboxes: true})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/todo.xhtml")
+	if !strings.Contains(page, `<span class="task-box">[ ]</span>`) {
+		t.Errorf("unchecked ASCII box missing:\n%s", page)
+	}
+	if !strings.Contains(page, `<span class="task-box">[x]</span>`) {
+		t.Errorf("checked ASCII box missing:\n%s", page)
+	}
+	if strings.Contains(page, "☐") || strings.Contains(page, "☑") {
+		t.Errorf("glyphs present despite ASCIICheckboxes:\n%s", page)
+	}
+}
+
+func TestConvert_TaskListCodeBlockUntouched(t *testing.T) {
+	md := "# Code\n\n```html\n<li><input disabled=\"\" type=\"checkbox\"> literal\n```\n"
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Test"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	page := readEPUBEntry(t, result.EPUBData, "OEBPS/code.xhtml")
+	if !strings.Contains(page, "&lt;input disabled=&quot;&quot; type=&quot;checkbox&quot;&gt;") {
+		t.Errorf("escaped checkbox HTML missing from code block:\n%s", page)
+	}
+	if strings.Contains(page, "task-box") {
+		t.Errorf("code block content was rewritten:\n%s", page)
+	}
+}
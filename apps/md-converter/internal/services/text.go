@@ -0,0 +1,71 @@
+package services
+
+import (
+	"bytes"
+	stdhtml "html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// reBlockEnd matches the closing tags (and <br>) that end a line of text.
+	reBlockEnd = regexp.MustCompile(`(?i)</(?:p|div|h[1-6]|li|ul|ol|pre|blockquote|table|tr|dl|dd)>|<br\s*/?>`)
+	// reListOpen matches an opening list-item tag, which becomes a bullet.
+	reListOpen = regexp.MustCompile(`(?i)<li[^>]*>`)
+	// reRunSpaces matches interior runs of spaces/tabs after a non-space
+	// character, so collapsing them leaves code-block indentation alone.
+	reRunSpaces = regexp.MustCompile(`(\S)[ \t]{2,}`)
+)
+
+// SectionsToText renders sections as a plain-text document for minimalist
+// readers that only open .txt files. Each section title is underlined ("=" for
+// top-level sections, "-" below), list items get "- " bullets, block elements
+// end their line, and the remaining markup is stripped and unescaped. Sections
+// are separated by a blank line.
+func SectionsToText(sections []Section) []byte {
+	var buf bytes.Buffer
+	for i, section := range sections {
+		if i > 0 {
+			buf.WriteString("\n\n")
+		}
+		underline := "="
+		if section.Level > 1 {
+			underline = "-"
+		}
+		buf.WriteString(section.Title)
+		buf.WriteByte('\n')
+		buf.WriteString(strings.Repeat(underline, len([]rune(section.Title))))
+		buf.WriteString("\n\n")
+		buf.WriteString(htmlToText(section.Content))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// htmlToText strips section HTML down to readable text: bullets for list
+// items, newlines for block boundaries, tags removed, entities unescaped, and
+// whitespace normalized without disturbing code-block indentation.
+func htmlToText(content string) string {
+	text := reListOpen.ReplaceAllString(content, "- ")
+	text = reBlockEnd.ReplaceAllString(text, "\n")
+	text = reTag.ReplaceAllString(text, "")
+	text = stdhtml.UnescapeString(text)
+	text = reRunSpaces.ReplaceAllString(text, "$1 ")
+
+	lines := strings.Split(text, "\n")
+	var out []string
+	blank := true // swallow leading blank lines
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			if !blank {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		out = append(out, line)
+		blank = false
+	}
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
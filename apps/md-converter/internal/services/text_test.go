@@ -0,0 +1,54 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSectionsToText_HeadingsUnderlined(t *testing.T) {
+	md := "# Intro\n\nFirst paragraph.\n\n## Details\n\nSecond paragraph.\n"
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+
+	text := string(SectionsToText(sections))
+	for _, want := range []string{"Intro\n=====\n", "Details\n-------\n", "First paragraph.", "Second paragraph."} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "<") {
+		t.Errorf("markup survived stripping:\n%s", text)
+	}
+}
+
+func TestSectionsToText_ListBullets(t *testing.T) {
+	md := "# Shopping\n\n- apples\n- pears\n  - conference\n"
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+
+	text := string(SectionsToText(sections))
+	for _, want := range []string{"- apples", "- pears", "- conference"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestSectionsToText_CodeBlockReadable(t *testing.T) {
+	md := "# Code\n\n```go\nif a < b {\n    return \"less\"\n}\n```\n"
+	sections, err := ParseMD([]byte(md))
+	if err != nil {
+		t.Fatalf("ParseMD: %v", err)
+	}
+
+	text := string(SectionsToText(sections))
+	for _, want := range []string{"if a < b {", "\nreturn \"less\"\n", "\n}"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q (entities unescaped, lines kept):\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// reHexSegment matches commit hashes and gist ids, which are never titles.
+var reHexSegment = regexp.MustCompile(`(?i)^[0-9a-f]{7,}$`)
+
+// skipSegments are the path components raw-file hosts insert around the real
+// file name (GitHub/GitLab raw routes, branch names, gist plumbing).
+var skipSegments = map[string]bool{
+	"raw":      true,
+	"blob":     true,
+	"download": true,
+	"main":     true,
+	"master":   true,
+	"refs":     true,
+	"heads":    true,
+}
+
+// TitleFromURL derives a readable document title from the last meaningful
+// path segment of a raw-file URL: routing segments, branch names and hex ids
+// are skipped, the extension is stripped, dashes and underscores become
+// spaces, and each word gets a capital. Returns "" when nothing usable is
+// left, e.g. for a bare host URL.
+func TitleFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	segments := strings.Split(u.Path, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment, err := url.PathUnescape(segments[i])
+		if err != nil {
+			segment = segments[i]
+		}
+		if segment == "" || skipSegments[strings.ToLower(segment)] || reHexSegment.MatchString(segment) {
+			continue
+		}
+		name := strings.TrimSuffix(segment, path.Ext(segment))
+		name = strings.NewReplacer("-", " ", "_", " ").Replace(name)
+		name = strings.Join(strings.Fields(name), " ")
+		if name == "" {
+			continue
+		}
+		return capitalizeWords(name)
+	}
+	return ""
+}
+
+// capitalizeWords upper-cases the first rune of each word, leaving the rest
+// alone so acronyms like README survive.
+func capitalizeWords(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,54 @@
+package services
+
+import "testing"
+
+func TestTitleFromURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "github raw",
+			url:  "https://raw.githubusercontent.com/toozej/tools/main/docs/getting-started.md",
+			want: "Getting Started",
+		},
+		{
+			name: "github readme keeps caps",
+			url:  "https://raw.githubusercontent.com/toozej/tools/main/README.md",
+			want: "README",
+		},
+		{
+			name: "gitlab raw route",
+			url:  "https://gitlab.com/group/project/-/raw/main/user_guide.md",
+			want: "User Guide",
+		},
+		{
+			name: "gist with file name",
+			url:  "https://gist.githubusercontent.com/alice/0123456789abcdef/raw/fedcba98765/notes-2024.md",
+			want: "Notes 2024",
+		},
+		{
+			name: "gist without file name falls back past hashes",
+			url:  "https://gist.githubusercontent.com/alice/0123456789abcdef/raw",
+			want: "Alice",
+		},
+		{
+			name: "escaped spaces",
+			url:  "https://example.com/files/meeting%20notes.md",
+			want: "Meeting Notes",
+		},
+		{
+			name: "bare host",
+			url:  "https://example.com/",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TitleFromURL(tt.url); got != tt.want {
+				t.Errorf("TitleFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+)
+
+var (
+	reFullPath = regexp.MustCompile(`full-path="([^"]+)"`)
+	reItemTag  = regexp.MustCompile(`<item\s[^>]*/?>`)
+	reIDAttr   = regexp.MustCompile(`\bid="([^"]+)"`)
+	reHrefAttr = regexp.MustCompile(`\bhref="([^"]+)"`)
+	reItemref  = regexp.MustCompile(`<itemref\s+idref="([^"]+)"`)
+)
+
+// ValidateEPUB checks the structural invariants of a generated EPUB: the
+// mimetype entry must come first and be stored uncompressed, the container
+// must point to a package document that exists, and every spine itemref must
+// resolve to a manifest item whose file is present in the archive. It does not
+// validate the XHTML content itself.
+func ValidateEPUB(data []byte) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("epub: open zip: %w", err)
+	}
+	if len(zr.File) == 0 {
+		return fmt.Errorf("epub: archive has no entries")
+	}
+
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+
+	// mimetype: first entry, stored, with the EPUB media type.
+	first := zr.File[0]
+	if first.Name != "mimetype" {
+		return fmt.Errorf("epub: first entry is %q, want mimetype", first.Name)
+	}
+	if first.Method != zip.Store {
+		return fmt.Errorf("epub: mimetype entry is compressed, must be stored")
+	}
+	mimetype, err := readZipEntry(first)
+	if err != nil {
+		return fmt.Errorf("epub: read mimetype: %w", err)
+	}
+	if string(mimetype) != "application/epub+zip" {
+		return fmt.Errorf("epub: mimetype is %q, want application/epub+zip", mimetype)
+	}
+
+	// container.xml: present and pointing at an existing package document.
+	container, ok := entries["META-INF/container.xml"]
+	if !ok {
+		return fmt.Errorf("epub: missing META-INF/container.xml")
+	}
+	containerXML, err := readZipEntry(container)
+	if err != nil {
+		return fmt.Errorf("epub: read container.xml: %w", err)
+	}
+	m := reFullPath.FindSubmatch(containerXML)
+	if m == nil {
+		return fmt.Errorf("epub: container.xml has no rootfile full-path")
+	}
+	opfPath := string(m[1])
+	opfFile, ok := entries[opfPath]
+	if !ok {
+		return fmt.Errorf("epub: package document %q referenced by container.xml is missing", opfPath)
+	}
+	opf, err := readZipEntry(opfFile)
+	if err != nil {
+		return fmt.Errorf("epub: read %s: %w", opfPath, err)
+	}
+
+	// Manifest: every item's file must exist, resolved relative to the OPF.
+	opfDir := path.Dir(opfPath)
+	manifestIDs := make(map[string]bool)
+	for _, tag := range reItemTag.FindAllString(string(opf), -1) {
+		id := reIDAttr.FindStringSubmatch(tag)
+		href := reHrefAttr.FindStringSubmatch(tag)
+		if id == nil || href == nil {
+			return fmt.Errorf("epub: manifest item without id or href: %s", tag)
+		}
+		if _, ok := entries[path.Join(opfDir, href[1])]; !ok {
+			return fmt.Errorf("epub: manifest item %q file %q is missing", id[1], href[1])
+		}
+		manifestIDs[id[1]] = true
+	}
+
+	// Spine: every itemref must resolve to a manifest item.
+	for _, m := range reItemref.FindAllStringSubmatch(string(opf), -1) {
+		if !manifestIDs[m[1]] {
+			return fmt.Errorf("epub: spine itemref %q has no manifest item", m[1])
+		}
+	}
+
+	return nil
+}
+
+// readZipEntry returns the full decompressed content of a zip entry.
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
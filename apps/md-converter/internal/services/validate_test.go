@@ -0,0 +1,167 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// zipEntry is one archive entry in order, for rebuilding corrupted EPUBs.
+type zipEntry struct {
+	name    string
+	content []byte
+}
+
+// readEntries returns all entries of an EPUB in archive order.
+func readEntries(t *testing.T, data []byte) []zipEntry {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open epub: %v", err)
+	}
+	entries := make([]zipEntry, 0, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		entries = append(entries, zipEntry{f.Name, content})
+	}
+	return entries
+}
+
+// writeEntries rebuilds an EPUB from entries in order. The mimetype entry is
+// stored uncompressed unless compressMimetype is set.
+func writeEntries(t *testing.T, entries []zipEntry, compressMimetype bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, e := range entries {
+		var f io.Writer
+		var err error
+		if e.name == "mimetype" && !compressMimetype {
+			f, err = w.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Store})
+		} else {
+			f, err = w.Create(e.name)
+		}
+		if err != nil {
+			t.Fatalf("create %s: %v", e.name, err)
+		}
+		if _, err := f.Write(e.content); err != nil {
+			t.Fatalf("write %s: %v", e.name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// validEPUB converts a small document and sanity-checks the result.
+func validEPUB(t *testing.T) []byte {
+	t.Helper()
+	result, err := Convert([]byte("# Alpha\n\nbody\n\n# Beta\n\nmore\n"), ConvertOptions{Title: "Test"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	return result.EPUBData
+}
+
+func TestValidateEPUB_Valid(t *testing.T) {
+	if err := ValidateEPUB(validEPUB(t)); err != nil {
+		t.Errorf("ValidateEPUB on generated epub: %v", err)
+	}
+}
+
+func TestValidateEPUB_NotAZip(t *testing.T) {
+	if err := ValidateEPUB([]byte("not a zip")); err == nil {
+		t.Error("expected error for non-zip data")
+	}
+}
+
+func TestValidateEPUB_MimetypeNotFirst(t *testing.T) {
+	entries := readEntries(t, validEPUB(t))
+	// Move mimetype to the end.
+	entries = append(entries[1:], entries[0])
+
+	err := ValidateEPUB(writeEntries(t, entries, false))
+	if err == nil || !strings.Contains(err.Error(), "first entry") {
+		t.Errorf("got %v, want first-entry error", err)
+	}
+}
+
+func TestValidateEPUB_MimetypeCompressed(t *testing.T) {
+	entries := readEntries(t, validEPUB(t))
+
+	err := ValidateEPUB(writeEntries(t, entries, true))
+	if err == nil || !strings.Contains(err.Error(), "stored") {
+		t.Errorf("got %v, want stored-mimetype error", err)
+	}
+}
+
+func TestValidateEPUB_MissingContainer(t *testing.T) {
+	var entries []zipEntry
+	for _, e := range readEntries(t, validEPUB(t)) {
+		if e.name != "META-INF/container.xml" {
+			entries = append(entries, e)
+		}
+	}
+
+	err := ValidateEPUB(writeEntries(t, entries, false))
+	if err == nil || !strings.Contains(err.Error(), "container.xml") {
+		t.Errorf("got %v, want missing-container error", err)
+	}
+}
+
+func TestValidateEPUB_MissingPackageDocument(t *testing.T) {
+	var entries []zipEntry
+	for _, e := range readEntries(t, validEPUB(t)) {
+		if e.name == "OEBPS/content.opf" {
+			e.name = "OEBPS/renamed.opf"
+		}
+		entries = append(entries, e)
+	}
+
+	err := ValidateEPUB(writeEntries(t, entries, false))
+	if err == nil || !strings.Contains(err.Error(), "package document") {
+		t.Errorf("got %v, want missing-package error", err)
+	}
+}
+
+func TestValidateEPUB_ManifestFileMissing(t *testing.T) {
+	var entries []zipEntry
+	for _, e := range readEntries(t, validEPUB(t)) {
+		if e.name == "OEBPS/alpha.xhtml" {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	err := ValidateEPUB(writeEntries(t, entries, false))
+	if err == nil || !strings.Contains(err.Error(), "manifest item") {
+		t.Errorf("got %v, want manifest-file error", err)
+	}
+}
+
+func TestValidateEPUB_SpineItemrefUnresolved(t *testing.T) {
+	var entries []zipEntry
+	for _, e := range readEntries(t, validEPUB(t)) {
+		if e.name == "OEBPS/content.opf" {
+			e.content = []byte(strings.Replace(string(e.content),
+				`<itemref idref="beta"/>`, `<itemref idref="ghost"/>`, 1))
+		}
+		entries = append(entries, e)
+	}
+
+	err := ValidateEPUB(writeEntries(t, entries, false))
+	if err == nil || !strings.Contains(err.Error(), "spine itemref") {
+		t.Errorf("got %v, want spine-itemref error", err)
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// reTag matches any HTML tag, used to strip markup before counting words.
+var reTag = regexp.MustCompile(`<[^>]*>`)
+
+// CountWords returns the number of whitespace-separated words in the
+// section's rendered content after stripping tags and decoding entities.
+func CountWords(content string) int {
+	text := reTag.ReplaceAllString(content, " ")
+	text = html.UnescapeString(text)
+	return len(strings.Fields(text))
+}
+
+// formatThousands renders n with comma grouping ("1240" → "1,240") for the
+// TOC word-count annotations.
+func formatThousands(n int) string {
+	s := fmt.Sprintf("%d", n)
+	if len(s) <= 3 {
+		return s
+	}
+	var sb strings.Builder
+	lead := len(s) % 3
+	if lead == 0 {
+		lead = 3
+	}
+	sb.WriteString(s[:lead])
+	for i := lead; i < len(s); i += 3 {
+		sb.WriteByte(',')
+		sb.WriteString(s[i : i+3])
+	}
+	return sb.String()
+}
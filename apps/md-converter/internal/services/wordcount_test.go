@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		content string
+		want    int
+	}{
+		{"<p>one two three</p>", 3},
+		{"<p>tags<em>do</em>not glue words</p>", 5},
+		{"<p>&amp; entities count as words</p>", 5},
+		{"", 0},
+		{"<hr/>", 0},
+	}
+	for _, tt := range tests {
+		if got := CountWords(tt.content); got != tt.want {
+			t.Errorf("CountWords(%q) = %d, want %d", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestFormatThousands(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "0"},
+		{42, "42"},
+		{999, "999"},
+		{1240, "1,240"},
+		{12345, "12,345"},
+		{1234567, "1,234,567"},
+	}
+	for _, tt := range tests {
+		if got := formatThousands(tt.n); got != tt.want {
+			t.Errorf("formatThousands(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestConvert_WordCountsInNav(t *testing.T) {
+	md := "# Alpha\n\n" + strings.Repeat("word ", 1240) + "\n\n# Beta\n\nshort body here\n"
+
+	result, err := Convert([]byte(md), ConvertOptions{Title: "Test", ShowWordCounts: true})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	if len(result.SectionStats) != 2 {
+		t.Fatalf("SectionStats: got %d entries, want 2", len(result.SectionStats))
+	}
+	if result.SectionStats[0].Words != 1240 {
+		t.Errorf("Alpha words: got %d, want 1240", result.SectionStats[0].Words)
+	}
+
+	nav := readEPUBEntry(t, result.EPUBData, "OEBPS/nav.xhtml")
+	for _, stat := range result.SectionStats {
+		annotation := fmt.Sprintf("(%s words)", formatThousands(stat.Words))
+		if !strings.Contains(nav, annotation) {
+			t.Errorf("nav missing annotation %q for section %q:\n%s", annotation, stat.Title, nav)
+		}
+	}
+}
+
+func TestConvert_WordCountsOffByDefault(t *testing.T) {
+	result, err := Convert([]byte("# Alpha\n\nsome words here\n"), ConvertOptions{Title: "Test"})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	nav := readEPUBEntry(t, result.EPUBData, "OEBPS/nav.xhtml")
+	if strings.Contains(nav, "words)") {
+		t.Errorf("nav has word-count annotations without the option:\n%s", nav)
+	}
+
+	// The statistics are still reported so callers can show their own totals.
+	if len(result.SectionStats) != 1 || result.SectionStats[0].Words != 3 {
+		t.Errorf("SectionStats: got %+v, want one entry with 3 words", result.SectionStats)
+	}
+}
@@ -0,0 +1,26 @@
+// Package mdconverter is the public surface of the md-converter module for
+// tools that want the parsed sections without going through EPUB generation,
+// e.g. to build PDF or standalone HTML output from the same parser.
+package mdconverter
+
+import "md-converter/internal/services"
+
+// Section is one unit of parsed content: an optional heading plus everything
+// up to the next heading. Its fields — Title (heading text after numbering),
+// Level (heading level 1–6, 1 for the implicit intro section) and Content
+// (rendered HTML) — are stable and safe for downstream renderers to depend
+// on.
+type Section = services.Section
+
+// ConvertOptions configures parsing and the section passes; see the field
+// comments on the underlying type. Options that only affect EPUB generation
+// (compression, size budget, fixed layout, spacing overrides) are ignored by
+// ParseSections.
+type ConvertOptions = services.ConvertOptions
+
+// ParseSections parses Markdown bytes and runs the converter's section passes
+// (exclusion, merging, numbering, splitting, footnote links, …), returning
+// the sections a conversion with the same options would write to the EPUB.
+func ParseSections(md []byte, opts ConvertOptions) ([]Section, error) {
+	return services.ParseSections(md, opts)
+}
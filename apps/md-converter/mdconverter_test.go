@@ -0,0 +1,38 @@
+package mdconverter
+
+import (
+	"reflect"
+	"testing"
+
+	"md-converter/internal/services"
+)
+
+func TestParseSections_MatchesInternalPipeline(t *testing.T) {
+	md := []byte("# Intro\n\nHello.\n\n# License\n\nMIT.\n\n## Details\n\nMore text.\n")
+	opts := ConvertOptions{NumberSections: true, ExcludePatterns: "license"}
+
+	got, err := ParseSections(md, opts)
+	if err != nil {
+		t.Fatalf("ParseSections: %v", err)
+	}
+	want, err := services.ParseSections(md, opts)
+	if err != nil {
+		t.Fatalf("services.ParseSections: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("public wrapper diverged from internal parsing:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("len(sections) = %d, want 2 (license excluded)", len(got))
+	}
+	if got[0].Title != "1 Intro" {
+		t.Errorf("sections[0].Title = %q, want numbered title", got[0].Title)
+	}
+	if got[1].Level != 2 {
+		t.Errorf("sections[1].Level = %d, want 2", got[1].Level)
+	}
+	if got[1].Content == "" {
+		t.Errorf("sections[1].Content is empty")
+	}
+}
@@ -28,16 +28,30 @@ window.readFileAsBase64 = function (file) {
 /**
  * Fetches a URL and returns the response as a base64-encoded string
  * via the global onFileRead(name, base64) callback set by Go.
+ * Failures are reported through onFetchError(message) with distinct
+ * messages for network errors, HTTP status codes, and non-text content.
  * @param {string} url
  * @returns {Promise<void>}
  */
 window.fetchURLAsBase64 = function (url) {
-    const name = url.split('/').pop() || 'deck.apkg';
+    const name = url.split('/').pop() || 'document.md';
     return fetch(url)
         .then(function (resp) {
             if (!resp.ok) {
                 throw new Error('HTTP ' + resp.status + ' ' + resp.statusText);
             }
+            // Reject obviously binary payloads before decoding them.
+            const ctype = (resp.headers.get('content-type') || '').toLowerCase();
+            const textual = ctype === '' ||
+                ctype.startsWith('text/') ||
+                ctype.includes('markdown') ||
+                ctype.includes('html') ||
+                ctype.includes('xml') ||
+                ctype.includes('json') ||
+                ctype.includes('octet-stream');
+            if (!textual) {
+                throw new Error('unsupported content type ' + ctype.split(';')[0] + ' (expected a text document)');
+            }
             return resp.arrayBuffer();
         })
         .then(function (buf) {
@@ -50,8 +64,15 @@ window.fetchURLAsBase64 = function (url) {
         })
         .catch(function (err) {
             console.error('fetchURLAsBase64 error:', err);
-            if (typeof window.onFileRead === 'function') {
-                // Signal error by passing empty base64
+            let msg = err && err.message ? err.message : String(err);
+            if (err instanceof TypeError) {
+                // fetch() collapses DNS failures, offline and CORS into TypeError.
+                msg = 'network error — DNS failure, offline, or CORS blocked';
+            }
+            if (typeof window.onFetchError === 'function') {
+                window.onFetchError(msg);
+            } else if (typeof window.onFileRead === 'function') {
+                // Legacy signal: empty name and data.
                 window.onFileRead('', '');
             }
         });